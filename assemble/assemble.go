@@ -0,0 +1,491 @@
+// Package assemble turns IconVG assembly text back into an IconVG graphic.
+//
+// It reads two forms of text. The first is the annotated listing produced by
+// decode.Disassemble, like the testdata/*.ivg.disassembly files. Only the
+// annotations are read; the hex bytes in the first column are ignored, so the
+// numbers in the annotations can be edited. The second form is a cleaner
+// mnemonic form, one statement per line, as produced by Format:
+//
+//	# A comment runs to the end of the line.
+//	viewBox -32 -32 32 32
+//	palette RGBA 76e1feff
+//	creg 1 RGBA ff0000ff
+//	lod 0 80
+//	start 1 -28 -20
+//	V -28
+//	H -20
+//	z
+//
+// The metadata statements come first:
+//
+//	viewBox minX minY maxX maxY
+//	palette color        sets the next color of the suggested palette
+//
+// They are followed by styling statements:
+//
+//	csel n               sets CSEL
+//	nsel n               sets NSEL
+//	creg adj color       sets CREG[CSEL-adj]
+//	creg+ color          sets CREG[CSEL] and increments CSEL
+//	nreg adj number      sets NREG[NSEL-adj]
+//	nreg+ number         sets NREG[NSEL] and increments NSEL
+//	lod lod0 lod1        sets the level of detail range
+//	start adj x y        starts a path filled with CREG[CSEL-adj]
+//
+// A path is made of drawing statements, named after the SVG path commands:
+// L, l, T, t, Q, q, S, s, C, c, A, a, H, h, V and v take the same arguments
+// as their SVG counterparts, and repeat when given more. The x-axis rotation
+// of A and a is in full turns, as in ivg.Destination, and not in degrees.
+// The statement z closes the path and ends it, and "z M x y" and "z m x y"
+// close the path and start a new one.
+//
+// Colors are written as ivg.Color's String method prints them: "RGBA
+// rrggbbaa", "customPalette[i]", "CREG[i]", "blend (255-t:t) (c0:c1)" or
+// "gradient (NSTOPS=n, CBASE=c, NBASE=n, shape, spread)". Numbers are
+// written as Go's strconv.ParseFloat reads them, including "+Inf".
+//
+// Coordinates are encoded as written, so they are not quantized to 1/64 of a
+// unit. Multiples of 1/64 in the range [-128, 128) encode in at most 2 bytes.
+package assemble
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/encode"
+)
+
+// Error is an error in assembly text, at a 1-based line number.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("assemble: line %d: %s", e.Line, e.Msg) }
+
+// Assemble parses the assembly text in src and returns the encoded IconVG
+// graphic that it describes.
+func Assemble(src []byte) ([]byte, error) {
+	var e encoder
+	if err := Parse(&e, src); err != nil {
+		return nil, err
+	}
+	return e.Bytes()
+}
+
+// Parse parses the assembly text in src and sends the graphic that it
+// describes to dst.
+func Parse(dst ivg.Destination, src []byte) error {
+	a := &assembler{dst: dst, metadata: ivg.DefaultMetadata}
+	var err error
+	if bytes.HasPrefix(src, []byte(listingMagic)) {
+		err = a.parseListing(src)
+	} else {
+		err = a.parseMnemonics(src)
+	}
+	if err != nil {
+		return err
+	}
+	if a.drawing {
+		return &Error{a.pathLine, "path is not closed"}
+	}
+	a.reset()
+	return nil
+}
+
+// encoder is an encode.Encoder that encodes coordinates as they are written,
+// instead of quantizing them to 1/64 of a unit.
+type encoder struct {
+	encode.Encoder
+}
+
+func (e *encoder) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	e.Encoder.Reset(viewbox, palette)
+	e.HighResolutionCoordinates = true
+}
+
+// assembler drives dst with the statements of either form of text.
+type assembler struct {
+	dst      ivg.Destination
+	metadata ivg.Metadata
+	nPalette int
+	// started is whether dst has been Reset with the metadata.
+	started bool
+	drawing bool
+	line    int
+	// pathLine is the line of the statement that started the current path.
+	pathLine int
+}
+
+func (a *assembler) errorf(format string, args ...interface{}) error {
+	return &Error{a.line, fmt.Sprintf(format, args...)}
+}
+
+// reset sends the metadata to dst, once, before any other statement.
+func (a *assembler) reset() {
+	if !a.started {
+		a.dst.Reset(a.metadata.ViewBox, a.metadata.Palette)
+		a.started = true
+	}
+}
+
+// nArgs is the number of arguments of one repetition of the statements that
+// take numbers only.
+var nArgs = map[string]int{
+	"viewBox": 4,
+	"csel":    1,
+	"nsel":    1,
+	"nreg":    2,
+	"nreg+":   1,
+	"lod":     2,
+	"start":   3,
+	"z":       0,
+	"zM":      2,
+	"zm":      2,
+	"L":       2, "l": 2,
+	"T": 2, "t": 2,
+	"Q": 4, "q": 4,
+	"S": 4, "s": 4,
+	"C": 6, "c": 6,
+	"A": 7, "a": 7,
+	"H": 1, "h": 1,
+	"V": 1, "v": 1,
+}
+
+// exec executes the statement op with the numeric arguments args.
+func (a *assembler) exec(op string, args []float32) error {
+	n, ok := nArgs[op]
+	if !ok {
+		return a.errorf("unknown statement %q", op)
+	}
+	// The drawing statements, except z, repeat when given more numbers.
+	repeats := len(op) == 1 && op != "z"
+	if len(args) != n && !(repeats && len(args) > 0 && len(args)%n == 0) {
+		return a.errorf("%s takes %d numbers, got %d", op, n, len(args))
+	}
+	if op == "viewBox" {
+		if a.started {
+			return a.errorf("viewBox after the metadata")
+		}
+		vb := ivg.ViewBox{MinX: args[0], MinY: args[1], MaxX: args[2], MaxY: args[3]}
+		if !(vb.MinX <= vb.MaxX) || !(vb.MinY <= vb.MaxY) {
+			return a.errorf("invalid viewBox")
+		}
+		a.metadata.ViewBox = vb
+		return nil
+	}
+	a.reset()
+
+	if len(op) == 1 || op == "zM" || op == "zm" {
+		if !a.drawing {
+			return a.errorf("%s outside of a path", op)
+		}
+	} else if a.drawing {
+		return a.errorf("%s inside a path", op)
+	}
+	d := a.dst
+	switch op {
+	case "csel", "nsel":
+		u, err := a.selector(args[0], 63)
+		if err != nil {
+			return err
+		}
+		if op == "csel" {
+			d.SetCSel(u)
+		} else {
+			d.SetNSel(u)
+		}
+	case "nreg", "nreg+":
+		if op == "nreg+" {
+			d.SetNReg(0, true, args[0])
+			break
+		}
+		adj, err := a.selector(args[0], 6)
+		if err != nil {
+			return err
+		}
+		d.SetNReg(adj, false, args[1])
+	case "lod":
+		d.SetLOD(args[0], args[1])
+	case "start":
+		adj, err := a.selector(args[0], 6)
+		if err != nil {
+			return err
+		}
+		d.StartPath(adj, args[1], args[2])
+		a.drawing, a.pathLine = true, a.line
+	case "z":
+		d.ClosePathEndPath()
+		a.drawing = false
+	case "zM":
+		d.ClosePathAbsMoveTo(args[0], args[1])
+	case "zm":
+		d.ClosePathRelMoveTo(args[0], args[1])
+	default:
+		for ; len(args) > 0; args = args[n:] {
+			if err := a.draw(op[0], args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// draw executes one repetition of the drawing statement op.
+func (a *assembler) draw(op byte, args []float32) error {
+	d := a.dst
+	switch op {
+	case 'L':
+		d.AbsLineTo(args[0], args[1])
+	case 'l':
+		d.RelLineTo(args[0], args[1])
+	case 'T':
+		d.AbsSmoothQuadTo(args[0], args[1])
+	case 't':
+		d.RelSmoothQuadTo(args[0], args[1])
+	case 'Q':
+		d.AbsQuadTo(args[0], args[1], args[2], args[3])
+	case 'q':
+		d.RelQuadTo(args[0], args[1], args[2], args[3])
+	case 'S':
+		d.AbsSmoothCubeTo(args[0], args[1], args[2], args[3])
+	case 's':
+		d.RelSmoothCubeTo(args[0], args[1], args[2], args[3])
+	case 'C':
+		d.AbsCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
+	case 'c':
+		d.RelCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
+	case 'A', 'a':
+		if !(0 <= args[2] && args[2] < 1) {
+			return a.errorf("x-axis rotation %g is not in [0, 1)", args[2])
+		}
+		largeArc, sweep := args[3] != 0, args[4] != 0
+		if largeArc && args[3] != 1 || sweep && args[4] != 1 {
+			return a.errorf("arc flags must be 0 or 1")
+		}
+		if op == 'A' {
+			d.AbsArcTo(args[0], args[1], args[2], largeArc, sweep, args[5], args[6])
+		} else {
+			d.RelArcTo(args[0], args[1], args[2], largeArc, sweep, args[5], args[6])
+		}
+	case 'H':
+		d.AbsHLineTo(args[0])
+	case 'h':
+		d.RelHLineTo(args[0])
+	case 'V':
+		d.AbsVLineTo(args[0])
+	case 'v':
+		d.RelVLineTo(args[0])
+	}
+	return nil
+}
+
+// execColor executes the statement op, which takes a color, with the
+// numeric arguments args.
+func (a *assembler) execColor(op string, args []float32, c ivg.Color) error {
+	switch op {
+	case "palette":
+		rgba, ok := c.RGBA()
+		if a.started || !ok || len(args) != 0 {
+			return a.errorf("palette takes an RGBA color before any other statement")
+		}
+		if a.nPalette == len(a.metadata.Palette) {
+			return a.errorf("too many palette colors")
+		}
+		a.metadata.Palette[a.nPalette] = rgba
+		a.nPalette++
+		return nil
+	case "creg", "creg+":
+		if a.reset(); a.drawing {
+			return a.errorf("%s inside a path", op)
+		}
+		if op == "creg+" && len(args) == 0 {
+			a.dst.SetCReg(0, true, c)
+			return nil
+		} else if op == "creg" && len(args) == 1 {
+			adj, err := a.selector(args[0], 6)
+			if err != nil {
+				return err
+			}
+			a.dst.SetCReg(adj, false, c)
+			return nil
+		}
+		return a.errorf("%s takes a color after %d numbers", op, len(args))
+	}
+	return a.errorf("unknown statement %q", op)
+}
+
+// selector converts f, a selector or selector adjustment, to a uint8.
+func (a *assembler) selector(f float32, max uint8) (uint8, error) {
+	if u := uint8(f); float32(u) == f && u <= max {
+		return u, nil
+	}
+	return 0, a.errorf("%g is not an integer in [0, %d]", f, max)
+}
+
+// parseMnemonics parses the mnemonic form of assembly text.
+func (a *assembler) parseMnemonics(src []byte) error {
+	for i, line := range strings.Split(string(src), "\n") {
+		a.line = i + 1
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		op, fields := fields[0], fields[1:]
+		if op == "z" && len(fields) > 0 && (fields[0] == "M" || fields[0] == "m") {
+			op, fields = "z"+fields[0], fields[1:]
+		}
+		switch op {
+		case "palette", "creg", "creg+":
+			nNumbers := 0
+			if op == "creg" {
+				nNumbers = 1
+			}
+			if len(fields) < nNumbers {
+				return a.errorf("%s is missing a color", op)
+			}
+			args, err := a.parseNumbers(fields[:nNumbers])
+			if err != nil {
+				return err
+			}
+			c, err := a.parseColor(strings.Join(fields[nNumbers:], " "))
+			if err != nil {
+				return err
+			}
+			if err := a.execColor(op, args, c); err != nil {
+				return err
+			}
+		default:
+			args, err := a.parseNumbers(fields)
+			if err != nil {
+				return err
+			}
+			if err := a.exec(op, args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *assembler) parseNumbers(fields []string) ([]float32, error) {
+	args := make([]float32, len(fields))
+	for i, s := range fields {
+		f, err := a.parseNumber(s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = f
+	}
+	return args, nil
+}
+
+func (a *assembler) parseNumber(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, a.errorf("invalid number %q", s)
+	}
+	return float32(f), nil
+}
+
+var (
+	gradientShapes  = [2]string{"linear", "radial"}
+	gradientSpreads = [4]string{"none", "pad", "reflect", "repeat"}
+)
+
+// parseColor parses a color in one of the forms printed by ivg.Color's
+// String method.
+func (a *assembler) parseColor(s string) (ivg.Color, error) {
+	s = strings.TrimSpace(s)
+	var c ivg.Color
+	switch {
+	case strings.HasPrefix(s, "RGBA "):
+		hex := strings.TrimSpace(s[len("RGBA "):])
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			break
+		}
+		return ivg.RGBAColor(color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}), nil
+	case strings.HasPrefix(s, "customPalette[") && strings.HasSuffix(s, "]"):
+		i, err := strconv.ParseUint(s[len("customPalette["):len(s)-1], 10, 8)
+		if err != nil || i >= 64 {
+			break
+		}
+		return ivg.PaletteIndexColor(uint8(i)), nil
+	case strings.HasPrefix(s, "CREG[") && strings.HasSuffix(s, "]"):
+		i, err := strconv.ParseUint(s[len("CREG["):len(s)-1], 10, 8)
+		if err != nil || i >= 64 {
+			break
+		}
+		return ivg.CRegColor(uint8(i)), nil
+	case strings.HasPrefix(s, "blend (") && strings.HasSuffix(s, ")"):
+		i := strings.Index(s, ") (")
+		if i < 0 {
+			break
+		}
+		var t0, t1 int
+		if n, _ := fmt.Sscanf(s[len("blend ("):i], "%d:%d", &t0, &t1); n != 2 || t0+t1 != 0xff || t1 < 0 || t1 > 0xff {
+			break
+		}
+		pair := s[i+len(") (") : len(s)-1]
+		j := strings.IndexByte(pair, ':')
+		if j < 0 {
+			break
+		}
+		c0, err0 := a.parseColor1(pair[:j])
+		c1, err1 := a.parseColor1(pair[j+1:])
+		if err0 != nil || err1 != nil {
+			break
+		}
+		return ivg.BlendColor(uint8(t1), c0, c1), nil
+	case strings.HasPrefix(s, "gradient (") && strings.HasSuffix(s, ")"):
+		var nStops, cBase, nBase uint8
+		var shape, spread string
+		fields := strings.Split(s[len("gradient ("):len(s)-1], ",")
+		if len(fields) != 5 {
+			break
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if n, _ := fmt.Sscanf(strings.Join(fields[:3], " "), "NSTOPS=%d CBASE=%d NBASE=%d", &nStops, &cBase, &nBase); n != 3 {
+			break
+		}
+		shape, spread = fields[3], fields[4]
+		sh, sp := index(gradientShapes[:], shape), index(gradientSpreads[:], spread)
+		if sh < 0 || sp < 0 || nStops >= 64 || cBase >= 64 || nBase >= 64 {
+			break
+		}
+		return ivg.RGBAColor(ivg.EncodeGradient(cBase, nBase, uint8(sh), uint8(sp), nStops)), nil
+	}
+	return c, a.errorf("invalid color %q", s)
+}
+
+// parseColor1 parses a color that has a 1 byte encoding, as used by blend.
+func (a *assembler) parseColor1(s string) (uint8, error) {
+	c, err := a.parseColor(s)
+	if err != nil {
+		return 0, err
+	}
+	x, ok := c.Encode1()
+	if !ok {
+		return 0, a.errorf("color %q has no 1 byte encoding", s)
+	}
+	return x, nil
+}
+
+func index(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
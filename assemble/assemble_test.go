@@ -0,0 +1,153 @@
+package assemble
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testdataFilenames = []string{
+	"../testdata/action-info.lores",
+	"../testdata/action-info.hires",
+	"../testdata/arcs",
+	"../testdata/blank",
+	"../testdata/cowbell",
+	"../testdata/elliptical",
+	"../testdata/favicon",
+	"../testdata/gradient",
+	"../testdata/lod-polygon",
+	"../testdata/video-005.primitive",
+}
+
+func readFile(t *testing.T, filename string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.FromSlash(filename))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return b
+}
+
+// TestAssembleListing tests that assembling a disassembly gives back the
+// original bytes.
+func TestAssembleListing(t *testing.T) {
+	for _, filename := range testdataFilenames {
+		want := readFile(t, filename+".ivg")
+		got, err := Assemble(readFile(t, filename+".ivg.disassembly"))
+		if err != nil {
+			t.Errorf("%s: Assemble: %v", filename, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s:\ngot  % x\nwant % x", filename, got, want)
+		}
+	}
+}
+
+// TestAssembleFormat tests that assembling the mnemonic form gives back the
+// original bytes.
+func TestAssembleFormat(t *testing.T) {
+	for _, filename := range testdataFilenames {
+		want := readFile(t, filename+".ivg")
+		src, err := Format(want)
+		if err != nil {
+			t.Errorf("%s: Format: %v", filename, err)
+			continue
+		}
+		got, err := Assemble(src)
+		if err != nil {
+			t.Errorf("%s: Assemble: %v\n%s", filename, err, src)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s:\ngot  % x\nwant % x", filename, got, want)
+		}
+	}
+}
+
+// TestAssembleSource tests the hand-authored sources in testdata.
+func TestAssembleSource(t *testing.T) {
+	for _, filename := range []string{
+		"../testdata/elliptical",
+		"../testdata/lod-polygon",
+	} {
+		want := readFile(t, filename+".ivg")
+		got, err := Assemble(readFile(t, filename+".ivgasm"))
+		if err != nil {
+			t.Errorf("%s: Assemble: %v", filename, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s:\ngot  % x\nwant % x", filename, got, want)
+		}
+	}
+}
+
+func TestAssembleEquivalent(t *testing.T) {
+	testCases := [][]string{{
+		"start 0 1 2\nL 3 4\nL 5 6\nz\n",
+		"start 0 1 2\nL 3 4 5 6\nz\n",
+		"# comment\n\nstart 0 1 2 # comment\n  L 3 4 5 6\nz",
+	}, {
+		"viewBox -24 -24 24 24\nstart 0 0 0\nh 1\nz M 2 2\nv 3\nz\n",
+		"viewBox -24 -24 +24 +24\nstart 0 0 0\nh 1\nz M 2.0 2e0\nv 3\nz\n",
+	}, {
+		"creg 0 blend (191:64) (CREG[63]:customPalette[0])\n",
+		"creg 0 blend (191:64) (CREG[63]:customPalette[0]) # comment\n",
+	}, {
+		"creg 0 RGBA 80808080\n",
+		"creg 0 RGBA 80808080",
+	}}
+	for _, tc := range testCases {
+		want, err := Assemble([]byte(tc[0]))
+		if err != nil {
+			t.Errorf("%q: %v", tc[0], err)
+			continue
+		}
+		for _, src := range tc[1:] {
+			got, err := Assemble([]byte(src))
+			if err != nil {
+				t.Errorf("%q: %v", src, err)
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("%q:\ngot  % x\nwant % x", src, got, want)
+			}
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	testCases := []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"csel 1\nfoo 1 2\n", 2, "unknown statement"},
+		{"start 0 1\n", 1, "takes 3 numbers"},
+		{"start 0 1 2\nL 1 2 3\nz\n", 2, "takes 2 numbers"},
+		{"start 0 1 2\nL\nz\n", 2, "takes 2 numbers"},
+		{"start 7 1 2\nz\n", 1, "not an integer in [0, 6]"},
+		{"L 1 2\n", 1, "outside of a path"},
+		{"start 0 1 2\ncsel 1\n", 2, "inside a path"},
+		{"csel 0\nstart 0 1 2\nL 3 4\n", 2, "path is not closed"},
+		{"csel 1\nviewBox 0 0 1 1\n", 2, "viewBox after the metadata"},
+		{"viewBox 1 0 0 1\n", 1, "invalid viewBox"},
+		{"creg 0 RGBA ff\n", 1, "invalid color"},
+		{"creg 0 red\n", 1, "invalid color"},
+		{"creg 0 blend (191:63) (CREG[63]:customPalette[0])\n", 1, "invalid color"},
+		{"creg 0 gradient (NSTOPS=2, CBASE=10, NBASE=10, conic, pad)\n", 1, "invalid color"},
+		{"palette RGBA ff0000ff\ncsel 1\npalette RGBA ff0000ff\n", 3, "palette takes an RGBA color"},
+		{"start 0 1 2\nA 1 1 0 2 0 3 4\nz\n", 2, "arc flags"},
+		{"start 0 1 2\nA 1 1 1 0 0 3 4\nz\n", 2, "x-axis rotation"},
+		{"start 0 x 2\nz\n", 1, "invalid number"},
+		{"89 49 56 47   IconVG Magic identifier\n00            Number of metadata chunks: 0\nc0            Start path\n", 3, "unknown annotation"},
+	}
+	for _, tc := range testCases {
+		_, err := Assemble([]byte(tc.src))
+		e, ok := err.(*Error)
+		if !ok || e.Line != tc.line || !strings.Contains(e.Msg, tc.msg) {
+			t.Errorf("%q: got %v, want line %d: %s", tc.src, err, tc.line, tc.msg)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package assemble
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+)
+
+// Format returns the mnemonic form of the encoded IconVG graphic src.
+// Assembling it gives back src, if src was encoded by encode.Encoder.
+func Format(src []byte) ([]byte, error) {
+	f := &formatter{}
+	if err := decode.Decode(f, src); err != nil {
+		return nil, err
+	}
+	return f.buf.Bytes(), nil
+}
+
+// formatter is an ivg.Destination that writes the mnemonic form of the
+// graphic sent to it.
+type formatter struct {
+	buf  bytes.Buffer
+	cSel uint8
+	nSel uint8
+}
+
+func (f *formatter) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	f.buf.Reset()
+	f.cSel, f.nSel = 0, 0
+	if viewbox != ivg.DefaultViewBox {
+		f.statement("viewBox", viewbox.MinX, viewbox.MinY, viewbox.MaxX, viewbox.MaxY)
+	}
+	if palette != ivg.DefaultPalette {
+		// Like encode.Encoder, leave out the trailing opaque black colors.
+		n := len(palette)
+		for ; n > 0 && palette[n-1] == (color.RGBA{0x00, 0x00, 0x00, 0xff}); n-- {
+		}
+		for _, c := range palette[:n] {
+			f.colorStatement("palette", ivg.RGBAColor(c))
+		}
+	}
+}
+
+func (f *formatter) CSel() uint8 { return f.cSel }
+func (f *formatter) NSel() uint8 { return f.nSel }
+
+func (f *formatter) SetCSel(cSel uint8) {
+	f.cSel = cSel & 0x3f
+	f.statement("csel", float32(f.cSel))
+}
+
+func (f *formatter) SetNSel(nSel uint8) {
+	f.nSel = nSel & 0x3f
+	f.statement("nsel", float32(f.nSel))
+}
+
+func (f *formatter) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	if incr {
+		f.cSel = (f.cSel + 1) & 0x3f
+		f.colorStatement("creg+", c)
+		return
+	}
+	f.colorStatement("creg", c, float32(adj))
+}
+
+func (f *formatter) SetNReg(adj uint8, incr bool, x float32) {
+	if incr {
+		f.nSel = (f.nSel + 1) & 0x3f
+		f.statement("nreg+", x)
+		return
+	}
+	f.statement("nreg", float32(adj), x)
+}
+
+func (f *formatter) SetLOD(lod0, lod1 float32) { f.statement("lod", lod0, lod1) }
+
+func (f *formatter) StartPath(adj uint8, x, y float32) {
+	f.statement("start", float32(adj), x, y)
+}
+
+func (f *formatter) ClosePathEndPath()                      { f.statement("z") }
+func (f *formatter) ClosePathAbsMoveTo(x, y float32)        { f.statement("z M", x, y) }
+func (f *formatter) ClosePathRelMoveTo(x, y float32)        { f.statement("z m", x, y) }
+func (f *formatter) AbsHLineTo(x float32)                   { f.statement("H", x) }
+func (f *formatter) RelHLineTo(x float32)                   { f.statement("h", x) }
+func (f *formatter) AbsVLineTo(y float32)                   { f.statement("V", y) }
+func (f *formatter) RelVLineTo(y float32)                   { f.statement("v", y) }
+func (f *formatter) AbsLineTo(x, y float32)                 { f.statement("L", x, y) }
+func (f *formatter) RelLineTo(x, y float32)                 { f.statement("l", x, y) }
+func (f *formatter) AbsSmoothQuadTo(x, y float32)           { f.statement("T", x, y) }
+func (f *formatter) RelSmoothQuadTo(x, y float32)           { f.statement("t", x, y) }
+func (f *formatter) AbsQuadTo(x1, y1, x, y float32)         { f.statement("Q", x1, y1, x, y) }
+func (f *formatter) RelQuadTo(x1, y1, x, y float32)         { f.statement("q", x1, y1, x, y) }
+func (f *formatter) AbsSmoothCubeTo(x2, y2, x, y float32)   { f.statement("S", x2, y2, x, y) }
+func (f *formatter) RelSmoothCubeTo(x2, y2, x, y float32)   { f.statement("s", x2, y2, x, y) }
+func (f *formatter) AbsCubeTo(x1, y1, x2, y2, x, y float32) { f.statement("C", x1, y1, x2, y2, x, y) }
+func (f *formatter) RelCubeTo(x1, y1, x2, y2, x, y float32) { f.statement("c", x1, y1, x2, y2, x, y) }
+
+func (f *formatter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	f.statement("A", rx, ry, xAxisRotation, flag(largeArc), flag(sweep), x, y)
+}
+
+func (f *formatter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	f.statement("a", rx, ry, xAxisRotation, flag(largeArc), flag(sweep), x, y)
+}
+
+func (f *formatter) statement(op string, args ...float32) {
+	f.buf.WriteString(op)
+	for _, x := range args {
+		f.buf.WriteByte(' ')
+		f.buf.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	f.buf.WriteByte('\n')
+}
+
+func (f *formatter) colorStatement(op string, c ivg.Color, args ...float32) {
+	f.buf.WriteString(op)
+	for _, x := range args {
+		f.buf.WriteByte(' ')
+		f.buf.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	f.buf.WriteByte(' ')
+	f.buf.WriteString(formatColor(c))
+	f.buf.WriteByte('\n')
+}
+
+// formatColor formats c like its String method does, except that an RGBA
+// color that is neither alpha-premultiplied nor a gradient is still written
+// in the "RGBA rrggbbaa" form.
+func formatColor(c ivg.Color) string {
+	if x, ok := c.Encode4(); ok {
+		rgba := color.RGBA{x[0], x[1], x[2], x[3]}
+		if !ivg.ValidAlphaPremulColor(rgba) && !ivg.ValidGradient(rgba) {
+			return fmt.Sprintf("RGBA %02x%02x%02x%02x", x[0], x[1], x[2], x[3])
+		}
+	}
+	return c.String()
+}
+
+func flag(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,223 @@
+package assemble
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// listingMagic starts the listing form of assembly text: the hex bytes of
+// the IconVG magic identifier.
+const listingMagic = "89 49 56 47"
+
+// listingHexWidth is the width of the column of hex bytes that starts each
+// line of a listing.
+const listingHexWidth = 14
+
+// listing is the listing form of assembly text, as the annotations of its
+// non-empty lines.
+type listing struct {
+	a     *assembler
+	lines []string
+	nums  []int
+	i     int
+}
+
+// parseListing parses the listing form of assembly text.
+func (a *assembler) parseListing(src []byte) error {
+	l := &listing{a: a}
+	for i, line := range strings.Split(string(src), "\n") {
+		if len(line) <= listingHexWidth {
+			continue
+		}
+		if s := strings.TrimSpace(line[listingHexWidth:]); s != "" {
+			l.lines = append(l.lines, s)
+			l.nums = append(l.nums, i+1)
+		}
+	}
+	for l.i < len(l.lines) {
+		if err := l.parseStatement(l.next()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// next returns the next annotation, or "" at the end of the listing.
+func (l *listing) next() string {
+	if l.i == len(l.lines) {
+		return ""
+	}
+	s := l.lines[l.i]
+	l.a.line = l.nums[l.i]
+	l.i++
+	return s
+}
+
+// operand returns the first field of the next annotation, which holds the
+// operand that the previous annotation described.
+func (l *listing) operand() (string, error) {
+	fields := strings.Fields(l.next())
+	if len(fields) == 0 {
+		return "", l.a.errorf("missing operand")
+	}
+	return fields[0], nil
+}
+
+// numbers returns the next n operands as numbers, after the leading numbers
+// in args.
+func (l *listing) numbers(n int, args ...float32) ([]float32, error) {
+	for ; n > 0; n-- {
+		s, err := l.operand()
+		if err != nil {
+			return nil, err
+		}
+		f, err := l.a.parseNumber(s)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, f)
+	}
+	return args, nil
+}
+
+func (l *listing) parseStatement(s string) error {
+	a := l.a
+	var u float32
+	switch {
+	case s == "IconVG Magic identifier",
+		strings.HasPrefix(s, "Number of metadata chunks:"),
+		strings.HasPrefix(s, "Metadata chunk length:"):
+		return nil
+
+	case s == "Metadata Identifier: 0 (viewBox)":
+		args, err := l.numbers(4)
+		if err != nil {
+			return err
+		}
+		return a.exec("viewBox", args)
+
+	case s == "Metadata Identifier: 1 (suggested palette)":
+		var n int
+		if _, err := fmt.Sscanf(l.next(), "%d palette colors", &n); err != nil {
+			return a.errorf("missing number of palette colors")
+		}
+		for ; n > 0; n-- {
+			c, err := a.parseColor(l.next())
+			if err != nil {
+				return err
+			}
+			if err := a.execColor("palette", nil, c); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case number(s, "Set CSEL = ", &u):
+		return a.exec("csel", []float32{u})
+
+	case number(s, "Set NSEL = ", &u):
+		return a.exec("nsel", []float32{u})
+
+	case number(s, "Set CREG[CSEL-", &u):
+		c, err := a.parseColor(l.next())
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(s, "; CSEL++") {
+			return a.execColor("creg+", nil, c)
+		}
+		return a.execColor("creg", []float32{u}, c)
+
+	case number(s, "Set NREG[NSEL-", &u):
+		if strings.HasSuffix(s, "; NSEL++") {
+			args, err := l.numbers(1)
+			if err != nil {
+				return err
+			}
+			return a.exec("nreg+", args)
+		}
+		args, err := l.numbers(1, u)
+		if err != nil {
+			return err
+		}
+		return a.exec("nreg", args)
+
+	case number(s, "Start path, filled with CREG[CSEL-", &u):
+		args, err := l.numbers(2, u)
+		if err != nil {
+			return err
+		}
+		return a.exec("start", args)
+
+	case s == "Set LOD":
+		args, err := l.numbers(2)
+		if err != nil {
+			return err
+		}
+		return a.exec("lod", args)
+
+	case s == "z (closePath); end path":
+		return a.exec("z", nil)
+
+	case s == "z (closePath); M (absolute moveTo)", s == "z (closePath); m (relative moveTo)":
+		args, err := l.numbers(2)
+		if err != nil {
+			return err
+		}
+		return a.exec("z"+s[len("z (closePath); "):][:1], args)
+
+	case strings.HasPrefix(s, "A (") || strings.HasPrefix(s, "a ("):
+		// The x-axis rotation is annotated like "0.25 × 360 degrees" and the
+		// flags like "0x1 (largeArc=1, sweep=0)".
+		args, err := l.numbers(3)
+		if err != nil {
+			return err
+		}
+		flags, err := l.operand()
+		if err != nil {
+			return err
+		}
+		x, err := strconv.ParseUint(flags, 0, 32)
+		if err != nil {
+			return a.errorf("invalid arc flags %q", flags)
+		}
+		args = append(args, float32(x&0x01), float32(x>>1&0x01))
+		if args, err = l.numbers(2, args...); err != nil {
+			return err
+		}
+		return a.exec(s[:1], args)
+
+	case len(s) > 2 && s[1] == ' ' && s[2] == '(':
+		// A drawing opcode, like "L (absolute lineTo), 2 reps", followed by
+		// the arguments of one repetition. Further repetitions are annotated
+		// like "L (absolute lineTo), implicit".
+		n, ok := nArgs[s[:1]]
+		if !ok {
+			break
+		}
+		args, err := l.numbers(n)
+		if err != nil {
+			return err
+		}
+		return a.exec(s[:1], args)
+	}
+	return a.errorf("unknown annotation %q", s)
+}
+
+// number reports whether s starts with prefix followed by a decimal number,
+// like "Set CREG[CSEL-" and the 1 of "Set CREG[CSEL-1] to a 1 byte color",
+// and stores that number in f.
+func number(s, prefix string, f *float32) bool {
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	s = s[len(prefix):]
+	i := 0
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	u, err := strconv.Atoi(s[:i])
+	*f = float32(u)
+	return err == nil
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/reactivego/ivg/assemble"
 	"github.com/reactivego/ivg/decode"
 )
 
 func main() {
 	var out = flag.String("o", "stdout", "the filename to write the disassembled IVG data to")
+	var mnemonic = flag.Bool("m", false, "write the mnemonic form read by asivg instead of the annotated listing")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "%[1]s is a tool for disassembling IVG icons.\n\n"+
 			"Usage:\n\n"+
@@ -30,10 +32,13 @@ func main() {
 	if err != nil {
 		log.Fatalf("%s: ReadFile: %v", filename, err)
 	}
-	dis, err := decode.Disassemble(ivgData)
-	if err != nil {
-		log.Fatalf("%s: disassemble: %v", filename, err)
+	disassemble := decode.Disassemble
+	if *mnemonic {
+		disassemble = assemble.Format
 	}
+	// A malformed graphic is disassembled up to the opcode that the error
+	// reports the offset of.
+	dis, disErr := disassemble(ivgData)
 	if out == nil || *out == "stdout" {
 		_, err := os.Stdout.Write(dis)
 		if err != nil {
@@ -42,4 +47,7 @@ func main() {
 	} else if err := os.WriteFile(filepath.FromSlash(filepath.FromSlash(*out)), dis, 0666); err != nil {
 		log.Fatalf("%s: WriteFile: %v", filename, err)
 	}
+	if disErr != nil {
+		log.Fatalf("%s: disassemble: %v", filename, disErr)
+	}
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster/svg"
+	"github.com/reactivego/ivg/render"
+	"github.com/reactivego/ivg/svgicon"
+)
+
+func main() {
+	var out = flag.String("o", "stdout", "the filename to write the SVG document to")
+	var size = flag.Int("size", 0, "if positive, render the icon to an SVG document this many pixels wide, instead of converting its graphic")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%[1]s is a tool for converting IVG icons to SVG.\n\n"+
+			"Usage:\n\n"+
+			"  %[1]s [flags] filepath\n\n"+
+			"The flags are:\n\n", flag.CommandLine.Name())
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	filename := flag.Arg(0)
+	ivgData, err := os.ReadFile(filepath.FromSlash(filename))
+	if err != nil {
+		log.Fatalf("%s: ReadFile: %v", filename, err)
+	}
+	var svgData []byte
+	if *size > 0 {
+		svgData, err = renderSVG(ivgData, *size)
+	} else {
+		svgData, err = export(ivgData)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	if *out == "stdout" {
+		if _, err := os.Stdout.Write(svgData); err != nil {
+			log.Fatalf("%s: Write: %v", *out, err)
+		}
+	} else if err := os.WriteFile(filepath.FromSlash(*out), svgData, 0666); err != nil {
+		log.Fatalf("%s: WriteFile: %v", *out, err)
+	}
+}
+
+// export converts the graphic of the icon in ivgData to SVG.
+func export(ivgData []byte) ([]byte, error) {
+	var e svgicon.Exporter
+	if err := decode.Decode(&e, ivgData); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	svgData, err := e.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	return svgData, nil
+}
+
+// renderSVG renders the icon in ivgData to an SVG document that is width
+// pixels wide, with the height that keeps the aspect ratio of its viewBox.
+func renderSVG(ivgData []byte, width int) ([]byte, error) {
+	vb, err := decode.DecodeViewBox(ivgData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	dx, dy := vb.Size()
+	height := int(float32(width)*dy/dx + 0.5)
+	z := svg.NewRasterizer(width, height)
+	var r render.Renderer
+	r.SetRasterizer(z, z.Bounds())
+	if err := decode.Decode(&r, ivgData); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return z.Bytes(), nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster/term"
+	"github.com/reactivego/ivg/render"
+)
+
+func main() {
+	var width = flag.Int("w", 32, "the width of an icon in character cells")
+	var sextant = flag.Bool("sextant", false, "show 2 by 3 pixels per character cell with sextant characters, instead of 1 by 2 with half blocks")
+	var bg = flag.String("bg", "", "the background color as #rrggbb that translucent pixels are composited on, instead of the background of the terminal")
+	var columns = flag.Int("cols", terminalColumns(), "the width of the terminal in character cells, for showing icons side by side")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%[1]s is a tool for previewing IVG icons in a terminal with 24-bit colors.\n"+
+			"A directory shows the IVG icons in it side by side, with their names.\n\n"+
+			"Usage:\n\n"+
+			"  %[1]s [flags] path...\n\n"+
+			"The flags are:\n\n", flag.CommandLine.Name())
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *width <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := catter{width: *width, cell: term.HalfBlock}
+	if *sextant {
+		c.cell = term.Sextant
+	}
+	if *bg != "" {
+		var err error
+		if c.background, err = parseColor(*bg); err != nil {
+			log.Fatalf("-bg: %v", err)
+		}
+	}
+
+	var previews []preview
+	// A single file shows without its name.
+	single, failed := flag.NArg() == 1, false
+	for _, path := range flag.Args() {
+		filenames := []string{path}
+		if fi, err := os.Stat(path); err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = true
+			continue
+		} else if fi.IsDir() {
+			single = false
+			if filenames, err = filepath.Glob(filepath.Join(path, "*.ivg")); err != nil {
+				log.Fatalf("%s: Glob: %v", path, err)
+			}
+		}
+		for _, filename := range filenames {
+			lines, err := c.render(filename)
+			if err != nil {
+				log.Printf("%s: %v", filename, err)
+				failed = true
+				continue
+			}
+			previews = append(previews, preview{filepath.Base(filename), lines})
+		}
+	}
+	if single && len(previews) == 1 {
+		os.Stdout.WriteString(strings.Join(previews[0].lines, "\n") + "\n")
+	} else {
+		os.Stdout.Write(grid(previews, *width, *columns))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// terminalColumns returns the width of the terminal from the COLUMNS
+// environment variable, or 80.
+func terminalColumns() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// parseColor parses a color as #rrggbb.
+func parseColor(s string) (color.Color, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(s, "#")) != 6 {
+		return nil, fmt.Errorf("color %q is not #rrggbb", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// catter renders icons as previews of a width in character cells.
+type catter struct {
+	width      int
+	cell       term.Cell
+	background color.Color
+}
+
+// preview is an icon as lines of text.
+type preview struct {
+	name  string
+	lines []string
+}
+
+// render renders the icon in the file to lines of text that are c.width
+// character cells wide, and as high as the aspect ratio of its viewBox needs.
+func (c *catter) render(filename string) ([]string, error) {
+	ivgData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := decode.DecodeViewBox(ivgData)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	size := c.cell.Size()
+	dx, dy := vb.Size()
+	w := c.width * size.X
+	h := int(float32(w)*dy/dx + 0.5)
+	z := term.NewRasterizer(c.width, (h+size.Y-1)/size.Y, c.cell)
+	z.Background = c.background
+	var r render.Renderer
+	r.SetRasterizer(z, image.Rect(0, 0, w, h))
+	if err := decode.Decode(&r, ivgData); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	var b bytes.Buffer
+	z.WriteTo(&b)
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n"), nil
+}
+
+// grid lays out the previews side by side, with their names below them, in
+// rows of text that are at most columns character cells wide.
+func grid(previews []preview, width, columns int) []byte {
+	const gap = 2
+	n := (columns + gap) / (width + gap)
+	if n < 1 {
+		n = 1
+	}
+	var b bytes.Buffer
+	for len(previews) > 0 {
+		row := previews
+		if len(row) > n {
+			row = row[:n]
+		}
+		previews = previews[len(row):]
+		height := 0
+		for _, p := range row {
+			if len(p.lines) > height {
+				height = len(p.lines)
+			}
+		}
+		for y := 0; y <= height; y++ {
+			for i, p := range row {
+				if i > 0 {
+					b.WriteString(strings.Repeat(" ", gap))
+				}
+				switch {
+				case y == height:
+					b.WriteString(pad(p.name, width))
+				case y < len(p.lines):
+					b.WriteString(p.lines[y])
+				default:
+					b.WriteString(strings.Repeat(" ", width))
+				}
+			}
+			b.WriteString("\n")
+		}
+		if len(previews) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// pad truncates or pads s with spaces to width runes.
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	r := []rune(s)
+	if len(r) > width {
+		r = append(r[:width-1], '…')
+	}
+	return string(r)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/reactivego/ivg/lint"
+)
+
+// problem is a problem of a file, as written by the -json flag.
+type problem struct {
+	File string `json:"file"`
+	lint.Problem
+}
+
+func main() {
+	var asJSON = flag.Bool("json", false, "write the problems as a JSON array of objects with a file, check, path and message, for CI")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%[1]s is a tool for checking IVG icons for problems, without rendering them.\n"+
+			"A directory checks the IVG icons in it. The exit status is 1 when there are problems.\n\n"+
+			"Usage:\n\n"+
+			"  %[1]s [flags] path...\n\n"+
+			"The flags are:\n\n", flag.CommandLine.Name())
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	problems := []problem{}
+	failed := false
+	for _, path := range flag.Args() {
+		filenames := []string{path}
+		if fi, err := os.Stat(path); err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = true
+			continue
+		} else if fi.IsDir() {
+			if filenames, err = filepath.Glob(filepath.Join(path, "*.ivg")); err != nil {
+				log.Fatalf("%s: Glob: %v", path, err)
+			}
+		}
+		for _, filename := range filenames {
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				log.Printf("%s: ReadFile: %v", filename, err)
+				failed = true
+				continue
+			}
+			found, err := lint.Lint(src)
+			if err != nil {
+				log.Printf("%s: %v", filename, err)
+				failed = true
+				continue
+			}
+			for _, p := range found {
+				problems = append(problems, problem{filepath.ToSlash(filename), p})
+			}
+		}
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(problems, "", "\t")
+		if err != nil {
+			log.Fatalf("json: %v", err)
+		}
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		for _, p := range problems {
+			fmt.Printf("%s: %v\n", p.File, p.Problem)
+		}
+	}
+	if failed || len(problems) > 0 {
+		os.Exit(1)
+	}
+}
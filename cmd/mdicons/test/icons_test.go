@@ -4,9 +4,16 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
+	"image"
+	"image/draw"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/optimize"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
 )
 
 // overwriteTestdataFiles is temporarily set to true when adding new
@@ -67,3 +74,40 @@ func TestHashes(t *testing.T) {
 		}
 	}
 }
+
+func rasterize(ivgData []byte) (*image.RGBA, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, 48, 48))
+	var z render.Renderer
+	z.SetRasterizer(&img.Rasterizer{Dst: dst, DrawOp: draw.Src}, dst.Bounds())
+	return dst, decode.Decode(&z, ivgData)
+}
+
+// TestOptimize tests that optimizing the icons makes none of them larger and
+// that they render the same, up to rounding.
+func TestOptimize(t *testing.T) {
+	before, after := 0, 0
+	for _, icon := range list {
+		got, err := optimize.Optimize(icon.data)
+		if err != nil {
+			t.Errorf("icon:%q: Optimize: %v", icon.name, err)
+			continue
+		}
+		if len(got) > len(icon.data) {
+			t.Errorf("icon:%q: got %d bytes, want at most %d", icon.name, len(got), len(icon.data))
+		}
+		before, after = before+len(icon.data), after+len(got)
+		m0, err0 := rasterize(got)
+		m1, err1 := rasterize(icon.data)
+		if err0 != nil || err1 != nil {
+			t.Errorf("icon:%q: Decode: %v, %v", icon.name, err0, err1)
+			continue
+		}
+		for i := range m0.Pix {
+			if d := int(m0.Pix[i]) - int(m1.Pix[i]); d < -1 || +1 < d {
+				t.Errorf("icon:%q: renders differently at (%d, %d)", icon.name, i%m0.Stride/4, i/m0.Stride)
+				break
+			}
+		}
+	}
+	t.Logf("optimized %d icons from %d to %d bytes", len(list), before, after)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/reactivego/ivg/optimize"
+)
+
+func main() {
+	var out = flag.String("o", "stdout", "the filename to write the optimized IVG data to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%[1]s is a tool for re-encoding IVG icons in fewer bytes.\n"+
+			"The optimized icon draws the same graphic as the original.\n\n"+
+			"Usage:\n\n"+
+			"  %[1]s [flags] filepath\n\n"+
+			"The flags are:\n\n", flag.CommandLine.Name())
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	filename := flag.Arg(0)
+	src, err := os.ReadFile(filepath.FromSlash(filename))
+	if err != nil {
+		log.Fatalf("%s: ReadFile: %v", filename, err)
+	}
+	ivgData, err := optimize.Optimize(src)
+	if err != nil {
+		log.Fatalf("%s: optimize: %v", filename, err)
+	}
+	if *out == "stdout" {
+		if _, err := os.Stdout.Write(ivgData); err != nil {
+			log.Fatalf("%s: Write: %v", *out, err)
+		}
+	} else if err := os.WriteFile(filepath.FromSlash(*out), ivgData, 0666); err != nil {
+		log.Fatalf("%s: WriteFile: %v", *out, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"image/color"
 	"math"
 	"testing"
+	"testing/quick"
 
 	"github.com/reactivego/ivg"
 )
@@ -252,3 +253,51 @@ func TestDecodeColor(t *testing.T) {
 		}
 	}
 }
+
+// isMultiple reports whether x is a multiple of 1/d in [lo/d, hi/d).
+func isMultiple(x float32, d, lo, hi float64) bool {
+	y := float64(x) * d
+	return math.Abs(y-math.Floor(y+0.5)) < 1e-3 && lo <= y && y < hi
+}
+
+func TestDecodeNumberProperty(t *testing.T) {
+	f := func(b []byte) bool {
+		// The low bits of the first byte tell the length of the number.
+		wantN := 0
+		if len(b) > 0 {
+			wantN = [4]int{1, 2, 1, 4}[b[0]&0x03]
+			if len(b) < wantN {
+				wantN = 0
+			}
+		}
+		u, n := buffer(b).decodeNatural()
+		if n != wantN {
+			return false
+		}
+		if n == 0 {
+			return true
+		}
+		// The bytes after the number do not change it.
+		if u1, n1 := buffer(b[:n]).decodeNatural(); u1 != u || n1 != n {
+			return false
+		}
+		number, nNumber := buffer(b).decodeReal()
+		coord, nCoord := buffer(b).decodeCoordinate()
+		zeroToOne, nZeroToOne := buffer(b).decodeZeroToOne()
+		if nNumber != n || nCoord != n || nZeroToOne != n {
+			return false
+		}
+		switch n {
+		case 1:
+			return isMultiple(number, 1, 0, 1<<7) && isMultiple(coord, 1, -64, 64) && isMultiple(zeroToOne, 120, 0, 1<<7)
+		case 2:
+			return isMultiple(number, 1, 0, 1<<14) && isMultiple(coord, 64, -128*64, 128*64) && isMultiple(zeroToOne, 15120, 0, 1<<14)
+		}
+		// A 4 byte number is a float32 without its 2 least significant bits.
+		bits := u << 2
+		return math.Float32bits(number) == bits && math.Float32bits(coord) == bits && math.Float32bits(zeroToOne) == bits
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
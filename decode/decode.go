@@ -17,25 +17,79 @@ func isNaNOrInfinity(f float32) bool {
 	return math.Float32bits(f)&0x7f800000 == 0x7f800000
 }
 
+// DecodeError is what is wrong with a malformed graphic.
 type DecodeError string
 
 func (e DecodeError) Error() string { return "iconvg: " + string(e) }
 
+// The errors that can be wrong with a malformed graphic. Decode returns them
+// wrapped in an *Error that tells where they went wrong, so test for them with
+// errors.Is.
 var (
-	errInconsistentMetadataChunkLength = DecodeError("inconsistent metadata chunk length")
-	errInvalidColor                    = DecodeError("invalid color")
-	errInvalidMagicIdentifier          = DecodeError("invalid magic identifier")
-	errInvalidMetadataChunkLength      = DecodeError("invalid metadata chunk length")
-	errInvalidMetadataIdentifier       = DecodeError("invalid metadata identifier")
-	errInvalidNumber                   = DecodeError("invalid number")
-	errInvalidNumberOfMetadataChunks   = DecodeError("invalid number of metadata chunks")
-	errInvalidSuggestedPalette         = DecodeError("invalid suggested palette")
-	errInvalidViewBox                  = DecodeError("invalid view box")
-	errUnsupportedDrawingOpcode        = DecodeError("unsupported drawing opcode")
-	errUnsupportedMetadataIdentifier   = DecodeError("unsupported metadata identifier")
-	errUnsupportedStylingOpcode        = DecodeError("unsupported styling opcode")
+	ErrInconsistentMetadataChunkLength = DecodeError("inconsistent metadata chunk length")
+	ErrInvalidColor                    = DecodeError("invalid color")
+	ErrInvalidMagicIdentifier          = DecodeError("invalid magic identifier")
+	ErrInvalidMetadataChunkLength      = DecodeError("invalid metadata chunk length")
+	ErrInvalidMetadataIdentifier       = DecodeError("invalid metadata identifier")
+	ErrInvalidNumber                   = DecodeError("invalid number")
+	ErrInvalidNumberOfMetadataChunks   = DecodeError("invalid number of metadata chunks")
+	ErrInvalidSuggestedPalette         = DecodeError("invalid suggested palette")
+	ErrInvalidViewBox                  = DecodeError("invalid view box")
+	ErrUnsupportedDrawingOpcode        = DecodeError("unsupported drawing opcode")
+	ErrUnsupportedMetadataIdentifier   = DecodeError("unsupported metadata identifier")
+	ErrUnsupportedStylingOpcode        = DecodeError("unsupported styling opcode")
 )
 
+// Mode is the part of a graphic that is being decoded.
+type Mode uint8
+
+const (
+	// ModeMetadata is the magic identifier and metadata chunks that start a
+	// graphic.
+	ModeMetadata Mode = iota
+	// ModeStyling is where the opcodes set registers and start paths.
+	ModeStyling
+	// ModeDrawing is where the opcodes draw the segments of a path.
+	ModeDrawing
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeMetadata:
+		return "metadata"
+	case ModeStyling:
+		return "styling"
+	case ModeDrawing:
+		return "drawing"
+	}
+	return fmt.Sprintf("Mode(%d)", uint8(m))
+}
+
+// Error is the error that decoding a malformed graphic returns. It wraps what
+// is wrong with the graphic, a DecodeError, with where it went wrong.
+type Error struct {
+	// Err is what is wrong with the graphic.
+	Err error
+	// Offset is the offset in bytes of the opcode that is malformed, or, in
+	// ModeMetadata, of the magic identifier or metadata chunk.
+	Offset int
+	// Mode is the mode of the opcode.
+	Mode Mode
+	// Opcode is the opcode that is malformed, except in ModeMetadata.
+	Opcode byte
+	// Ops is the number of opcodes that were decoded before it.
+	Ops int
+}
+
+func (e *Error) Error() string {
+	if e.Mode == ModeMetadata {
+		return fmt.Sprintf("%v at offset %d in the metadata", e.Err, e.Offset)
+	}
+	return fmt.Sprintf("%v at offset %d: %v opcode %#02x after %d ops", e.Err, e.Offset, e.Mode, e.Opcode, e.Ops)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
 var midDescriptions = [...]string{
 	ivg.MidViewBox:          "viewBox",
 	ivg.MidSuggestedPalette: "suggested palette",
@@ -64,7 +118,7 @@ func WithColorAt(index int, c color.Color) DecodeOption {
 // DecodeViewbox decodes only the metadata in an IconVG graphic.
 func DecodeViewBox(src []byte) (vb ivg.ViewBox, err error) {
 	m := ivg.DefaultMetadata
-	err = decode(nil, nil, &m, true, src)
+	err = decode(nil, nil, &m, true, src, nil)
 	return m.ViewBox, err
 }
 
@@ -72,10 +126,12 @@ func DecodeViewBox(src []byte) (vb ivg.ViewBox, err error) {
 // provided, the palette suggested in the IconVG graphic's data will be used.
 func Decode(dst ivg.Destination, src []byte, opts ...DecodeOption) error {
 	m := ivg.DefaultMetadata
-	return decode(dst, nil, &m, false, src, opts...)
+	return decode(dst, nil, &m, false, src, nil, opts...)
 }
 
-// Disassemble returns a disassembly of an encoded IconVG graphic.
+// Disassemble returns a disassembly of an encoded IconVG graphic. When the
+// graphic is malformed, it returns the disassembly up to where it went wrong
+// with the error.
 func Disassemble(src []byte) ([]byte, error) {
 	w := new(bytes.Buffer)
 	p := func(b []byte, format string, args ...interface{}) {
@@ -92,15 +148,26 @@ func Disassemble(src []byte) ([]byte, error) {
 		fmt.Fprintf(w, format, args...)
 	}
 	m := ivg.Metadata{}
-	if err := decode(nil, p, &m, false, buffer(src)); err != nil {
-		return nil, err
-	}
-	return w.Bytes(), nil
+	err := decode(nil, p, &m, false, buffer(src), nil)
+	return w.Bytes(), err
 }
 
-func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool, src buffer, opts ...DecodeOption) (err error) {
+// decode decodes src into dst. When l is not nil, it decodes src within the
+// limits of l, which it sends the calls for dst to.
+func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool, src buffer, l *limiter, opts ...DecodeOption) (err error) {
+	// fail returns err with where it went wrong: at the start of at, after
+	// ops opcodes in mode.
+	start := src
+	fail := func(err error, at buffer, mode Mode, ops int) error {
+		e := &Error{Err: err, Offset: len(start) - len(at), Mode: mode, Ops: ops}
+		if mode != ModeMetadata {
+			e.Opcode = at[0]
+		}
+		return e
+	}
+
 	if !bytes.HasPrefix(src, ivg.MagicBytes) {
-		return errInvalidMagicIdentifier
+		return fail(ErrInvalidMagicIdentifier, src, ModeMetadata, 0)
 	}
 	if p != nil {
 		p(src[:len(ivg.Magic)], "IconVG Magic identifier\n")
@@ -109,7 +176,7 @@ func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool,
 
 	nMetadataChunks, n := src.decodeNatural()
 	if n == 0 {
-		return errInvalidNumberOfMetadataChunks
+		return fail(ErrInvalidNumberOfMetadataChunks, src, ModeMetadata, 0)
 	}
 	if p != nil {
 		p(src[:n], "Number of metadata chunks: %d\n", nMetadataChunks)
@@ -117,9 +184,10 @@ func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool,
 	src = src[n:]
 
 	for ; nMetadataChunks > 0; nMetadataChunks-- {
+		chunk := src
 		src, err = decodeMetadataChunk(p, m, src)
 		if err != nil {
-			return err
+			return fail(err, chunk, ModeMetadata, 0)
 		}
 	}
 	for _, opt := range opts {
@@ -128,15 +196,30 @@ func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool,
 	if metadataOnly {
 		return nil
 	}
+	if l != nil {
+		l.Destination = dst
+		dst = l
+	}
 	if dst != nil {
 		dst.Reset(m.ViewBox, m.Palette)
 	}
 
-	mf := modeFunc(decodeStyling)
-	for len(src) > 0 {
-		mf, src, err = mf(dst, p, src)
+	mode := ModeStyling
+	for ops := 0; len(src) > 0; ops++ {
+		if l != nil {
+			if err := l.op(); err != nil {
+				return err
+			}
+		}
+		next, src1, err := modeFuncs[mode](dst, p, src)
 		if err != nil {
-			return err
+			return fail(err, src, mode, ops)
+		}
+		mode, src = next, src1
+		if l != nil {
+			if err := l.err(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -145,7 +228,7 @@ func decode(dst ivg.Destination, p printer, m *ivg.Metadata, metadataOnly bool,
 func decodeMetadataChunk(p printer, m *ivg.Metadata, src buffer) (src1 buffer, err error) {
 	length, n := src.decodeNatural()
 	if n == 0 {
-		return nil, errInvalidMetadataChunkLength
+		return nil, ErrInvalidMetadataChunkLength
 	}
 	if p != nil {
 		p(src[:n], "Metadata chunk length: %d\n", length)
@@ -155,10 +238,10 @@ func decodeMetadataChunk(p printer, m *ivg.Metadata, src buffer) (src1 buffer, e
 
 	mid, n := src.decodeNatural()
 	if n == 0 {
-		return nil, errInvalidMetadataIdentifier
+		return nil, ErrInvalidMetadataIdentifier
 	}
 	if mid >= uint32(len(midDescriptions)) {
-		return nil, errUnsupportedMetadataIdentifier
+		return nil, ErrUnsupportedMetadataIdentifier
 	}
 	if p != nil {
 		p(src[:n], "Metadata Identifier: %d (%s)\n", mid, midDescriptions[mid])
@@ -168,26 +251,26 @@ func decodeMetadataChunk(p printer, m *ivg.Metadata, src buffer) (src1 buffer, e
 	switch mid {
 	case ivg.MidViewBox:
 		if m.ViewBox.MinX, src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
-			return nil, errInvalidViewBox
+			return nil, ErrInvalidViewBox
 		}
 		if m.ViewBox.MinY, src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
-			return nil, errInvalidViewBox
+			return nil, ErrInvalidViewBox
 		}
 		if m.ViewBox.MaxX, src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
-			return nil, errInvalidViewBox
+			return nil, ErrInvalidViewBox
 		}
 		if m.ViewBox.MaxY, src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
-			return nil, errInvalidViewBox
+			return nil, ErrInvalidViewBox
 		}
 		if m.ViewBox.MinX > m.ViewBox.MaxX || m.ViewBox.MinY > m.ViewBox.MaxY ||
 			isNaNOrInfinity(m.ViewBox.MinX) || isNaNOrInfinity(m.ViewBox.MinY) ||
 			isNaNOrInfinity(m.ViewBox.MaxX) || isNaNOrInfinity(m.ViewBox.MaxY) {
-			return nil, errInvalidViewBox
+			return nil, ErrInvalidViewBox
 		}
 
 	case ivg.MidSuggestedPalette:
 		if len(src) == 0 {
-			return nil, errInvalidSuggestedPalette
+			return nil, ErrInvalidSuggestedPalette
 		}
 		length, format := 1+int(src[0]&0x3f), src[0]>>6
 		decode := buffer.decodeColor4
@@ -207,7 +290,7 @@ func decodeMetadataChunk(p printer, m *ivg.Metadata, src buffer) (src1 buffer, e
 		for i := 0; i < length; i++ {
 			c, n := decode(src)
 			if n == 0 {
-				return nil, errInvalidSuggestedPalette
+				return nil, ErrInvalidSuggestedPalette
 			}
 			rgba, _ := c.RGBA()
 			if p != nil {
@@ -218,24 +301,29 @@ func decodeMetadataChunk(p printer, m *ivg.Metadata, src buffer) (src1 buffer, e
 		}
 
 	default:
-		return nil, errUnsupportedMetadataIdentifier
+		return nil, ErrUnsupportedMetadataIdentifier
 	}
 
 	if int64(len(src)) != lenSrcWant {
-		return nil, errInconsistentMetadataChunkLength
+		return nil, ErrInconsistentMetadataChunkLength
 	}
 	return src, nil
 }
 
-// modeFunc is the decoding mode: whether we are decoding styling or drawing
+// modeFunc decodes in a mode: whether we are decoding styling or drawing
 // opcodes.
 //
-// It is a function type. The decoding loop calls this function to decode and
-// execute the next opcode from the src buffer, returning the subsequent mode
-// and the remaining source bytes.
-type modeFunc func(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer, error)
+// It is a function type. The decoding loop calls the function of its mode to
+// decode and execute the next opcode from the src buffer, returning the
+// subsequent mode and the remaining source bytes.
+type modeFunc func(dst ivg.Destination, p printer, src buffer) (Mode, buffer, error)
+
+var modeFuncs = [...]modeFunc{
+	ModeStyling: decodeStyling,
+	ModeDrawing: decodeDrawing,
+}
 
-func decodeStyling(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer, error) {
+func decodeStyling(dst ivg.Destination, p printer, src buffer) (Mode, buffer, error) {
 	switch opcode := src[0]; {
 	case opcode < 0x80:
 		if opcode < 0x40 {
@@ -257,7 +345,7 @@ func decodeStyling(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer
 				dst.SetNSel(opcode)
 			}
 		}
-		return decodeStyling, src, nil
+		return ModeStyling, src, nil
 	case opcode < 0xa8:
 		return decodeSetCReg(dst, p, src, opcode)
 	case opcode < 0xc0:
@@ -267,10 +355,10 @@ func decodeStyling(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer
 	case opcode == 0xc7:
 		return decodeSetLOD(dst, p, src)
 	}
-	return nil, nil, errUnsupportedStylingOpcode
+	return 0, nil, ErrUnsupportedStylingOpcode
 }
 
-func decodeSetCReg(dst ivg.Destination, p printer, src buffer, opcode byte) (modeFunc, buffer, error) {
+func decodeSetCReg(dst ivg.Destination, p printer, src buffer, opcode byte) (Mode, buffer, error) {
 	nBytes, directness, adj := 0, "", opcode&0x07
 	var decode func(buffer) (ivg.Color, int)
 	incr := adj == 7
@@ -301,7 +389,7 @@ func decodeSetCReg(dst ivg.Destination, p printer, src buffer, opcode byte) (mod
 
 	c, n := decode(src)
 	if n == 0 {
-		return nil, nil, errInvalidColor
+		return 0, nil, ErrInvalidColor
 	}
 
 	if p != nil {
@@ -313,10 +401,10 @@ func decodeSetCReg(dst ivg.Destination, p printer, src buffer, opcode byte) (mod
 		dst.SetCReg(adj, incr, c)
 	}
 
-	return decodeStyling, src, nil
+	return ModeStyling, src, nil
 }
 
-func decodeSetNReg(dst ivg.Destination, p printer, src buffer, opcode byte) (modeFunc, buffer, error) {
+func decodeSetNReg(dst ivg.Destination, p printer, src buffer, opcode byte) (Mode, buffer, error) {
 	decode, typ, adj := buffer.decodeZeroToOne, "zero-to-one", opcode&0x07
 	incr := adj == 7
 	if incr {
@@ -340,7 +428,7 @@ func decodeSetNReg(dst ivg.Destination, p printer, src buffer, opcode byte) (mod
 
 	f, n := decode(src)
 	if n == 0 {
-		return nil, nil, errInvalidNumber
+		return 0, nil, ErrInvalidNumber
 	}
 	if p != nil {
 		p(src[:n], "    %g\n", f)
@@ -351,10 +439,10 @@ func decodeSetNReg(dst ivg.Destination, p printer, src buffer, opcode byte) (mod
 		dst.SetNReg(adj, incr, f)
 	}
 
-	return decodeStyling, src, nil
+	return ModeStyling, src, nil
 }
 
-func decodeStartPath(dst ivg.Destination, p printer, src buffer, opcode byte) (modeFunc, buffer, error) {
+func decodeStartPath(dst ivg.Destination, p printer, src buffer, opcode byte) (Mode, buffer, error) {
 	adj := opcode & 0x07
 	if p != nil {
 		p(src[:1], "Start path, filled with CREG[CSEL-%d]; M (absolute moveTo)\n", adj)
@@ -363,21 +451,21 @@ func decodeStartPath(dst ivg.Destination, p printer, src buffer, opcode byte) (m
 
 	x, src, err := decodeNumber(p, src, buffer.decodeCoordinate)
 	if err != nil {
-		return nil, nil, err
+		return 0, nil, err
 	}
 	y, src, err := decodeNumber(p, src, buffer.decodeCoordinate)
 	if err != nil {
-		return nil, nil, err
+		return 0, nil, err
 	}
 
 	if dst != nil {
 		dst.StartPath(adj, x, y)
 	}
 
-	return decodeDrawing, src, nil
+	return ModeDrawing, src, nil
 }
 
-func decodeSetLOD(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer, error) {
+func decodeSetLOD(dst ivg.Destination, p printer, src buffer) (Mode, buffer, error) {
 	if p != nil {
 		p(src[:1], "Set LOD\n")
 	}
@@ -385,20 +473,20 @@ func decodeSetLOD(dst ivg.Destination, p printer, src buffer) (modeFunc, buffer,
 
 	lod0, src, err := decodeNumber(p, src, buffer.decodeReal)
 	if err != nil {
-		return nil, nil, err
+		return 0, nil, err
 	}
 	lod1, src, err := decodeNumber(p, src, buffer.decodeReal)
 	if err != nil {
-		return nil, nil, err
+		return 0, nil, err
 	}
 
 	if dst != nil {
 		dst.SetLOD(lod0, lod1)
 	}
-	return decodeStyling, src, nil
+	return ModeStyling, src, nil
 }
 
-func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src1 buffer, err error) {
+func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mode Mode, src1 buffer, err error) {
 	var coords [6]float32
 
 	switch opcode := src[0]; {
@@ -458,25 +546,25 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 			if op[0] != 'A' && op[0] != 'a' {
 				src, err = decodeCoordinates(coords[:nCoords], p, src)
 				if err != nil {
-					return nil, nil, err
+					return 0, nil, err
 				}
 			} else {
 				// We have an absolute or relative arcTo.
 				src, err = decodeCoordinates(coords[:2], p, src)
 				if err != nil {
-					return nil, nil, err
+					return 0, nil, err
 				}
 				coords[2], src, err = decodeAngle(p, src)
 				if err != nil {
-					return nil, nil, err
+					return 0, nil, err
 				}
 				largeArc, sweep, src, err = decodeArcToFlags(p, src)
 				if err != nil {
-					return nil, nil, err
+					return 0, nil, err
 				}
 				src, err = decodeCoordinates(coords[4:6], p, src)
 				if err != nil {
-					return nil, nil, err
+					return 0, nil, err
 				}
 			}
 
@@ -519,7 +607,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		if dst != nil {
 			dst.ClosePathEndPath()
 		}
-		return decodeStyling, src, nil
+		return ModeStyling, src, nil
 
 	case opcode == 0xe2:
 		if p != nil {
@@ -528,7 +616,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:2], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.ClosePathAbsMoveTo(coords[0], coords[1])
@@ -541,7 +629,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:2], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.ClosePathRelMoveTo(coords[0], coords[1])
@@ -554,7 +642,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.AbsHLineTo(coords[0])
@@ -567,7 +655,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.RelHLineTo(coords[0])
@@ -580,7 +668,7 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.AbsVLineTo(coords[0])
@@ -593,16 +681,16 @@ func decodeDrawing(dst ivg.Destination, p printer, src buffer) (mf modeFunc, src
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
 		if err != nil {
-			return nil, nil, err
+			return 0, nil, err
 		}
 		if dst != nil {
 			dst.RelVLineTo(coords[0])
 		}
 
 	default:
-		return nil, nil, errUnsupportedDrawingOpcode
+		return 0, nil, ErrUnsupportedDrawingOpcode
 	}
-	return decodeDrawing, src, nil
+	return ModeDrawing, src, nil
 }
 
 type decodeNumberFunc func(buffer) (float32, int)
@@ -610,7 +698,7 @@ type decodeNumberFunc func(buffer) (float32, int)
 func decodeNumber(p printer, src buffer, dnf decodeNumberFunc) (float32, buffer, error) {
 	x, n := dnf(src)
 	if n == 0 {
-		return 0, nil, errInvalidNumber
+		return 0, nil, ErrInvalidNumber
 	}
 	if p != nil {
 		p(src[:n], "    %+g\n", x)
@@ -631,7 +719,7 @@ func decodeCoordinates(coords []float32, p printer, src buffer) (src1 buffer, er
 func decodeAngle(p printer, src buffer) (float32, buffer, error) {
 	x, n := src.decodeZeroToOne()
 	if n == 0 {
-		return 0, nil, errInvalidNumber
+		return 0, nil, ErrInvalidNumber
 	}
 	if p != nil {
 		p(src[:n], "    %v × 360 degrees (%v degrees)\n", x, x*360)
@@ -642,7 +730,7 @@ func decodeAngle(p printer, src buffer) (float32, buffer, error) {
 func decodeArcToFlags(p printer, src buffer) (bool, bool, buffer, error) {
 	x, n := src.decodeNatural()
 	if n == 0 {
-		return false, false, nil, errInvalidNumber
+		return false, false, nil, ErrInvalidNumber
 	}
 	if p != nil {
 		p(src[:n], "    %#x (largeArc=%d, sweep=%d)\n", x, (x>>0)&0x01, (x>>1)&0x01)
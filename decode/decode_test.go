@@ -6,6 +6,7 @@ package decode
 
 import (
 	"bytes"
+	"errors"
 	"image/color"
 	"os"
 	"path/filepath"
@@ -137,3 +138,127 @@ func (e *resolutionPreservingEncoder) Reset(viewbox ivg.ViewBox, palette [64]col
 	e.Encoder.Reset(viewbox, palette)
 	e.HighResolutionCoordinates = orig
 }
+
+func TestDecodeErrors(t *testing.T) {
+	// The header is the magic identifier and zero metadata chunks.
+	const header = "\x89IVG\x00"
+	testCases := []struct {
+		src     string
+		want    Error
+		wantDis string
+	}{{
+		src:  "\x8bIVG\x00",
+		want: Error{Err: ErrInvalidMagicIdentifier},
+	}, {
+		src:  "\x89IVG\x02\x00",
+		want: Error{Err: ErrInvalidMetadataIdentifier, Offset: 5},
+	}, {
+		src:     header + "\x00\xff",
+		want:    Error{Err: ErrUnsupportedStylingOpcode, Offset: 6, Mode: ModeStyling, Opcode: 0xff, Ops: 1},
+		wantDis: "Set CSEL = 0\n",
+	}, {
+		src:     header + "\xc0\x80",
+		want:    Error{Err: ErrInvalidNumber, Offset: 5, Mode: ModeStyling, Opcode: 0xc0},
+		wantDis: "Start path",
+	}, {
+		src:     header + "\xc0\x80\x80\x00\x80\x80\xe0",
+		want:    Error{Err: ErrUnsupportedDrawingOpcode, Offset: 11, Mode: ModeDrawing, Opcode: 0xe0, Ops: 2},
+		wantDis: "L (absolute lineTo), 1 reps\n",
+	}}
+	for _, tc := range testCases {
+		err := Decode(nil, []byte(tc.src))
+		var got *Error
+		if !errors.As(err, &got) || *got != tc.want {
+			t.Errorf("% x: got %v, want %v", tc.src, err, &tc.want)
+			continue
+		}
+		if !errors.Is(err, tc.want.Err) {
+			t.Errorf("% x: %v is not %v", tc.src, err, tc.want.Err)
+		}
+		dis, err := Disassemble([]byte(tc.src))
+		if err == nil || err.Error() != got.Error() {
+			t.Errorf("% x: Disassemble: got %v, want %v", tc.src, err, got)
+		}
+		if !strings.Contains(string(dis), tc.wantDis) {
+			t.Errorf("% x: Disassemble: got %q, want it to contain %q", tc.src, dis, tc.wantDis)
+		}
+	}
+}
+
+// lineCounter is an Encoder that counts the calls to AbsLineTo.
+type lineCounter struct {
+	encode.Encoder
+	lines int
+}
+
+func (e *lineCounter) AbsLineTo(x, y float32) {
+	e.lines++
+	e.Encoder.AbsLineTo(x, y)
+}
+
+func TestDecoder(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := os.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var e resolutionPreservingEncoder
+		e.HighResolutionCoordinates = strings.HasSuffix(tc.filename, ".hires")
+		limits := Limits{MaxBytes: int64(len(ivgData)), MaxOps: 1 << 16, MaxSegments: 1 << 16}
+		if err := NewDecoder(bytes.NewReader(ivgData), limits).Decode(&e); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		if got, err := e.Bytes(); err != nil || !bytes.Equal(got, ivgData) {
+			t.Errorf("%s: got %d bytes and error %v, want %d bytes", tc.filename, len(got), err, len(ivgData))
+		}
+
+		limits.MaxBytes--
+		err = NewDecoder(bytes.NewReader(ivgData), limits).Decode(nil)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "bytes" || limitErr.Max != limits.MaxBytes {
+			t.Errorf("%s: MaxBytes %d: got %v", tc.filename, limits.MaxBytes, err)
+		}
+	}
+
+	// The graphic has 6 ops: SetCReg, StartPath, AbsLineTo with 3 reps,
+	// AbsHLineTo, AbsLineTo and ClosePathEndPath, with 6 segments.
+	var e encode.Encoder
+	e.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	e.SetCReg(0, false, ivg.RGBAColor(color.RGBA{0x40, 0, 0, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(10, 10)
+	e.AbsLineTo(0, 10)
+	e.AbsHLineTo(5)
+	e.AbsLineTo(3, 3)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		limits    Limits
+		wantErr   error
+		wantLines int
+	}{
+		{Limits{}, nil, 4},
+		{Limits{MaxOps: 6, MaxSegments: 6}, nil, 4},
+		{Limits{MaxOps: 5}, &LimitError{"ops", 5}, 4},
+		{Limits{MaxOps: 2}, &LimitError{"ops", 2}, 0},
+		{Limits{MaxSegments: 5}, &LimitError{"segments", 5}, 4},
+		{Limits{MaxSegments: 2}, &LimitError{"segments", 2}, 2},
+		{Limits{MaxBytes: int64(len(ivgData)) - 1}, &LimitError{"bytes", int64(len(ivgData)) - 1}, 0},
+	}
+	for _, tc := range testCases {
+		var dst lineCounter
+		err := NewDecoder(bytes.NewReader(ivgData), tc.limits).Decode(&dst)
+		if tc.wantErr == nil && err != nil || tc.wantErr != nil && (err == nil || err.Error() != tc.wantErr.Error()) {
+			t.Errorf("limits %+v: got error %v, want %v", tc.limits, err, tc.wantErr)
+		}
+		if dst.lines != tc.wantLines {
+			t.Errorf("limits %+v: got %d lines, want %d", tc.limits, dst.lines, tc.wantLines)
+		}
+	}
+}
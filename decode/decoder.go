@@ -0,0 +1,271 @@
+package decode
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/reactivego/ivg"
+)
+
+// Limits bounds the work that a Decoder does for a graphic, to protect it
+// against graphics that are crafted to take much memory or time to decode and
+// rasterize. A limit of zero means no limit.
+type Limits struct {
+	// MaxBytes is the maximum size of the graphic in bytes.
+	MaxBytes int64
+	// MaxOps is the maximum number of opcodes of the graphic.
+	MaxOps int64
+	// MaxSegments is the maximum number of path segments of the graphic,
+	// counting every line, curve and arc of a repeated drawing opcode, and
+	// every closing of a path.
+	MaxSegments int64
+}
+
+// LimitError is the error that a Decoder returns when a graphic exceeds one
+// of its Limits.
+type LimitError struct {
+	// Limit is the name of the limit: "bytes", "ops" or "segments".
+	Limit string
+	// Max is the value of the limit.
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("iconvg: graphic exceeds the limit of %d %s", e.Max, e.Limit)
+}
+
+// Decoder decodes an IconVG graphic that it reads from an io.Reader, within
+// its Limits.
+type Decoder struct {
+	r      io.Reader
+	limits Limits
+}
+
+// NewDecoder returns a decoder that reads from r, within limits.
+func NewDecoder(r io.Reader, limits Limits) *Decoder {
+	return &Decoder{r: r, limits: limits}
+}
+
+// Decode reads the graphic until the end of its reader, and decodes it like
+// the Decode function does. It reads no more than MaxBytes and fails with a
+// *LimitError when the graphic is larger than that. It also fails with a
+// *LimitError as soon as the graphic exceeds MaxOps or MaxSegments, before it
+// calls dst for the op or segment that exceeds them.
+func (d *Decoder) Decode(dst ivg.Destination, opts ...DecodeOption) error {
+	r := d.r
+	if d.limits.MaxBytes > 0 {
+		r = io.LimitReader(r, d.limits.MaxBytes+1)
+	}
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if max := d.limits.MaxBytes; max > 0 && int64(len(src)) > max {
+		return &LimitError{Limit: "bytes", Max: max}
+	}
+	m := ivg.DefaultMetadata
+	return decode(dst, nil, &m, false, src, &limiter{limits: d.limits}, opts...)
+}
+
+// limiter counts the ops that are decoded and the path segments that are
+// sent to its Destination, which it no longer sends when they exceed the
+// limits. Its Destination may be nil.
+type limiter struct {
+	ivg.Destination
+	limits   Limits
+	ops      int64
+	segments int64
+}
+
+// op counts an opcode, and returns a *LimitError when there are too many.
+func (l *limiter) op() error {
+	if l.ops++; l.limits.MaxOps > 0 && l.ops > l.limits.MaxOps {
+		return &LimitError{Limit: "ops", Max: l.limits.MaxOps}
+	}
+	return nil
+}
+
+// err returns a *LimitError when there are too many segments.
+func (l *limiter) err() error {
+	if l.limits.MaxSegments > 0 && l.segments > l.limits.MaxSegments {
+		return &LimitError{Limit: "segments", Max: l.limits.MaxSegments}
+	}
+	return nil
+}
+
+// segment counts a path segment, and reports whether to send it.
+func (l *limiter) segment() bool {
+	l.segments++
+	return l.Destination != nil && l.err() == nil
+}
+
+func (l *limiter) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	if l.Destination != nil {
+		l.Destination.Reset(viewbox, palette)
+	}
+}
+
+func (l *limiter) CSel() uint8 {
+	if l.Destination != nil {
+		return l.Destination.CSel()
+	}
+	return 0
+}
+
+func (l *limiter) SetCSel(cSel uint8) {
+	if l.Destination != nil {
+		l.Destination.SetCSel(cSel)
+	}
+}
+
+func (l *limiter) NSel() uint8 {
+	if l.Destination != nil {
+		return l.Destination.NSel()
+	}
+	return 0
+}
+
+func (l *limiter) SetNSel(nSel uint8) {
+	if l.Destination != nil {
+		l.Destination.SetNSel(nSel)
+	}
+}
+
+func (l *limiter) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	if l.Destination != nil {
+		l.Destination.SetCReg(adj, incr, c)
+	}
+}
+
+func (l *limiter) SetNReg(adj uint8, incr bool, f float32) {
+	if l.Destination != nil {
+		l.Destination.SetNReg(adj, incr, f)
+	}
+}
+
+func (l *limiter) SetLOD(lod0, lod1 float32) {
+	if l.Destination != nil {
+		l.Destination.SetLOD(lod0, lod1)
+	}
+}
+
+func (l *limiter) StartPath(adj uint8, x, y float32) {
+	if l.Destination != nil {
+		l.Destination.StartPath(adj, x, y)
+	}
+}
+
+func (l *limiter) ClosePathEndPath() {
+	if l.segment() {
+		l.Destination.ClosePathEndPath()
+	}
+}
+
+func (l *limiter) ClosePathAbsMoveTo(x, y float32) {
+	if l.segment() {
+		l.Destination.ClosePathAbsMoveTo(x, y)
+	}
+}
+
+func (l *limiter) ClosePathRelMoveTo(x, y float32) {
+	if l.segment() {
+		l.Destination.ClosePathRelMoveTo(x, y)
+	}
+}
+
+func (l *limiter) AbsHLineTo(x float32) {
+	if l.segment() {
+		l.Destination.AbsHLineTo(x)
+	}
+}
+
+func (l *limiter) RelHLineTo(x float32) {
+	if l.segment() {
+		l.Destination.RelHLineTo(x)
+	}
+}
+
+func (l *limiter) AbsVLineTo(y float32) {
+	if l.segment() {
+		l.Destination.AbsVLineTo(y)
+	}
+}
+
+func (l *limiter) RelVLineTo(y float32) {
+	if l.segment() {
+		l.Destination.RelVLineTo(y)
+	}
+}
+
+func (l *limiter) AbsLineTo(x, y float32) {
+	if l.segment() {
+		l.Destination.AbsLineTo(x, y)
+	}
+}
+
+func (l *limiter) RelLineTo(x, y float32) {
+	if l.segment() {
+		l.Destination.RelLineTo(x, y)
+	}
+}
+
+func (l *limiter) AbsSmoothQuadTo(x, y float32) {
+	if l.segment() {
+		l.Destination.AbsSmoothQuadTo(x, y)
+	}
+}
+
+func (l *limiter) RelSmoothQuadTo(x, y float32) {
+	if l.segment() {
+		l.Destination.RelSmoothQuadTo(x, y)
+	}
+}
+
+func (l *limiter) AbsQuadTo(x1, y1, x, y float32) {
+	if l.segment() {
+		l.Destination.AbsQuadTo(x1, y1, x, y)
+	}
+}
+
+func (l *limiter) RelQuadTo(x1, y1, x, y float32) {
+	if l.segment() {
+		l.Destination.RelQuadTo(x1, y1, x, y)
+	}
+}
+
+func (l *limiter) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	if l.segment() {
+		l.Destination.AbsSmoothCubeTo(x2, y2, x, y)
+	}
+}
+
+func (l *limiter) RelSmoothCubeTo(x2, y2, x, y float32) {
+	if l.segment() {
+		l.Destination.RelSmoothCubeTo(x2, y2, x, y)
+	}
+}
+
+func (l *limiter) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	if l.segment() {
+		l.Destination.AbsCubeTo(x1, y1, x2, y2, x, y)
+	}
+}
+
+func (l *limiter) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	if l.segment() {
+		l.Destination.RelCubeTo(x1, y1, x2, y2, x, y)
+	}
+}
+
+func (l *limiter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	if l.segment() {
+		l.Destination.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+}
+
+func (l *limiter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	if l.segment() {
+		l.Destination.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+}
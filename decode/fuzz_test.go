@@ -0,0 +1,93 @@
+//go:build go1.18
+// +build go1.18
+
+package decode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addTestdata adds the testdata files to the seed corpus of f.
+func addTestdata(f *testing.F) {
+	filenames, err := filepath.Glob(filepath.FromSlash("../testdata/*.ivg"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+}
+
+// sameError reports whether the errors are both nil, or have the same message.
+func sameError(err1, err2 error) bool {
+	return (err1 == nil) == (err2 == nil) && fmt.Sprint(err1) == fmt.Sprint(err2)
+}
+
+// FuzzDecode tests that Decode does not panic, fails in the same way whatever
+// its Destination, and that what it decodes encodes at high resolution to a
+// graphic that decodes to the same calls.
+func FuzzDecode(f *testing.F) {
+	addTestdata(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err := Decode(nil, data)
+		var r recorder
+		if err1 := Decode(&r, data); !sameError(err, err1) {
+			t.Fatalf("Decode: got %v with a Destination, %v without", err1, err)
+		}
+		if err != nil {
+			return
+		}
+		var e resolutionPreservingEncoder
+		e.HighResolutionCoordinates = true
+		if err := checkRoundTrip(&e, e.Bytes, r.calls, 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// FuzzDisassemble tests that Disassemble does not panic, and fails when and
+// like Decode does.
+func FuzzDisassemble(f *testing.F) {
+	addTestdata(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dis, err := Disassemble(data)
+		if err1 := Decode(nil, data); !sameError(err, err1) {
+			t.Fatalf("Disassemble: got %v, Decode: got %v", err, err1)
+		}
+		if err == nil && len(dis) == 0 {
+			t.Fatal("Disassemble: got no disassembly")
+		}
+	})
+}
+
+// FuzzDecodeViewBox tests that DecodeViewBox does not panic, fails when the
+// metadata of the graphic is malformed like Decode does, and otherwise returns
+// the viewBox that Decode resets its Destination with.
+func FuzzDecodeViewBox(f *testing.F) {
+	addTestdata(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		vb, err := DecodeViewBox(data)
+		var r recorder
+		err1 := Decode(&r, data)
+		if err != nil {
+			if !sameError(err, err1) {
+				t.Fatalf("DecodeViewBox: got %v, Decode: got %v", err, err1)
+			}
+			return
+		}
+		if len(r.calls) == 0 || r.calls[0].op != "Reset" {
+			t.Fatalf("Decode: got no Reset for viewBox %v", vb)
+		}
+		got := r.calls[0].reals
+		if got[0] != vb.MinX || got[1] != vb.MinY || got[2] != vb.MaxX || got[3] != vb.MaxY {
+			t.Fatalf("DecodeViewBox: got %v, Decode: got Reset with %v", vb, got)
+		}
+	})
+}
@@ -0,0 +1,412 @@
+package decode
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/encode"
+)
+
+// call is a call of a Destination method, with its arguments by how they are
+// encoded.
+type call struct {
+	op string
+	// exact are the selectors, adjustments and flags.
+	exact [2]uint8
+	c     ivg.Color
+	// palette is the palette of Reset.
+	palette [64]color.RGBA
+	// reals are the real and zero-to-one numbers, and the viewBox of Reset,
+	// which an Encoder rounds to a multiple of 4 of their least significant
+	// bits at most.
+	reals []float32
+	// coords are the coordinates of a path, which an Encoder also quantizes.
+	coords []float32
+	angle  float32
+}
+
+func (c call) String() string {
+	return fmt.Sprintf("%s%v %v %v %v %v", c.op, c.exact, c.c, c.reals, c.coords, c.angle)
+}
+
+// near reports whether the decoded call d is c after encoding it, with its
+// coordinates quantized to multiples of quantum when they lie in [-128, 128).
+func (c call) near(d call, quantum float32) bool {
+	if c.op != d.op || c.exact != d.exact || c.c != d.c || c.palette != d.palette ||
+		len(c.reals) != len(d.reals) || len(c.coords) != len(d.coords) {
+		return false
+	}
+	for i, x := range c.reals {
+		if !nearReal(x, d.reals[i]) {
+			return false
+		}
+	}
+	for i, x := range c.coords {
+		if quantum > 0 && -128 <= x && x < 128 {
+			if math.Abs(float64(x-d.coords[i])) > float64(quantum)/2 {
+				return false
+			}
+		} else if !nearReal(x, d.coords[i]) {
+			return false
+		}
+	}
+	// The angle is a fraction of a full turn.
+	delta := float64(c.angle - d.angle)
+	return !(math.Abs(delta-math.Floor(delta+0.5)) > 1.0/(1<<20))
+}
+
+// nearReal reports whether y is x after encoding it as a 4 byte real number,
+// which rounds away its 2 least significant bits.
+func nearReal(x, y float32) bool {
+	if x == y || x != x && y != y {
+		return true
+	}
+	return math.Abs(float64(x-y)) <= math.Abs(float64(x))/(1<<21)
+}
+
+// recorder is a Destination that records the calls of its methods.
+type recorder struct {
+	calls      []call
+	cSel, nSel uint8
+}
+
+func (r *recorder) add(c call) { r.calls = append(r.calls, c) }
+
+func (r *recorder) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	r.cSel, r.nSel = 0, 0
+	r.add(call{op: "Reset", palette: palette, reals: []float32{viewbox.MinX, viewbox.MinY, viewbox.MaxX, viewbox.MaxY}})
+}
+
+func (r *recorder) CSel() uint8 { return r.cSel }
+func (r *recorder) NSel() uint8 { return r.nSel }
+
+func (r *recorder) SetCSel(cSel uint8) {
+	r.cSel = cSel & 0x3f
+	r.add(call{op: "SetCSel", exact: [2]uint8{cSel}})
+}
+
+func (r *recorder) SetNSel(nSel uint8) {
+	r.nSel = nSel & 0x3f
+	r.add(call{op: "SetNSel", exact: [2]uint8{nSel}})
+}
+
+func (r *recorder) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	r.add(call{op: "SetCReg", exact: [2]uint8{adj, flag(incr)}, c: c})
+}
+
+func (r *recorder) SetNReg(adj uint8, incr bool, f float32) {
+	r.add(call{op: "SetNReg", exact: [2]uint8{adj, flag(incr)}, reals: []float32{f}})
+}
+
+func (r *recorder) SetLOD(lod0, lod1 float32) {
+	r.add(call{op: "SetLOD", reals: []float32{lod0, lod1}})
+}
+
+func (r *recorder) StartPath(adj uint8, x, y float32) {
+	r.add(call{op: "StartPath", exact: [2]uint8{adj}, coords: []float32{x, y}})
+}
+
+func (r *recorder) ClosePathEndPath() { r.add(call{op: "ClosePathEndPath"}) }
+
+func (r *recorder) ClosePathAbsMoveTo(x, y float32) {
+	r.add(call{op: "ClosePathAbsMoveTo", coords: []float32{x, y}})
+}
+
+func (r *recorder) ClosePathRelMoveTo(x, y float32) {
+	r.add(call{op: "ClosePathRelMoveTo", coords: []float32{x, y}})
+}
+
+func (r *recorder) AbsHLineTo(x float32) { r.add(call{op: "AbsHLineTo", coords: []float32{x}}) }
+func (r *recorder) RelHLineTo(x float32) { r.add(call{op: "RelHLineTo", coords: []float32{x}}) }
+func (r *recorder) AbsVLineTo(y float32) { r.add(call{op: "AbsVLineTo", coords: []float32{y}}) }
+func (r *recorder) RelVLineTo(y float32) { r.add(call{op: "RelVLineTo", coords: []float32{y}}) }
+
+func (r *recorder) AbsLineTo(x, y float32) { r.add(call{op: "AbsLineTo", coords: []float32{x, y}}) }
+func (r *recorder) RelLineTo(x, y float32) { r.add(call{op: "RelLineTo", coords: []float32{x, y}}) }
+
+func (r *recorder) AbsSmoothQuadTo(x, y float32) {
+	r.add(call{op: "AbsSmoothQuadTo", coords: []float32{x, y}})
+}
+
+func (r *recorder) RelSmoothQuadTo(x, y float32) {
+	r.add(call{op: "RelSmoothQuadTo", coords: []float32{x, y}})
+}
+
+func (r *recorder) AbsQuadTo(x1, y1, x, y float32) {
+	r.add(call{op: "AbsQuadTo", coords: []float32{x1, y1, x, y}})
+}
+
+func (r *recorder) RelQuadTo(x1, y1, x, y float32) {
+	r.add(call{op: "RelQuadTo", coords: []float32{x1, y1, x, y}})
+}
+
+func (r *recorder) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	r.add(call{op: "AbsSmoothCubeTo", coords: []float32{x2, y2, x, y}})
+}
+
+func (r *recorder) RelSmoothCubeTo(x2, y2, x, y float32) {
+	r.add(call{op: "RelSmoothCubeTo", coords: []float32{x2, y2, x, y}})
+}
+
+func (r *recorder) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	r.add(call{op: "AbsCubeTo", coords: []float32{x1, y1, x2, y2, x, y}})
+}
+
+func (r *recorder) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	r.add(call{op: "RelCubeTo", coords: []float32{x1, y1, x2, y2, x, y}})
+}
+
+func (r *recorder) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	r.add(call{op: "AbsArcTo", exact: [2]uint8{flag(largeArc), flag(sweep)}, coords: []float32{rx, ry, x, y}, angle: xAxisRotation})
+}
+
+func (r *recorder) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	r.add(call{op: "RelArcTo", exact: [2]uint8{flag(largeArc), flag(sweep)}, coords: []float32{rx, ry, x, y}, angle: xAxisRotation})
+}
+
+func flag(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// play calls the methods of dst that the calls record.
+func play(dst ivg.Destination, calls []call) {
+	for _, c := range calls {
+		a, x := c.coords, c.exact
+		switch c.op {
+		case "Reset":
+			dst.Reset(ivg.ViewBox{MinX: c.reals[0], MinY: c.reals[1], MaxX: c.reals[2], MaxY: c.reals[3]}, c.palette)
+		case "SetCSel":
+			dst.SetCSel(x[0])
+		case "SetNSel":
+			dst.SetNSel(x[0])
+		case "SetCReg":
+			dst.SetCReg(x[0], x[1] != 0, c.c)
+		case "SetNReg":
+			dst.SetNReg(x[0], x[1] != 0, c.reals[0])
+		case "SetLOD":
+			dst.SetLOD(c.reals[0], c.reals[1])
+		case "StartPath":
+			dst.StartPath(x[0], a[0], a[1])
+		case "ClosePathEndPath":
+			dst.ClosePathEndPath()
+		case "ClosePathAbsMoveTo":
+			dst.ClosePathAbsMoveTo(a[0], a[1])
+		case "ClosePathRelMoveTo":
+			dst.ClosePathRelMoveTo(a[0], a[1])
+		case "AbsHLineTo":
+			dst.AbsHLineTo(a[0])
+		case "RelHLineTo":
+			dst.RelHLineTo(a[0])
+		case "AbsVLineTo":
+			dst.AbsVLineTo(a[0])
+		case "RelVLineTo":
+			dst.RelVLineTo(a[0])
+		case "AbsLineTo":
+			dst.AbsLineTo(a[0], a[1])
+		case "RelLineTo":
+			dst.RelLineTo(a[0], a[1])
+		case "AbsSmoothQuadTo":
+			dst.AbsSmoothQuadTo(a[0], a[1])
+		case "RelSmoothQuadTo":
+			dst.RelSmoothQuadTo(a[0], a[1])
+		case "AbsQuadTo":
+			dst.AbsQuadTo(a[0], a[1], a[2], a[3])
+		case "RelQuadTo":
+			dst.RelQuadTo(a[0], a[1], a[2], a[3])
+		case "AbsSmoothCubeTo":
+			dst.AbsSmoothCubeTo(a[0], a[1], a[2], a[3])
+		case "RelSmoothCubeTo":
+			dst.RelSmoothCubeTo(a[0], a[1], a[2], a[3])
+		case "AbsCubeTo":
+			dst.AbsCubeTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		case "RelCubeTo":
+			dst.RelCubeTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		case "AbsArcTo":
+			dst.AbsArcTo(a[0], a[1], c.angle, x[0] != 0, x[1] != 0, a[2], a[3])
+		case "RelArcTo":
+			dst.RelArcTo(a[0], a[1], c.angle, x[0] != 0, x[1] != 0, a[2], a[3])
+		default:
+			panic("unknown op " + c.op)
+		}
+	}
+}
+
+// graphic is a random valid sequence of calls of Destination methods, that an
+// Encoder encodes without error.
+type graphic []call
+
+var drawOps = [...]struct {
+	op      string
+	nCoords int
+}{
+	{"AbsHLineTo", 1}, {"RelHLineTo", 1}, {"AbsVLineTo", 1}, {"RelVLineTo", 1},
+	{"AbsLineTo", 2}, {"RelLineTo", 2}, {"AbsSmoothQuadTo", 2}, {"RelSmoothQuadTo", 2},
+	{"AbsQuadTo", 4}, {"RelQuadTo", 4}, {"AbsSmoothCubeTo", 4}, {"RelSmoothCubeTo", 4},
+	{"AbsCubeTo", 6}, {"RelCubeTo", 6}, {"AbsArcTo", 4}, {"RelArcTo", 4},
+	{"ClosePathAbsMoveTo", 2}, {"ClosePathRelMoveTo", 2},
+}
+
+func (graphic) Generate(r *rand.Rand, size int) reflect.Value {
+	g := graphic{randomReset(r)}
+	for n := r.Intn(size + 1); n > 0; n-- {
+		switch r.Intn(6) {
+		case 0:
+			g = append(g, call{op: "SetCSel", exact: [2]uint8{uint8(r.Intn(64))}})
+		case 1:
+			g = append(g, call{op: "SetNSel", exact: [2]uint8{uint8(r.Intn(64))}})
+		case 2:
+			g = append(g, call{op: "SetCReg", exact: randomAdj(r), c: randomColor(r)})
+		case 3:
+			g = append(g, call{op: "SetNReg", exact: randomAdj(r), reals: []float32{randomReal(r)}})
+		case 4:
+			lod1 := float32(math.Inf(1))
+			if r.Intn(2) == 0 {
+				lod1 = randomReal(r)
+			}
+			g = append(g, call{op: "SetLOD", reals: []float32{randomReal(r), lod1}})
+		case 5:
+			g = append(g, call{op: "StartPath", exact: [2]uint8{uint8(r.Intn(7))}, coords: randomCoords(r, 2)})
+			for m := r.Intn(size + 1); m > 0; m-- {
+				d := drawOps[r.Intn(len(drawOps))]
+				c := call{op: d.op, coords: randomCoords(r, d.nCoords)}
+				if d.op == "AbsArcTo" || d.op == "RelArcTo" {
+					c.exact = [2]uint8{uint8(r.Intn(2)), uint8(r.Intn(2))}
+					c.angle = r.Float32()
+				}
+				g = append(g, c)
+			}
+			g = append(g, call{op: "ClosePathEndPath"})
+		}
+	}
+	return reflect.ValueOf(g)
+}
+
+func randomReset(r *rand.Rand) call {
+	c := call{op: "Reset", palette: ivg.DefaultPalette, reals: []float32{-32, -32, 32, 32}}
+	if r.Intn(2) == 0 {
+		x, y := randomCoords(r, 2)[0], randomCoords(r, 2)[1]
+		c.reals = []float32{x, y, x + r.Float32()*100, y + r.Float32()*100}
+	}
+	if r.Intn(2) == 0 {
+		for i := r.Intn(64); i >= 0; i-- {
+			a := uint8(r.Intn(256))
+			c.palette[r.Intn(64)] = color.RGBA{uint8(r.Intn(int(a) + 1)), uint8(r.Intn(int(a) + 1)), uint8(r.Intn(int(a) + 1)), a}
+		}
+	}
+	return c
+}
+
+// randomAdj returns a selector adjustment and whether to increment the
+// selector.
+func randomAdj(r *rand.Rand) [2]uint8 {
+	if r.Intn(4) == 0 {
+		return [2]uint8{0, 1}
+	}
+	return [2]uint8{uint8(r.Intn(7)), 0}
+}
+
+func randomColor(r *rand.Rand) ivg.Color {
+	switch r.Intn(5) {
+	case 0:
+		return ivg.PaletteIndexColor(uint8(r.Intn(64)))
+	case 1:
+		return ivg.CRegColor(uint8(r.Intn(64)))
+	case 2:
+		return ivg.BlendColor(uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)))
+	case 3:
+		// A color with a 1, 2 or 3 byte encoding.
+		return ivg.RGBAColor(color.RGBA{0x11 * uint8(r.Intn(16)), 0x11 * uint8(r.Intn(16)), 0x11 * uint8(r.Intn(16)), 0xff})
+	}
+	return ivg.RGBAColor(color.RGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256))})
+}
+
+// randomReal returns a number that has a 1, 2 or 4 byte encoding as a real,
+// coordinate or zero-to-one number.
+func randomReal(r *rand.Rand) float32 {
+	switch r.Intn(4) {
+	case 0:
+		return float32(r.Intn(1 << 14))
+	case 1:
+		return float32(r.Intn(15120)) / 15120
+	case 2:
+		return float32(r.Intn(128*64)-64*64) / 64
+	}
+	return float32(r.NormFloat64() * 1000)
+}
+
+func randomCoords(r *rand.Rand, n int) []float32 {
+	coords := make([]float32, n)
+	for i := range coords {
+		switch r.Intn(4) {
+		case 0:
+			coords[i] = float32(r.Intn(128) - 64)
+		case 1:
+			coords[i] = float32(r.Intn(256*64)-128*64) / 64
+		case 2:
+			coords[i] = float32(r.NormFloat64() * 64)
+		default:
+			coords[i] = float32(r.NormFloat64() * 1e6)
+		}
+	}
+	return coords
+}
+
+// checkRoundTrip encodes the calls with e, decodes them and reports how the
+// decoded calls differ, when their coordinates are quantized to multiples of
+// quantum.
+func checkRoundTrip(e ivg.Destination, bytes func() ([]byte, error), calls []call, quantum float32) error {
+	play(e, calls)
+	data, err := bytes()
+	if err != nil {
+		return fmt.Errorf("Encoder.Bytes: %v", err)
+	}
+	var r recorder
+	if err := Decode(&r, data); err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	if len(r.calls) != len(calls) {
+		return fmt.Errorf("got %d calls, want %d", len(r.calls), len(calls))
+	}
+	for i, c := range calls {
+		if !c.near(r.calls[i], quantum) {
+			return fmt.Errorf("call %d: got %v, want %v", i, r.calls[i], c)
+		}
+	}
+	return nil
+}
+
+// TestRoundTripProperty tests that random graphics decode to the calls that
+// encoded them, up to quantization.
+func TestRoundTripProperty(t *testing.T) {
+	f := func(g graphic) bool {
+		var e encode.Encoder
+		if err := checkRoundTrip(&e, e.Bytes, g, 1.0/64); err != nil {
+			t.Error(err)
+			return false
+		}
+		var hires resolutionPreservingEncoder
+		hires.HighResolutionCoordinates = true
+		if err := checkRoundTrip(&hires, hires.Bytes, g, 0); err != nil {
+			t.Errorf("high resolution: %v", err)
+			return false
+		}
+		return true
+	}
+	config := &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(f, config); err != nil {
+		// The graphic is too long to print.
+		if err, ok := err.(*quick.CheckError); ok {
+			t.Fatalf("graphic #%d does not round-trip", err.Count)
+		}
+		t.Fatal(err)
+	}
+}
@@ -46,6 +46,16 @@ func (b *buffer) encodeReal(f float32) int {
 }
 
 func (b *buffer) encode4ByteReal(f float32) {
+	u := round4ByteReal(f)
+
+	// A 4 byte encoding has the low two bits set.
+	u |= 0x03
+	*b = append(*b, uint8(u), uint8(u>>8), uint8(u>>16), uint8(u>>24))
+}
+
+// round4ByteReal returns the bits of f, rounded for a 4 byte encoding, of
+// which a decoder ignores the low two bits.
+func round4ByteReal(f float32) uint32 {
 	u := math.Float32bits(f)
 
 	// Round the fractional bits (the low 23 bits) to the nearest multiple of
@@ -54,11 +64,7 @@ func (b *buffer) encode4ByteReal(f float32) {
 	if v < 0x007ffffe {
 		v += 2
 	}
-	u = (u & 0xff800000) | v
-
-	// A 4 byte encoding has the low two bits set.
-	u |= 0x03
-	*b = append(*b, uint8(u), uint8(u>>8), uint8(u>>16), uint8(u>>24))
+	return (u & 0xff800000) | v
 }
 
 func (b *buffer) encodeCoordinate(f float32) int {
@@ -78,6 +84,18 @@ func (b *buffer) encodeCoordinate(f float32) int {
 	return 4
 }
 
+// encodedCoordinate returns the coordinate f as it decodes after
+// encodeCoordinate.
+func encodedCoordinate(f float32) float32 {
+	if i := int32(f); -64 <= i && i < +64 && float32(i) == f {
+		return f
+	}
+	if i := int32(f * 64); -128*64 <= i && i < +128*64 && float32(i) == f*64 {
+		return f
+	}
+	return math.Float32frombits(round4ByteReal(f) &^ 0x03)
+}
+
 func (b *buffer) encodeAngle(f float32) int {
 	// Normalize f to the range [0, 1).
 	g := float64(f)
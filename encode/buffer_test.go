@@ -7,7 +7,10 @@ package encode
 import (
 	"image/color"
 	"math"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	"github.com/reactivego/ivg"
 )
@@ -267,3 +270,135 @@ func TestEncodeColor(t *testing.T) {
 		}
 	}
 }
+
+// natural returns the natural number that b encodes, and the number of bytes
+// of its encoding, which is 0 when b is not one encoded number.
+func natural(b buffer) (u uint32, n int) {
+	switch {
+	case len(b) == 1 && b[0]&0x01 == 0:
+		return uint32(b[0]) >> 1, 1
+	case len(b) == 2 && b[0]&0x03 == 0x01:
+		return (uint32(b[0]) | uint32(b[1])<<8) >> 2, 2
+	case len(b) == 4 && b[0]&0x03 == 0x03:
+		return (uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24) >> 2, 4
+	}
+	return 0, 0
+}
+
+// roundsTo reports whether the 4 byte real number b encodes is f with its 23
+// fractional bits rounded to the nearest multiple of 4, or down when rounding
+// up would overflow into its exponent.
+func roundsTo(b buffer, f float32) bool {
+	u, n := natural(b)
+	if n != 4 {
+		return false
+	}
+	got, want := u<<2, math.Float32bits(f)
+	delta := int64(got) - int64(want)
+	return got&0xff800000 == want&0xff800000 && -3 <= delta && delta <= 2
+}
+
+func TestEncodeNaturalProperty(t *testing.T) {
+	f := func(u uint32) bool {
+		u &= 1<<30 - 1
+		var b buffer
+		b.encodeNatural(u)
+		got, n := natural(b)
+		wantN := 4
+		if u < 1<<7 {
+			wantN = 1
+		} else if u < 1<<14 {
+			wantN = 2
+		}
+		return got == u && n == wantN
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeRealProperty(t *testing.T) {
+	f := func(x float32) bool {
+		var b buffer
+		n := b.encodeReal(x)
+		if n != len(b) {
+			return false
+		}
+		u, m := natural(b)
+		if i := uint32(x); float32(i) == x && i < 1<<14 {
+			return m == n && m < 4 && u == i
+		}
+		return roundsTo(b, x)
+	}
+	for _, x := range []float32{0, 1, 127, 128, 16383, 16384, -1, 0.5, float32(math.Inf(1)), math.Float32frombits(0x3fffffff)} {
+		if !f(x) {
+			t.Errorf("value=%v: not encoded as the shortest real number", x)
+		}
+	}
+	if err := quick.Check(f, &quick.Config{Values: numberValues}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeCoordinateProperty(t *testing.T) {
+	f := func(x float32) bool {
+		var b buffer
+		n := b.encodeCoordinate(x)
+		if n != len(b) {
+			return false
+		}
+		u, m := natural(b)
+		switch {
+		case x == float32(int32(x)) && -64 <= x && x < 64:
+			return m == 1 && float32(int32(u)-64) == x
+		case x*64 == float32(int32(x*64)) && -128 <= x && x < 128:
+			return m == 2 && float32(int32(u)-128*64)/64 == x
+		}
+		return roundsTo(b, x)
+	}
+	if err := quick.Check(f, &quick.Config{Values: numberValues}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeZeroToOneProperty(t *testing.T) {
+	f := func(x float32) bool {
+		var b buffer
+		n := b.encodeZeroToOne(x)
+		if n != len(b) {
+			return false
+		}
+		u, m := natural(b)
+		switch {
+		case m == 1:
+			return math.Abs(float64(u)/120-float64(x)) < 1e-7
+		case m == 2:
+			return math.Abs(float64(u)/15120-float64(x)) < 1e-7
+		}
+		// A number that is a multiple of 1/15120 in [0, 1) is shorter.
+		if i := uint32(x * 15120); float32(i) == x*15120 && i < 15120 {
+			return false
+		}
+		return roundsTo(b, x)
+	}
+	if err := quick.Check(f, &quick.Config{Values: numberValues}); err != nil {
+		t.Error(err)
+	}
+}
+
+// numberValues generates a number for a property, that is an integer, a
+// multiple of 1/64, a multiple of 1/15120 in [0, 1) or any float32.
+func numberValues(args []reflect.Value, r *rand.Rand) {
+	var x float32
+	switch r.Intn(4) {
+	case 0:
+		x = float32(r.Intn(1<<15) - 1<<14)
+	case 1:
+		x = float32(r.Intn(512*64)-256*64) / 64
+	case 2:
+		x = float32(r.Intn(15120)) / 15120
+	default:
+		x = math.Float32frombits(r.Uint32())
+	}
+	args[0] = reflect.ValueOf(x)
+}
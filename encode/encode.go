@@ -60,10 +60,45 @@ type Encoder struct {
 	// encoding format.
 	HighResolutionCoordinates bool
 
-	// highResolutionCoordinates is a local copy, copied during StartPath, to
-	// avoid having to specify the semantics of modifying the exported field
-	// while drawing.
+	// Quantum is the spacing of the grid that the encoder quantizes
+	// coordinate numbers in [-128, 128) of subsequent paths to, instead of
+	// 1/64th of a unit, like 1.0/16 or 1.0/256. The encoder quantizes each
+	// coordinate on its own, so the quantization errors of relative
+	// coordinates add up along a path.
+	//
+	// A Quantum of a power of two that is at least 1/64th keeps coordinates
+	// in 1 or 2 bytes. A finer one gives greater accuracy, but encodes most
+	// coordinates in 4 bytes.
+	Quantum float32
+
+	// Tolerance, if positive, is the maximum deviation in pixels of the
+	// coordinates of subsequent paths, at a height of Height pixels, instead
+	// of quantizing them to a grid of Quantum. For each coordinate, the
+	// encoder picks the coarsest grid, from 1 unit to 1/256th of a unit,
+	// that quantizes it within Tolerance, so that it is encoded in as few
+	// bytes as possible. It feeds the quantization errors of relative
+	// coordinates back, so that they do not add up along a path.
+	Tolerance float32
+
+	// Height is the height in pixels that Tolerance is measured at, which a
+	// graphic is drawn at. Zero means the height of the viewBox, one pixel
+	// per unit.
+	Height float32
+
+	// highResolutionCoordinates, quantum and tolerance are local copies,
+	// copied during StartPath, to avoid having to specify the semantics of
+	// modifying the exported fields while drawing. The tolerance is in
+	// units.
 	highResolutionCoordinates bool
+	quantum                   float32
+	tolerance                 float64
+
+	// pen and start are the current point and the start of the subpath as
+	// given, and encPen and encStart as encoded. maxDeviation is the maximum
+	// deviation of the encoded coordinates from the given ones.
+	pen, start       [2]float64
+	encPen, encStart [2]float64
+	maxDeviation     float64
 
 	buf      buffer
 	altBuf   buffer
@@ -90,12 +125,15 @@ func (e *Encoder) Bytes() ([]byte, error) {
 	if e.mode == modeInitial {
 		e.appendDefaultMetadata()
 	}
+	// A path that is not ended yet still has its last drawing ops.
+	e.flushDrawOps()
 	return []byte(e.buf), nil
 }
 
 // Reset resets the Encoder for the given Metadata.
 //
-// This includes setting e.HighResolutionCoordinates to false.
+// This includes setting e.HighResolutionCoordinates to false, and
+// e.Quantum, e.Tolerance and e.Height to zero.
 func (e *Encoder) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
 	m := ivg.Metadata{ViewBox: viewbox, Palette: palette}
 	*e = Encoder{
@@ -182,6 +220,7 @@ func (e *Encoder) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
 func (e *Encoder) appendDefaultMetadata() {
 	e.buf = append(e.buf[:0], ivg.Magic...)
 	e.buf = append(e.buf, 0x00) // There are zero metadata chunks.
+	e.metadata = ivg.DefaultMetadata
 	e.mode = modeStyling
 }
 
@@ -330,12 +369,30 @@ func (e *Encoder) StartPath(adj uint8, x, y float32) {
 		return
 	}
 	e.highResolutionCoordinates = e.HighResolutionCoordinates
+	e.quantum = e.Quantum
+	if e.quantum <= 0 {
+		e.quantum = 1.0 / 64
+	}
+	e.tolerance = float64(e.Tolerance)
+	if vb := e.metadata.ViewBox; e.Height > 0 {
+		e.tolerance *= float64(vb.MaxY-vb.MinY) / float64(e.Height)
+	}
+	p := [2]float32{x, y}
+	e.point(p[:], false)
+	e.start, e.encStart = e.pen, e.encPen
 	e.buf = append(e.buf, uint8(0xc0+adj))
-	e.buf.encodeCoordinate(e.quantize(x))
-	e.buf.encodeCoordinate(e.quantize(y))
+	e.buf.encodeCoordinate(p[0])
+	e.buf.encodeCoordinate(p[1])
 	e.mode = modeDrawing
 }
 
+// MaxDeviation returns the maximum deviation in units of the coordinates that
+// the encoder encoded since Reset from the given coordinates, when decoded.
+// For a relative coordinate, it is that of the point it moves to.
+func (e *Encoder) MaxDeviation() float32 {
+	return float32(e.maxDeviation)
+}
+
 func (e *Encoder) AbsHLineTo(x float32)                   { e.draw('H', x, 0, 0, 0, 0, 0) }
 func (e *Encoder) RelHLineTo(x float32)                   { e.draw('h', x, 0, 0, 0, 0, 0) }
 func (e *Encoder) AbsVLineTo(y float32)                   { e.draw('V', y, 0, 0, 0, 0, 0) }
@@ -385,6 +442,9 @@ func (e *Encoder) draw(drawOp byte, arg0, arg1, arg2, arg3, arg4, arg5 float32)
 		e.flushDrawOps()
 	}
 	e.drawOp = drawOp
+	args := [6]float32{arg0, arg1, arg2, arg3, arg4, arg5}
+	e.place(drawOp, &args)
+	arg0, arg1, arg2, arg3, arg4, arg5 = args[0], args[1], args[2], args[3], args[4], args[5]
 	switch drawOps[drawOp].nArgs {
 	case 0:
 		// No-op.
@@ -428,17 +488,17 @@ func (e *Encoder) flushDrawOps() {
 			switch e.drawOp {
 			default:
 				for j := m * int(op.nArgs); j > 0; j-- {
-					e.buf.encodeCoordinate(e.quantize(e.drawArgs[i]))
+					e.buf.encodeCoordinate(e.drawArgs[i])
 					i++
 				}
 			case 'A', 'a':
 				for j := m; j > 0; j-- {
-					e.buf.encodeCoordinate(e.quantize(e.drawArgs[i+0]))
-					e.buf.encodeCoordinate(e.quantize(e.drawArgs[i+1]))
+					e.buf.encodeCoordinate(e.drawArgs[i+0])
+					e.buf.encodeCoordinate(e.drawArgs[i+1])
 					e.buf.encodeAngle(e.drawArgs[i+2])
 					e.buf.encodeNatural(uint32(e.drawArgs[i+3]))
-					e.buf.encodeCoordinate(e.quantize(e.drawArgs[i+4]))
-					e.buf.encodeCoordinate(e.quantize(e.drawArgs[i+5]))
+					e.buf.encodeCoordinate(e.drawArgs[i+4])
+					e.buf.encodeCoordinate(e.drawArgs[i+5])
 					i += 6
 				}
 			}
@@ -451,12 +511,101 @@ func (e *Encoder) flushDrawOps() {
 	e.drawArgs = e.drawArgs[:0]
 }
 
+// place quantizes the coordinates of the args of drawOp, and moves the pen to
+// the point that it draws to.
+func (e *Encoder) place(drawOp byte, args *[6]float32) {
+	rel := 'a' <= drawOp && drawOp <= 'z'
+	switch drawOp {
+	case 'Z':
+		e.pen, e.encPen = e.start, e.encStart
+	case 'Y', 'y':
+		// The move is relative to the start of the closed subpath.
+		e.pen, e.encPen = e.start, e.encStart
+		e.point(args[0:2], rel)
+		e.start, e.encStart = e.pen, e.encPen
+	case 'H', 'h':
+		e.coordinate(args[0:1], 0, rel)
+	case 'V', 'v':
+		e.coordinate(args[0:1], 1, rel)
+	case 'L', 'l', 'T', 't':
+		e.point(args[0:2], rel)
+	case 'Q', 'q', 'S', 's':
+		e.control(args[0:2], rel)
+		e.point(args[2:4], rel)
+	case 'C', 'c':
+		e.control(args[0:2], rel)
+		e.control(args[2:4], rel)
+		e.point(args[4:6], rel)
+	case 'A', 'a':
+		// The radii are lengths, not points.
+		for i := 0; i < 2; i++ {
+			r := args[i]
+			args[i] = e.quantize(r)
+			e.deviate(float64(encodedCoordinate(args[i])) - float64(r))
+		}
+		e.point(args[4:6], rel)
+	}
+}
+
+// point quantizes the point p, which is relative to the pen when rel is true,
+// and moves the pen to it.
+func (e *Encoder) point(p []float32, rel bool) {
+	e.coordinate(p[0:1], 0, rel)
+	e.coordinate(p[1:2], 1, rel)
+}
+
+// control quantizes the control point p, which is relative to the pen when
+// rel is true.
+func (e *Encoder) control(p []float32, rel bool) {
+	pen, encPen := e.pen, e.encPen
+	e.point(p, rel)
+	e.pen, e.encPen = pen, encPen
+}
+
+// coordinate quantizes the coordinate c[0] of the axis, which is relative to
+// the pen when rel is true, and moves the pen along the axis to it. When
+// quantizing within a tolerance, it quantizes a relative coordinate so that
+// the point that it moves to is within the tolerance.
+func (e *Encoder) coordinate(c []float32, axis int, rel bool) {
+	want, origin := float64(c[0]), 0.0
+	if rel {
+		want += e.pen[axis]
+		origin = e.encPen[axis]
+	}
+	if e.tolerance > 0 && !e.highResolutionCoordinates {
+		c[0] = float32(want - origin)
+	}
+	c[0] = e.quantize(c[0])
+	got := origin + float64(encodedCoordinate(c[0]))
+	e.deviate(got - want)
+	e.pen[axis], e.encPen[axis] = want, got
+}
+
+// deviate records the deviation d of an encoded coordinate.
+func (e *Encoder) deviate(d float64) {
+	if d = math.Abs(d); d > e.maxDeviation {
+		e.maxDeviation = d
+	}
+}
+
 func (e *Encoder) quantize(coord float32) float32 {
-	if !e.highResolutionCoordinates && (-128 <= coord && coord < 128) {
-		x := math.Floor(float64(coord*64 + 0.5))
-		return float32(x) / 64
+	if e.highResolutionCoordinates || !(-128 <= coord && coord < 128) {
+		return coord
+	}
+	if e.tolerance > 0 {
+		// The coarsest grid is the most likely to quantize to a coordinate
+		// that is encoded in 1 byte.
+		for scale := float32(1); scale <= 256; scale *= 2 {
+			x := float32(math.Floor(float64(coord*scale+0.5))) / scale
+			if math.Abs(float64(x-coord)) <= e.tolerance {
+				return x
+			}
+		}
+		return coord
 	}
-	return coord
+	scale := 1 / e.quantum
+	x := math.Floor(float64(coord*scale + 0.5))
+	return float32(x) / scale
 }
 
 var drawOps = [256]struct {
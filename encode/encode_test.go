@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
 )
 
 // overwriteTestdataFiles is temporarily set to true when adding new
@@ -252,3 +253,152 @@ func TestEncodeVideo005Primitive(t *testing.T) {
 
 	testEncode(t, &e, "../testdata/video-005.primitive.ivg")
 }
+
+// TestBytesUnendedPath tests that Bytes includes the drawing ops of a path
+// that is not ended yet.
+func TestBytesUnendedPath(t *testing.T) {
+	path := func(e *Encoder) {
+		e.StartPath(0, 2, 2)
+		e.AbsLineTo(10, 2)
+		e.AbsLineTo(10, 10)
+	}
+	var ended Encoder
+	path(&ended)
+	ended.ClosePathEndPath()
+	want, err := ended.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without its ClosePathEndPath, the path is the same but for the last
+	// byte.
+	want = want[:len(want)-1]
+
+	var e Encoder
+	path(&e)
+	for i := 0; i < 2; i++ {
+		got, err := e.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Bytes call #%d: got % x, want % x", i, got, want)
+		}
+	}
+}
+
+// points is a Destination that records the points that the lines of a path
+// go to.
+type points struct {
+	ivg.Destination
+	points [][2]float64
+}
+
+func (p *points) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {}
+func (p *points) ClosePathEndPath()                                 {}
+
+func (p *points) StartPath(adj uint8, x, y float32) {
+	p.points = append(p.points, [2]float64{float64(x), float64(y)})
+}
+
+func (p *points) RelLineTo(x, y float32) {
+	pen := p.points[len(p.points)-1]
+	p.points = append(p.points, [2]float64{pen[0] + float64(x), pen[1] + float64(y)})
+}
+
+func TestQuantization(t *testing.T) {
+	// The path steps by relative lines that are not multiples of 1/64th of
+	// a unit, of which the quantization errors add up.
+	const steps = 200
+	step := [2]float32{0.337, -0.213}
+	want := [][2]float64{{-31.9, 31.9}}
+	for i := 0; i < steps; i++ {
+		pen := want[len(want)-1]
+		want = append(want, [2]float64{pen[0] + float64(step[0]), pen[1] + float64(step[1])})
+	}
+
+	testCases := []struct {
+		name                       string
+		quantum, tolerance, height float32
+		// maxDeviation is the maximum deviation in units that the encoder
+		// may report, and maxBytes the maximum size of the coordinates of
+		// the lines.
+		maxDeviation float64
+		maxBytes     int
+	}{
+		{"default", 0, 0, 0, 2, 2 * 2 * steps},
+		{"quantum 1/256", 1.0 / 256, 0, 0, steps / 512.0, 4 * 2 * steps},
+		{"quantum 1/16", 1.0 / 16, 0, 0, steps / 32.0, 2 * 2 * steps},
+		{"tolerance", 0, 0.5, 0, 0.5, 1 * 2 * steps},
+		{"tolerance at height", 0, 0.05, 16, 0.2, 2 * 2 * steps},
+		{"tolerance below 1/256", 0, 1.0 / 1024, 0, 1.0 / 1024, 4 * 2 * steps},
+	}
+	for _, tc := range testCases {
+		var e Encoder
+		e.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+		e.Quantum, e.Tolerance, e.Height = tc.quantum, tc.tolerance, tc.height
+		e.StartPath(0, float32(want[0][0]), float32(want[0][1]))
+		for i := 0; i < steps; i++ {
+			e.RelLineTo(step[0], step[1])
+		}
+		e.ClosePathEndPath()
+		data, err := e.Bytes()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		var p points
+		if err := decode.Decode(&p, data); err != nil {
+			t.Fatalf("%s: Decode: %v", tc.name, err)
+		}
+		if len(p.points) != len(want) {
+			t.Fatalf("%s: got %d points, want %d", tc.name, len(p.points), len(want))
+		}
+		deviation := 0.0
+		for i, got := range p.points {
+			for j := range got {
+				deviation = math.Max(deviation, math.Abs(got[j]-want[i][j]))
+			}
+		}
+		if got := float64(e.MaxDeviation()); math.Abs(got-deviation) > 1e-4 {
+			t.Errorf("%s: MaxDeviation: got %v, want %v", tc.name, got, deviation)
+		}
+		if deviation > tc.maxDeviation {
+			t.Errorf("%s: got deviation %v, want at most %v", tc.name, deviation, tc.maxDeviation)
+		}
+		// The graphic also has a header, the start of the path and the
+		// opcodes of the lines.
+		if overhead := 5 + 9 + steps/32 + 2; len(data) > tc.maxBytes+overhead {
+			t.Errorf("%s: got %d bytes, want at most %d", tc.name, len(data), tc.maxBytes+overhead)
+		}
+	}
+}
+
+// TestQuantizationPerPath tests that the encoder quantizes a path like its
+// fields were when the path started.
+func TestQuantizationPerPath(t *testing.T) {
+	var e Encoder
+	e.StartPath(0, 0.3, 0.3)
+	e.Tolerance = 0.5
+	e.AbsLineTo(0.3, 10.3)
+	e.ClosePathAbsMoveTo(10.3, 0.3)
+	e.AbsLineTo(10.3, 10.3)
+	e.ClosePathEndPath()
+	e.StartPath(0, 20.3, 0.3)
+	e.AbsLineTo(20.3, 10.3)
+	e.ClosePathEndPath()
+	data, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first path quantizes to 1/64th of a unit, and the second to whole
+	// units.
+	want := "\x89IVG\x00" +
+		"\xc0\x4d\x80\x4d\x80\x00\x4d\x80\x4d\x8a\xe2\x4d\x8a\x4d\x80\x00\x4d\x8a\x4d\x8a\xe1" +
+		"\xc0\xa8\x80\x00\xa8\x94\xe1"
+	if string(data) != want {
+		t.Errorf("got\n% x\nwant\n% x", data, want)
+	}
+	if got := e.MaxDeviation(); math.Abs(float64(got)-0.3) > 1e-6 {
+		t.Errorf("MaxDeviation: got %v, want 0.3", got)
+	}
+}
@@ -16,6 +16,11 @@ func (e Error) Error() string { return string(e) }
 const (
 	CSELUsedAsBothGradientAndStop = Error("ivg: CSEL used as both gradient and stop")
 	TooManyGradientStops          = Error("ivg: too many gradient stops")
+	PathDataMissingMoveTo         = Error("ivg: path data does not start with a moveto")
+	PathDataMissingNumber         = Error("ivg: path data is missing a number")
+	PathDataInvalidArcFlag        = Error("ivg: path data has an invalid arc flag")
+	PathDataCrossingSubpaths      = Error("ivg: path data has crossing subpaths")
+	PolylineOddCoordinates        = Error("ivg: polyline has an odd number of coordinates")
 )
 
 func UnrecognizedPathDataVerb(verb byte) Error {
@@ -95,6 +100,8 @@ type GradientStop struct {
 
 type Generator struct {
 	ivg.Destination
+	// transforms is the stack of transformations pushed by PushTransform,
+	// whose top is the current transformation. It is empty for the identity.
 	transforms []Aff3
 }
 
@@ -104,7 +111,8 @@ func (g *Generator) SetDestination(d ivg.Destination) {
 
 // SetLinearGradient is like SetGradient with shape=ShapeLinear except that the
 // transformation matrix is implicitly defined by two boundary points (x1, y1)
-// and (x2, y2).
+// and (x2, y2). Like path data, the points are transformed by the current
+// transformation.
 func (g *Generator) SetLinearGradient(x1, y1, x2, y2 float32, spread GradientSpread, stops []GradientStop) error {
 	// See the package documentation's appendix for a derivation of the
 	// transformation matrix.
@@ -116,12 +124,13 @@ func (g *Generator) SetLinearGradient(x1, y1, x2, y2 float32, spread GradientSpr
 		ma, mb, -ma*x1 - mb*y1,
 		0, 0, 0,
 	}
-	return g.SetGradient(GradientShapeLinear, spread, stops, vbx2grad)
+	return g.SetGradient(GradientShapeLinear, spread, stops, g.untransform(vbx2grad))
 }
 
 // SetCircularGradient is like SetGradient with radial=true except that the
 // transformation matrix is implicitly defined by a center (cx, cy) and a
-// radius vector (rx, ry) such that (cx+rx, cy+ry) is on the circle.
+// radius vector (rx, ry) such that (cx+rx, cy+ry) is on the circle. Like path
+// data, the circle is transformed by the current transformation.
 func (g *Generator) SetCircularGradient(cx, cy, rx, ry float32, spread GradientSpread, stops []GradientStop) error {
 	// See the package documentation's appendix for a derivation of the
 	// transformation matrix.
@@ -130,13 +139,14 @@ func (g *Generator) SetCircularGradient(cx, cy, rx, ry float32, spread GradientS
 		invR, 0, -cx * invR,
 		0, invR, -cy * invR,
 	}
-	return g.SetGradient(GradientShapeRadial, spread, stops, vbx2grad)
+	return g.SetGradient(GradientShapeRadial, spread, stops, g.untransform(vbx2grad))
 }
 
 // SetEllipticalGradient is like SetGradient with radial=true except that the
 // transformation matrix is implicitly defined by a center (cx, cy) and two
 // axis vectors (rx, ry) and (sx, sy) such that (cx+rx, cy+ry) and (cx+sx,
-// cy+sy) are on the ellipse.
+// cy+sy) are on the ellipse. Like path data, the ellipse is transformed by the
+// current transformation.
 func (d *Generator) SetEllipticalGradient(cx, cy, rx, ry, sx, sy float32, spread GradientSpread, stops []GradientStop) error {
 	// Explicitly disable FMA in the floating-point calculations below
 	// to get consistent results on all platforms, and in turn produce
@@ -158,7 +168,7 @@ func (d *Generator) SetEllipticalGradient(cx, cy, rx, ry, sx, sy float32, spread
 		ma, mb, mc,
 		md, me, mf,
 	}
-	return d.SetGradient(GradientShapeRadial, spread, stops, vbx2grad)
+	return d.SetGradient(GradientShapeRadial, spread, stops, d.untransform(vbx2grad))
 }
 
 // SetGradient sets CREG[CSEL] to encode the gradient whose colors defined by
@@ -213,16 +223,177 @@ func (d *Generator) SetGradient(shape GradientShape, spread GradientSpread, stop
 	return nil
 }
 
+// SetTransform sets the current transformation, the affine transformation
+// that SetPathData applies to path data, to the concatenation of transforms.
+// Any transformation, including rotations and skews, is applied exactly:
+// relative segments are transformed as vectors, and horizontal and vertical
+// lines and elliptical arcs are rewritten when the transformation does not
+// keep their shape.
+//
+// SetTransform replaces the current transformation, but not the ones that
+// PushTransform saved.
 func (e *Generator) SetTransform(transforms ...Aff3) {
-	e.transforms = []Aff3{Concat(transforms...)}
+	if n := len(e.transforms); n > 0 {
+		e.transforms[n-1] = Concat(transforms...)
+	} else {
+		e.transforms = []Aff3{Concat(transforms...)}
+	}
+}
+
+// PushTransform saves the current transformation and then concatenates
+// transforms and the current transformation, so that transforms apply first.
+// This is how the transform attribute of a nested SVG group applies. The
+// gradient methods and the shape methods use the current transformation as
+// well.
+func (e *Generator) PushTransform(transforms ...Aff3) {
+	m, _ := e.current()
+	e.transforms = append(e.transforms, Concat(Concat(transforms...), m))
+}
+
+// PopTransform restores the current transformation saved by the matching
+// call to PushTransform. Without one, the current transformation becomes the
+// identity.
+func (e *Generator) PopTransform() {
+	if n := len(e.transforms); n > 0 {
+		e.transforms = e.transforms[:n-1]
+	}
+}
+
+// current returns the current transformation, and false when it is the
+// identity because no transformation was set.
+func (e *Generator) current() (Aff3, bool) {
+	if n := len(e.transforms); n > 0 {
+		return e.transforms[n-1], true
+	}
+	return Scale(), false
+}
+
+// untransform returns the gradient transformation m, which maps from the
+// coordinates of path data, as one that maps from graphic coordinate space,
+// by applying the inverse of the current transformation first. A current
+// transformation that is not invertible leaves m unchanged, as it draws
+// nothing.
+func (e *Generator) untransform(m Aff3) Aff3 {
+	if t, ok := e.current(); ok {
+		if inv, ok := invert(t); ok {
+			return Concat(inv, m)
+		}
+	}
+	return m
+}
+
+// invert returns the inverse of m, and false when m is not invertible.
+func invert(m Aff3) (Aff3, bool) {
+	det := m[0]*m[4] - m[1]*m[3]
+	if det == 0 {
+		return Aff3{}, false
+	}
+	inv := 1 / det
+	return Aff3{
+		+m[4] * inv, -m[1] * inv, (m[1]*m[5] - m[2]*m[4]) * inv,
+		-m[3] * inv, +m[0] * inv, (m[2]*m[3] - m[0]*m[5]) * inv,
+	}, true
 }
 
+// SetPathData emits the path described by the SVG path data in d. The path
+// data must start with a moveto ('M' or 'm') command. A terminating 'Z' or
+// 'z' is optional and only makes a difference for stroking. Empty path data
+// emits nothing.
 func (e *Generator) SetPathData(d string, adj uint8) error {
+	started := false
+	err := parsePathData(d, func(verb byte, args *[7]float32, n int, pen, subpathStart [2]float32) error {
+		started = true
+		e.emitCmd(verb, args, n, pen, subpathStart, adj)
+		return nil
+	})
+	if err == nil && started {
+		e.ClosePathEndPath()
+	}
+	return err
+}
+
+// SetEvenOddPathData is like SetPathData, but the path is filled like SVG
+// fills it with a fill-rule of evenodd, where IconVG always uses the nonzero
+// rule. It emits the path like SetPathData does, unless subpaths that are
+// holes go in the same direction as the subpath that they are in. Then it
+// emits the path with those subpaths reversed, and with its curves as cubic
+// Bézier curves. That is only exact when no subpath crosses itself or another
+// subpath, so then it returns PathDataCrossingSubpaths and emits nothing.
+func (e *Generator) SetEvenOddPathData(d string, adj uint8) error {
+	p, err := parseStrokePath(d)
+	if err != nil {
+		return err
+	}
+	var o outline
+	reversed, ok := p.EvenOdd(&o)
+	if !ok {
+		return PathDataCrossingSubpaths
+	}
+	if !reversed {
+		return e.SetPathData(d, adj)
+	}
+	e.emitPath(o, adj)
+	return nil
+}
+
+// pathCmd is a path data command with its arguments.
+type pathCmd struct {
+	verb byte
+	args []float32
+}
+
+// emitPath emits the path of cmds, which start with a moveto, like
+// SetPathData emits the path described by path data. It emits nothing when
+// cmds is empty.
+func (e *Generator) emitPath(cmds []pathCmd, adj uint8) {
+	if len(cmds) == 0 {
+		return
+	}
+	var pen, subpathStart [2]float32
+	for i, c := range cmds {
+		var args [7]float32
+		n := copy(args[:], c.args)
+		verb := c.verb
+		if i == 0 {
+			verb = '@'
+		}
+		prevPen, prevSubpathStart := pen, subpathStart
+		pen, subpathStart = advance(&args, verb, pen, subpathStart)
+		e.emitCmd(verb, &args, n, prevPen, prevSubpathStart, adj)
+	}
+	e.ClosePathEndPath()
+}
+
+// emitCmd emits the path data command verb with n arguments args in the
+// coordinates of the path data, where pen and subpathStart are the current
+// point and the start of the current subpath before the command.
+func (e *Generator) emitCmd(verb byte, args *[7]float32, n int, pen, subpathStart [2]float32, adj uint8) {
+	if verb == 'm' {
+		// IconVG moves relative to the start of the subpath that was
+		// closed, while SVG moves relative to the current point.
+		args[0], args[1] = pen[0]+args[0]-subpathStart[0], pen[1]+args[1]-subpathStart[1]
+	}
+	if m, ok := e.current(); ok {
+		verb = transform(args, n, verb, m, pen)
+	}
+	e.emit(verb, args, adj)
+}
+
+// parsePathData parses the SVG path data in d and calls f for every command
+// with its verb, its n arguments, and the current point and the start of the
+// current subpath before the command. The verb of the first moveto is '@' and
+// an implicit command gets the verb of the command it repeats, where a
+// repeated moveto is a lineto.
+func parsePathData(d string, f func(verb byte, args *[7]float32, n int, pen, subpathStart [2]float32) error) error {
 	var args [7]float32
 	prevN, prevVerb := 0, byte(0)
-	for start := true; d != "z"; start = false {
-		// The verb at the start of the path data in d must be either 'M' or 'm'
-		// A terminating 'Z' or 'z' is optional and only makes a difference for stroking.
+	// pen and subpathStart are the current point and the start of the
+	// current subpath, in the coordinates of the path data.
+	var pen, subpathStart [2]float32
+	for start := true; ; start = false {
+		if d = trimSeparators(d); d == "" {
+			return nil
+		}
 		n, verb, implicit := 0, d[0], false
 		switch verb {
 		case 'H', 'h', 'V', 'v':
@@ -238,11 +409,14 @@ func (e *Generator) SetPathData(d string, adj uint8) error {
 		case 'Z', 'z':
 			n = 0
 		default:
-			if prevVerb == '\x00' {
+			if prevN == 0 || scanNumber(d) == 0 {
 				return UnrecognizedPathDataVerb(verb)
 			}
 			n, verb, implicit = prevN, prevVerb, true
 		}
+		if start && verb != 'M' && verb != 'm' {
+			return PathDataMissingMoveTo
+		}
 		prevN, prevVerb = n, verb
 		if prevVerb == 'M' {
 			prevVerb = 'L'
@@ -256,143 +430,291 @@ func (e *Generator) SetPathData(d string, adj uint8) error {
 			d = d[1:]
 		}
 
-		if dnext, err := scan(&args, d, n); err != nil {
+		if dnext, err := scan(&args, d, n, n == 7); err != nil {
 			return err
 		} else {
 			d = dnext
 		}
-		normalize(&args, n, verb, e.transforms...)
-
-		switch verb {
-		case 'H':
-			e.AbsHLineTo(args[0])
-		case 'h':
-			e.RelHLineTo(args[0])
-		case 'V':
-			e.AbsVLineTo(args[0])
-		case 'v':
-			e.RelVLineTo(args[0])
-		case 'L':
-			e.AbsLineTo(args[0], args[1])
-		case 'l':
-			e.RelLineTo(args[0], args[1])
-		case '@':
-			e.StartPath(adj, args[0], args[1])
-		case 'M':
-			e.ClosePathAbsMoveTo(args[0], args[1])
-		case 'm':
-			e.ClosePathRelMoveTo(args[0], args[1])
-		case 'T':
-			e.AbsSmoothQuadTo(args[0], args[1])
-		case 't':
-			e.RelSmoothQuadTo(args[0], args[1])
-		case 'Q':
-			e.AbsQuadTo(args[0], args[1], args[2], args[3])
-		case 'q':
-			e.RelQuadTo(args[0], args[1], args[2], args[3])
-		case 'S':
-			e.AbsSmoothCubeTo(args[0], args[1], args[2], args[3])
-		case 's':
-			e.RelSmoothCubeTo(args[0], args[1], args[2], args[3])
-		case 'C':
-			e.AbsCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
-		case 'c':
-			e.RelCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
-		case 'A':
-			e.AbsArcTo(args[0], args[1], args[2]/360, args[3] != 0, args[4] != 0, args[5], args[6])
-		case 'a':
-			e.RelArcTo(args[0], args[1], args[2]/360, args[3] != 0, args[4] != 0, args[5], args[6])
-		case 'Z', 'z':
-			// No-op.
-		default:
-			return UnrecognizedPathDataVerb(verb)
+		prevPen, prevSubpathStart := pen, subpathStart
+		pen, subpathStart = advance(&args, verb, pen, subpathStart)
+		if err := f(verb, &args, n, prevPen, prevSubpathStart); err != nil {
+			return err
 		}
 	}
-	e.ClosePathEndPath()
-	return nil
 }
 
-func scan(args *[7]float32, d string, n int) (string, error) {
+// emit emits the IconVG path command for the path data command verb with
+// arguments args, which are already transformed.
+func (e *Generator) emit(verb byte, args *[7]float32, adj uint8) {
+	switch verb {
+	case 'H':
+		e.AbsHLineTo(args[0])
+	case 'h':
+		e.RelHLineTo(args[0])
+	case 'V':
+		e.AbsVLineTo(args[0])
+	case 'v':
+		e.RelVLineTo(args[0])
+	case 'L':
+		e.AbsLineTo(args[0], args[1])
+	case 'l':
+		e.RelLineTo(args[0], args[1])
+	case '@':
+		e.StartPath(adj, args[0], args[1])
+	case 'M':
+		e.ClosePathAbsMoveTo(args[0], args[1])
+	case 'm':
+		e.ClosePathRelMoveTo(args[0], args[1])
+	case 'T':
+		e.AbsSmoothQuadTo(args[0], args[1])
+	case 't':
+		e.RelSmoothQuadTo(args[0], args[1])
+	case 'Q':
+		e.AbsQuadTo(args[0], args[1], args[2], args[3])
+	case 'q':
+		e.RelQuadTo(args[0], args[1], args[2], args[3])
+	case 'S':
+		e.AbsSmoothCubeTo(args[0], args[1], args[2], args[3])
+	case 's':
+		e.RelSmoothCubeTo(args[0], args[1], args[2], args[3])
+	case 'C':
+		e.AbsCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
+	case 'c':
+		e.RelCubeTo(args[0], args[1], args[2], args[3], args[4], args[5])
+	case 'A':
+		e.AbsArcTo(args[0], args[1], args[2]/360, args[3] != 0, args[4] != 0, args[5], args[6])
+	case 'a':
+		e.RelArcTo(args[0], args[1], args[2]/360, args[3] != 0, args[4] != 0, args[5], args[6])
+	case 'Z', 'z':
+		// No-op.
+	}
+}
+
+// scan parses n numbers from the path data in d into args. When arc is true,
+// the 4th and 5th numbers are arc flags, which are a single '0' or '1' and
+// need not be separated from the number that follows them.
+func scan(args *[7]float32, d string, n int, arc bool) (string, error) {
 	for i := 0; i < n; i++ {
-		nDots := 0
-		if d[0] == '.' {
-			nDots = 1
-		}
-		j := 1 // skip over a '+' or '-' or any other character for that matter
-		for ; ; j++ {
-			switch d[j] {
-			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-				continue
-			case '.':
-				nDots++
-				if nDots == 1 {
-					continue
-				}
+		d = trimSeparators(d)
+		if arc && (i == 3 || i == 4) {
+			if d == "" || (d[0] != '0' && d[0] != '1') {
+				return d, PathDataInvalidArcFlag
 			}
-			break
+			args[i] = float32(d[0] - '0')
+			d = d[1:]
+			continue
+		}
+		j := scanNumber(d)
+		if j == 0 {
+			return d, PathDataMissingNumber
 		}
 		f, err := strconv.ParseFloat(d[:j], 64)
 		if err != nil {
 			return d, err
 		}
 		args[i] = float32(f)
-		for ; d[j] == ' ' || d[j] == ','; j++ {
-		}
 		d = d[j:]
 	}
 	return d, nil
 }
 
-func normalize(args *[7]float32, n int, verb byte, transforms ...Aff3) {
-	if len(transforms) > 0 {
-		// The original SVG is 32x32 units, with the top left being (0, 0).
-		// Normalize to 64x64 units, with the center being (0, 0).
-		if true {
-			transform := Concat(transforms...)
-			scale := Aff3{transform[0], 0, 0, 0, transform[4], 0}
-			if 'a' <= verb && verb <= 'z' {
-				transform = scale
-			}
-			switch n {
-			case 7:
-				args[0], args[1] = MulAff3(args[0], args[1], scale)
-				args[5], args[6] = MulAff3(args[5], args[6], transform)
-			case 6:
-				args[4], args[5] = MulAff3(args[4], args[5], transform)
-				fallthrough
-			case 4:
-				args[2], args[3] = MulAff3(args[2], args[3], transform)
-				fallthrough
-			case 2:
-				args[0], args[1] = MulAff3(args[0], args[1], transform)
-			case 1:
-				if verb == 'H' || verb == 'h' {
-					args[0], _ = MulAff3(args[0], 0, transform)
-				} else if verb == 'V' || verb == 'v' {
-					_, args[0] = MulAff3(0, args[0], transform)
-				}
-			}
-		} else {
-			if verb == 'A' {
-				args[0] = 2 * args[0]
-				args[1] = 2 * args[1]
-				args[5] = 2*args[5] - 32
-				args[6] = 2*args[6] - 32
-			} else if verb == 'a' {
-				args[0] = 2 * args[0]
-				args[1] = 2 * args[1]
-				args[5] = 2 * args[5]
-				args[6] = 2 * args[6]
-			} else if '@' <= verb && verb <= 'Z' {
-				for i := range args {
-					args[i] = 2*args[i] - 32
-				}
-			} else {
-				for i := range args {
-					args[i] = 2 * args[i]
-				}
+// scanNumber returns the length of the number at the start of d, or 0 if d
+// does not start with a number. A number has an optional sign, a mantissa
+// with at most one '.' and an optional exponent.
+func scanNumber(d string) int {
+	j := 0
+	if j < len(d) && (d[j] == '+' || d[j] == '-') {
+		j++
+	}
+	nDigits := 0
+	for ; j < len(d) && '0' <= d[j] && d[j] <= '9'; j++ {
+		nDigits++
+	}
+	if j < len(d) && d[j] == '.' {
+		j++
+		for ; j < len(d) && '0' <= d[j] && d[j] <= '9'; j++ {
+			nDigits++
+		}
+	}
+	if nDigits == 0 {
+		return 0
+	}
+	if j < len(d) && (d[j] == 'e' || d[j] == 'E') {
+		k := j + 1
+		if k < len(d) && (d[k] == '+' || d[k] == '-') {
+			k++
+		}
+		if k < len(d) && '0' <= d[k] && d[k] <= '9' {
+			for j = k; j < len(d) && '0' <= d[j] && d[j] <= '9'; j++ {
 			}
 		}
-		// fmt.Println(args[:n])
 	}
+	return j
+}
+
+// trimSeparators removes leading white space and commas from d.
+func trimSeparators(d string) string {
+	for d != "" {
+		switch d[0] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			d = d[1:]
+			continue
+		}
+		break
+	}
+	return d
+}
+
+// advance returns the current point and the start of the current subpath
+// after the path data command verb with arguments args.
+func advance(args *[7]float32, verb byte, pen, subpathStart [2]float32) (newPen, newSubpathStart [2]float32) {
+	switch verb {
+	case '@', 'M':
+		pen = [2]float32{args[0], args[1]}
+		return pen, pen
+	case 'm':
+		pen = [2]float32{pen[0] + args[0], pen[1] + args[1]}
+		return pen, pen
+	case 'Z', 'z':
+		return subpathStart, subpathStart
+	case 'H':
+		pen[0] = args[0]
+	case 'h':
+		pen[0] += args[0]
+	case 'V':
+		pen[1] = args[0]
+	case 'v':
+		pen[1] += args[0]
+	case 'L', 'T':
+		pen = [2]float32{args[0], args[1]}
+	case 'l', 't':
+		pen = [2]float32{pen[0] + args[0], pen[1] + args[1]}
+	case 'Q', 'S':
+		pen = [2]float32{args[2], args[3]}
+	case 'q', 's':
+		pen = [2]float32{pen[0] + args[2], pen[1] + args[3]}
+	case 'C':
+		pen = [2]float32{args[4], args[5]}
+	case 'c':
+		pen = [2]float32{pen[0] + args[4], pen[1] + args[5]}
+	case 'A':
+		pen = [2]float32{args[5], args[6]}
+	case 'a':
+		pen = [2]float32{pen[0] + args[5], pen[1] + args[6]}
+	}
+	return pen, subpathStart
+}
+
+// transform applies m to the n arguments of the path data command verb,
+// where pen is the current point before the command. Absolute coordinates
+// are transformed as points and relative ones as vectors. It returns the verb
+// to emit, which is a line instead of a horizontal or vertical line when m
+// does not keep that line horizontal or vertical.
+func transform(args *[7]float32, n int, verb byte, m Aff3, pen [2]float32) byte {
+	if 'a' <= verb && verb <= 'z' {
+		// A relative coordinate is a vector, which is not translated.
+		m[2], m[5] = 0, 0
+	}
+	switch verb {
+	case 'H':
+		if m[3] != 0 {
+			args[0], args[1] = MulAff3(args[0], pen[1], m)
+			return 'L'
+		}
+		args[0], _ = MulAff3(args[0], pen[1], m)
+		return verb
+	case 'h':
+		if m[3] != 0 {
+			args[0], args[1] = MulAff3(args[0], 0, m)
+			return 'l'
+		}
+		args[0], _ = MulAff3(args[0], 0, m)
+		return verb
+	case 'V':
+		if m[1] != 0 {
+			args[0], args[1] = MulAff3(pen[0], args[0], m)
+			return 'L'
+		}
+		_, args[0] = MulAff3(pen[0], args[0], m)
+		return verb
+	case 'v':
+		if m[1] != 0 {
+			args[0], args[1] = MulAff3(0, args[0], m)
+			return 'l'
+		}
+		_, args[0] = MulAff3(0, args[0], m)
+		return verb
+	}
+	switch n {
+	case 7:
+		args[0], args[1], args[2] = transformArc(m, args[0], args[1], args[2])
+		if m[0]*m[4]-m[1]*m[3] < 0 {
+			// A mirrored arc sweeps the other way.
+			args[4] = 1 - args[4]
+		}
+		args[5], args[6] = MulAff3(args[5], args[6], m)
+	case 6:
+		args[4], args[5] = MulAff3(args[4], args[5], m)
+		fallthrough
+	case 4:
+		args[2], args[3] = MulAff3(args[2], args[3], m)
+		fallthrough
+	case 2:
+		args[0], args[1] = MulAff3(args[0], args[1], m)
+	}
+	return verb
+}
+
+// transformArc returns the radii and x-axis rotation, in degrees, of the
+// ellipse with radii rx and ry and x-axis rotation xAxisRotation after
+// applying the linear part of m.
+func transformArc(m Aff3, rx, ry, xAxisRotation float32) (float32, float32, float32) {
+	if m[1] == 0 && m[3] == 0 && m[0] > 0 && m[4] > 0 &&
+		(m[0] == m[4] || math.Mod(float64(xAxisRotation), 180) == 0) {
+		// Scaling keeps the axes of the ellipse.
+		return rx * m[0], ry * m[4], xAxisRotation
+	}
+
+	// The ellipse is the image of the unit circle under A = m·R·D, where R
+	// rotates by the x-axis rotation and D scales by the radii. The columns
+	// of A are the images u and v of the ellipse's semi-axes.
+	phi := float64(xAxisRotation) * math.Pi / 180
+	sin, cos := math.Sincos(phi)
+	a, b, c, d := float64(m[0]), float64(m[1]), float64(m[3]), float64(m[4])
+	ux, uy := cos*float64(rx), sin*float64(rx)
+	vx, vy := -sin*float64(ry), cos*float64(ry)
+	ux, uy = a*ux+b*uy, c*ux+d*uy
+	vx, vy = a*vx+b*vy, c*vx+d*vy
+
+	uu, vv, uv := ux*ux+uy*uy, vx*vx+vy*vy, ux*vx+uy*vy
+	if uv*uv <= 1e-12*uu*vv {
+		// The images of the semi-axes are still perpendicular, which
+		// includes the degenerate case of a zero radius.
+		return float32(math.Sqrt(uu)), float32(math.Sqrt(vv)), degrees(math.Atan2(uy, ux))
+	}
+
+	// Otherwise, the semi-axes are given by the singular value decomposition
+	// of A, which is a rotation by theta, a scale by (sx, sy) and another
+	// rotation. See Jim Blinn, "Consider the Lowly 2×2 Matrix".
+	e, f := (ux+vy)/2, (ux-vy)/2
+	g, h := (uy+vx)/2, (uy-vx)/2
+	q, r := math.Hypot(e, h), math.Hypot(f, g)
+	sx, sy := q+r, math.Abs(q-r)
+	if a*d-b*c == 0 {
+		// A transformation that is not invertible flattens the ellipse.
+		sy = 0
+	}
+	theta := (math.Atan2(g, f) + math.Atan2(h, e)) / 2
+	return float32(sx), float32(sy), degrees(theta)
+}
+
+// degrees converts the x-axis rotation theta from radians to degrees in the
+// range [0, 180), as rotating an ellipse by 180 degrees gives the same
+// ellipse.
+func degrees(theta float64) float32 {
+	d := math.Mod(theta*180/math.Pi, 180)
+	if d < 0 {
+		d += 180
+	}
+	return float32(d)
 }
@@ -2,13 +2,20 @@ package generate
 
 import (
 	"bytes"
+	"image"
 	"image/color"
+	"image/draw"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
 	"github.com/reactivego/ivg/encode"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
 )
 
 // overwriteTestdataFiles is temporarily set to true when adding new
@@ -375,3 +382,537 @@ func TestEncodeFavicon(t *testing.T) {
 
 	testEncode(t, &e, "../testdata/favicon.ivg")
 }
+
+func TestSetPathData(t *testing.T) {
+	pathData := func(d string) ([]byte, error) {
+		var e encode.Encoder
+		gen := Generator{}
+		gen.SetDestination(&e)
+		if err := gen.SetPathData(d, 0); err != nil {
+			return nil, err
+		}
+		return e.Bytes()
+	}
+
+	equivalent := [][]string{{
+		"M1 2L3 -4z",
+		"M1 2L3 -4",
+		" M 1,2 L 3,-4 Z ",
+		"M1,2,3,-4",
+		"M1e0 .2e1L3-4e-0",
+		"M+1+2 3-4",
+	}, {
+		"M0 0a4 4 0 1010 10z",
+		"M0 0a4 4 0 1 0 10 10z",
+		"M0,0 a4,4,0,1,0,10,10",
+	}, {
+		"M0 0H10V10h-10zM-5-5h2v2h-2z",
+		"M 0 0 H 10 V 10 h -10 z M -5 -5 h 2 v 2 h -2",
+	}, {
+		"M0 0l10 0zm11 1l2 2",
+		"M0 0l10 0m1 1l2 2",
+	}}
+	for _, tc := range equivalent {
+		want, err := pathData(tc[0])
+		if err != nil {
+			t.Errorf("%q: %v", tc[0], err)
+			continue
+		}
+		for _, d := range tc[1:] {
+			if got, err := pathData(d); err != nil {
+				t.Errorf("%q: %v", d, err)
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("%q:\ngot  % x\nwant % x", d, got, want)
+			}
+		}
+	}
+
+	invalid := []struct {
+		d   string
+		err error
+	}{
+		{"L1 2", PathDataMissingMoveTo},
+		{"M1", PathDataMissingNumber},
+		{"M1 2L3", PathDataMissingNumber},
+		{"M1 2z3 4", UnrecognizedPathDataVerb('3')},
+		{"M1 2x", UnrecognizedPathDataVerb('x')},
+		{"M0 0a4 4 0 2 0 10 10", PathDataInvalidArcFlag},
+	}
+	for _, tc := range invalid {
+		if _, err := pathData(tc.d); err != tc.err {
+			t.Errorf("%q: got %v, want %v", tc.d, err, tc.err)
+		}
+	}
+}
+
+func TestSetPathDataTransform(t *testing.T) {
+	pathData := func(d string, transforms ...Aff3) ([]byte, error) {
+		var e encode.Encoder
+		gen := Generator{}
+		gen.SetDestination(&e)
+		if len(transforms) > 0 {
+			gen.SetTransform(transforms...)
+		}
+		if err := gen.SetPathData(d, 0); err != nil {
+			return nil, err
+		}
+		return e.Bytes()
+	}
+
+	rotate90 := Aff3{0, -1, 0, 1, 0, 0}
+	testCases := []struct {
+		d         string
+		transform Aff3
+		want      string
+	}{
+		{"M1 2h3v4H0V0l1 1z", rotate90, "M-2 1l0 3l-4 0L-6 0L0 0l-1 1z"},
+		{"M1 2h3v4H0V0l1 1z", Scale(2, 3), "M2 6h6v12H0V0l2 3z"},
+		{"M1 2h3v4H0V0l1 1z", Aff3{1, 1, 0, 0, 1, 0}, "M3 2h3l4 4H6L0 0l2 1z"},
+		{"M0 0a4 2 0 0 1 10 0", rotate90, "M0 0a4 2 90 0 1 0 10"},
+		{"M0 0a4 2 30 0 1 10 0", Scale(-1, 1), "M0 0a4 2 150 0 0 -10 0"},
+		{"M0 0a4 2 30 0 1 10 0", Scale(2, 2), "M0 0a8 4 30 0 1 20 0"},
+		{"M0 0A4 2 0 0 1 10 0", Scale(2, 1), "M0 0A8 2 0 0 1 20 0"},
+	}
+	for _, tc := range testCases {
+		got, err := pathData(tc.d, tc.transform)
+		if err != nil {
+			t.Errorf("%q: %v", tc.d, err)
+			continue
+		}
+		want, err := pathData(tc.want)
+		if err != nil {
+			t.Errorf("%q: %v", tc.want, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%q, %v:\ngot  % x\nwant % x", tc.d, tc.transform, got, want)
+		}
+	}
+}
+
+// TestTransformArc tests that the points of a transformed ellipse are on the
+// ellipse that transformArc returns.
+func TestTransformArc(t *testing.T) {
+	testCases := []struct {
+		m                     Aff3
+		rx, ry, xAxisRotation float32
+	}{
+		{Aff3{0, -1, 0, 1, 0, 0}, 4, 2, 30},
+		{Aff3{2, 0, 0, 0, 1, 0}, 4, 2, 30},
+		{Aff3{1, 0.5, 0, 0, 1, 0}, 4, 2, 0},
+		{Aff3{1, 0.5, 0, 0.25, -1, 0}, 3, 3, 0},
+		{Aff3{0.8, -0.6, 0, 0.6, 0.8, 0}, 5, 1, 100},
+		{Aff3{1, 2, 0, 2, 4, 0}, 4, 2, 30},
+		{Aff3{1, 0, 0, 0, 1, 0}, 0, 2, 30},
+	}
+	for _, tc := range testCases {
+		rx, ry, xAxisRotation := transformArc(tc.m, tc.rx, tc.ry, tc.xAxisRotation)
+		sin0, cos0 := math.Sincos(float64(tc.xAxisRotation) * math.Pi / 180)
+		sin1, cos1 := math.Sincos(float64(xAxisRotation) * math.Pi / 180)
+		for i := 0; i < 16; i++ {
+			s, c := math.Sincos(float64(i) * math.Pi / 8)
+			// A point of the ellipse, transformed.
+			x := cos0*float64(tc.rx)*c - sin0*float64(tc.ry)*s
+			y := sin0*float64(tc.rx)*c + cos0*float64(tc.ry)*s
+			x, y = float64(tc.m[0])*x+float64(tc.m[1])*y, float64(tc.m[3])*x+float64(tc.m[4])*y
+			// The point in the frame of the transformed ellipse.
+			u, v := cos1*x+sin1*y, -sin1*x+cos1*y
+			if math.Min(float64(rx), float64(ry)) <= 1e-6*math.Max(float64(rx), float64(ry)) {
+				// The ellipse is a line segment.
+				if w := math.Max(float64(rx), float64(ry)); math.Min(math.Abs(u), math.Abs(v)) > 1e-4 || math.Max(math.Abs(u), math.Abs(v)) > w+1e-4 {
+					t.Errorf("%v: got rx=%g, ry=%g, rotation=%g, which does not contain (%g, %g)", tc, rx, ry, xAxisRotation, x, y)
+					break
+				}
+				continue
+			}
+			if r := u*u/float64(rx*rx) + v*v/float64(ry*ry); math.Abs(r-1) > 1e-4 {
+				t.Errorf("%v: got rx=%g, ry=%g, rotation=%g, which does not contain (%g, %g)", tc, rx, ry, xAxisRotation, x, y)
+				break
+			}
+		}
+	}
+}
+
+func TestStrokePathData(t *testing.T) {
+	testCases := []struct {
+		d          string
+		s          Stroke
+		transforms []Aff3
+		want       string
+	}{
+		{"M2 4H10", Stroke{Width: 4}, nil, "M2 6L10 6L10 2L2 2z"},
+		{"M10 4L2 4", Stroke{Width: 4}, nil, "M10 2L2 2L2 6L10 6z"},
+		{"M2 4H10", Stroke{Width: 4, Cap: CapRound}, nil, "M2 6L10 6A2 2 0 0 0 10 2L2 2A2 2 0 0 0 2 6z"},
+		{"M2 4H10", Stroke{Width: 4, Cap: CapSquare}, nil, "M2 6L10 6L12 6L12 2L10 2L2 2L0 2L0 6z"},
+		{"M2 4H10", Stroke{Width: 4}, []Aff3{Scale(2)}, "M4 12L20 12L20 4L4 4z"},
+		{"M0 0H10V10", Stroke{Width: 2}, nil, "M0 1L10 1L10 0L9 0L9 10L11 10L11 0L11 -1L10 -1L0 -1z"},
+		{"M0 0H10V10", Stroke{Width: 2, Join: JoinBevel}, nil, "M0 1L10 1L10 0L9 0L9 10L11 10L11 0L10 -1L0 -1z"},
+		{"M0 0H10V10", Stroke{Width: 2, MiterLimit: 1.4}, nil, "M0 1L10 1L10 0L9 0L9 10L11 10L11 0L10 -1L0 -1z"},
+		{"M0 0H10V10", Stroke{Width: 2, Join: JoinRound}, nil, "M0 1L10 1L10 0L9 0L9 10L11 10L11 0A1 1 0 0 0 10 -1L0 -1z"},
+		{"M1 2z", Stroke{Width: 2, Cap: CapSquare}, nil, "M2 3L0 3L0 1L2 1z"},
+		{"M1 2z", Stroke{Width: 2}, nil, ""},
+		{"M1 2", Stroke{Width: 2, Cap: CapRound}, nil, ""},
+		{"M2 4H10", Stroke{}, nil, ""},
+	}
+	for _, tc := range testCases {
+		var e encode.Encoder
+		gen := Generator{}
+		gen.SetDestination(&e)
+		if len(tc.transforms) > 0 {
+			gen.SetTransform(tc.transforms...)
+		}
+		if err := gen.StrokePathData(tc.d, 0, tc.s); err != nil {
+			t.Errorf("%q, %v: %v", tc.d, tc.s, err)
+			continue
+		}
+		got, err := e.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f encode.Encoder
+		gen.SetDestination(&f)
+		gen.SetTransform()
+		if err := gen.SetPathData(tc.want, 0); err != nil {
+			t.Fatalf("%q: %v", tc.want, err)
+		}
+		want, err := f.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%q, %v:\ngot  % x\nwant % x", tc.d, tc.s, got, want)
+		}
+	}
+}
+
+// rasterize renders the graphic that f generates at 64x64 pixels.
+func rasterize(f func(gen *Generator) error) (*image.RGBA, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	return dst, rasterizeTo(&img.Rasterizer{Dst: dst, DrawOp: draw.Src}, f)
+}
+
+// rasterizeTo renders the graphic that f generates with the 64x64 pixel
+// Rasterizer r.
+func rasterizeTo(r raster.Rasterizer, f func(gen *Generator) error) error {
+	var e encode.Encoder
+	gen := Generator{}
+	gen.SetDestination(&e)
+	if err := f(&gen); err != nil {
+		return err
+	}
+	data, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+	var z render.Renderer
+	z.SetRasterizer(r, image.Rect(0, 0, 64, 64))
+	return decode.Decode(&z, data)
+}
+
+// sampler is a Rasterizer that samples the points of the segments that it
+// rasterizes, in pixel coordinates.
+type sampler struct {
+	img.Rasterizer
+	points [][2]float64
+}
+
+// sample samples 257 points of the Bézier curve from the pen via the control
+// points to the end point, which are given as x and y coordinates.
+func (z *sampler) sample(xy ...float32) {
+	px, py := z.Pen()
+	xy = append([]float32{px, py}, xy...)
+	for i := 0; i <= 256; i++ {
+		t := float32(i) / 256
+		// De Casteljau's algorithm.
+		q := append([]float32(nil), xy...)
+		for n := len(q) - 2; n > 0; n -= 2 {
+			for j := 0; j < n; j++ {
+				q[j] += t * (q[j+2] - q[j])
+			}
+		}
+		z.points = append(z.points, [2]float64{float64(q[0]), float64(q[1])})
+	}
+}
+
+func (z *sampler) LineTo(bx, by float32) {
+	z.sample(bx, by)
+	z.Rasterizer.LineTo(bx, by)
+}
+
+func (z *sampler) QuadTo(bx, by, cx, cy float32) {
+	z.sample(bx, by, cx, cy)
+	z.Rasterizer.QuadTo(bx, by, cx, cy)
+}
+
+func (z *sampler) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	z.sample(bx, by, cx, cy, dx, dy)
+	z.Rasterizer.CubeTo(bx, by, cx, cy, dx, dy)
+}
+
+// TestStrokePathDataRender tests that strokes render like the fill of their
+// outline, where that has a simple form, within the tolerance of the offset
+// curves.
+func TestStrokePathDataRender(t *testing.T) {
+	const (
+		circle  = "M-20 0A20 20 0 1 1 20 0A20 20 0 1 1 -20 0"
+		annulus = "M-24 0C-24-13.254834-13.254834-24 0-24S24-13.254834 24 0 13.254834 24 0 24-24 13.254834-24 0z" +
+			"M-16 0C-16 8.836556-8.836556 16 0 16S16 8.836556 16 0 8.836556-16 0-16-16-8.836556-16 0z"
+	)
+	testCases := []struct {
+		d    string
+		s    Stroke
+		want string
+	}{
+		{circle + "z", Stroke{Width: 8}, annulus},
+		{"M-20 0A20 20 0 0 1 20 0", Stroke{Width: 8, Cap: CapRound}, "M-16 0C-16-8.836556-8.836556-16 0-16S16-8.836556 16 0" +
+			"A4 4 0 0 0 24 0C24-13.254834 13.254834-24 0-24S-24-13.254834-24 0A4 4 0 0 0 -16 0z"},
+		{"M-20-20H10V20", Stroke{Width: 8, Join: JoinRound}, "M-20-24H10A4 4 0 0 1 14-20V20H6V-16H-20z"},
+		{"M-20-20H20V20z", Stroke{Width: 4}, "M-24.828427-22H22V24.828427zM-15.171573-18L18 15.171573V-18z"},
+	}
+	for _, tc := range testCases {
+		got, err := rasterize(func(gen *Generator) error {
+			return gen.StrokePathData(tc.d, 0, tc.s)
+		})
+		if err != nil {
+			t.Errorf("%q, %v: %v", tc.d, tc.s, err)
+			continue
+		}
+		want, err := rasterize(func(gen *Generator) error {
+			return gen.SetPathData(tc.want, 0)
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", tc.want, err)
+		}
+		for i := range got.Pix {
+			if d := int(got.Pix[i]) - int(want.Pix[i]); d < -0x10 || 0x10 < d {
+				x, y := i%got.Stride/4, i/got.Stride
+				t.Errorf("%q, %v: at (%d, %d): got %#02x, want %#02x", tc.d, tc.s, x, y, got.Pix[i], want.Pix[i])
+				break
+			}
+		}
+	}
+
+	// A stroke with round caps and joins covers the points that are within
+	// half its width of the path.
+	for _, d := range []string{
+		"M-20 10C-20-30 20 30 20-10",
+		"M-20 0Q-20-20 0-20T20 0 0 20-20 0z",
+		"M-20-20C20-20-20 20 20 20",
+		"M-20 20L-10-20 0 20 10-20 20 20",
+		"M-10 0A10 20 30 1 1 10 0S20 20 0 20",
+	} {
+		s := Stroke{Width: 6, Cap: CapRound, Join: JoinRound}
+		got, err := rasterize(func(gen *Generator) error {
+			return gen.StrokePathData(d, 0, s)
+		})
+		if err != nil {
+			t.Errorf("%q: %v", d, err)
+			continue
+		}
+		z := &sampler{Rasterizer: img.Rasterizer{Dst: image.NewRGBA(image.Rect(0, 0, 64, 64))}}
+		if err := rasterizeTo(z, func(gen *Generator) error {
+			return gen.SetPathData(d, 0)
+		}); err != nil {
+			t.Fatalf("%q: %v", d, err)
+		}
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				px, py, dist := float64(x)+0.5, float64(y)+0.5, math.Inf(1)
+				for _, q := range z.points {
+					dist = math.Min(dist, math.Hypot(px-q[0], py-q[1]))
+				}
+				a := got.RGBAAt(x, y).A
+				if (dist < 2 && a != 0xff) || (dist > 4 && a != 0) {
+					t.Errorf("%q: at (%d, %d), %g from the path: got alpha %#02x", d, x, y, dist, a)
+				}
+			}
+		}
+	}
+}
+
+func TestShapes(t *testing.T) {
+	testCases := []struct {
+		shape func(gen *Generator)
+		want  string
+	}{
+		{func(gen *Generator) { gen.Rect(1, 2, 3, 4, 0) }, "M1 2h3v4h-3z"},
+		{func(gen *Generator) { gen.Rect(1, 2, 0, 4, 0) }, ""},
+		{func(gen *Generator) { gen.RoundedRect(1, 2, 3, 4, [4]float32{}, 0) }, "M1 2h3v4h-3z"},
+		{func(gen *Generator) { gen.RoundedRect(0, 0, 10, 8, [4]float32{1, 2, 3, 0}, 0) },
+			"M1 0h7a2 2 0 0 1 2 2v3a3 3 0 0 1-3 3h-7v-7a1 1 0 0 1 1-1z"},
+		{func(gen *Generator) { gen.RoundedRect(0, 0, 10, 4, [4]float32{4, 4, 4, 4}, 0) },
+			"M2 0h6a2 2 0 0 1 2 2a2 2 0 0 1-2 2h-6a2 2 0 0 1-2-2a2 2 0 0 1 2-2z"},
+		{func(gen *Generator) { gen.Circle(16, 16, 10, 0) }, "M6 16a10 10 0 1 1 20 0a10 10 0 1 1-20 0z"},
+		{func(gen *Generator) { gen.Ellipse(0, 0, 4, 2, 0) }, "M-4 0a4 2 0 1 1 8 0a4 2 0 1 1-8 0z"},
+		{func(gen *Generator) { gen.Ellipse(0, 0, 4, 0, 0) }, ""},
+		{func(gen *Generator) { gen.RegularPolygon(0, 0, 10, 4, 0) }, "M0-10L10 0 0 10-10 0z"},
+		{func(gen *Generator) { gen.RegularPolygon(0, 0, 10, 2, 0) }, ""},
+		{func(gen *Generator) { gen.Star(0, 0, 10, 5, 2, 0) }, "M0-10L5 0 0 10-5 0z"},
+		{func(gen *Generator) { gen.Polyline([]float32{1, 2, 3, 4, 5, 0}, 0) }, "M1 2L3 4 5 0z"},
+		{func(gen *Generator) { gen.Polyline([]float32{1, 2}, 0) }, ""},
+	}
+	for _, transform := range []Aff3{
+		Scale(),
+		{0, -1, 0, 1, 0, 0},
+		Concat(Scale(2, 3), Translate(-5, 7)),
+		{1, 0.5, 0, 0, 1, 0},
+	} {
+		for _, tc := range testCases {
+			var e encode.Encoder
+			gen := Generator{}
+			gen.SetDestination(&e)
+			gen.SetTransform(transform)
+			tc.shape(&gen)
+			got, err := e.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f encode.Encoder
+			gen.SetDestination(&f)
+			if err := gen.SetPathData(tc.want, 0); err != nil {
+				t.Fatalf("%q: %v", tc.want, err)
+			}
+			want, err := f.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%q, %v:\ngot  % x\nwant % x", tc.want, transform, got, want)
+			}
+		}
+	}
+}
+
+func TestPolylineOddCoordinates(t *testing.T) {
+	var e encode.Encoder
+	gen := Generator{}
+	gen.SetDestination(&e)
+	if err := gen.Polyline([]float32{1, 2, 3, 4, 5}, 0); err != PolylineOddCoordinates {
+		t.Fatalf("got %v, want %v", err, PolylineOddCoordinates)
+	}
+	got, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f encode.Encoder
+	want, err := f.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+func TestPushTransform(t *testing.T) {
+	const d = "M1 2h3v4H0l1 1z"
+	var e, f encode.Encoder
+	gen, want := Generator{}, Generator{}
+	gen.SetDestination(&e)
+	want.SetDestination(&f)
+	pathData := func(transforms ...Aff3) {
+		if err := gen.SetPathData(d, 0); err != nil {
+			t.Fatal(err)
+		}
+		want.SetTransform(transforms...)
+		if err := want.SetPathData(d, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gen.PushTransform(Translate(10, 0))
+	pathData(Translate(10, 0))
+	gen.PushTransform(Scale(2), Translate(0, 1))
+	pathData(Scale(2), Translate(0, 1), Translate(10, 0))
+	gen.SetTransform(Scale(3))
+	pathData(Scale(3))
+	gen.PopTransform()
+	pathData(Translate(10, 0))
+	gen.PopTransform()
+	pathData()
+	gen.PopTransform()
+	pathData()
+
+	got, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, err := f.Bytes(); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, want) {
+		t.Errorf("\ngot  % x\nwant % x", got, want)
+	}
+}
+
+// TestPushTransformGradient tests that the gradient methods apply the current
+// transformation to their geometry.
+func TestPushTransformGradient(t *testing.T) {
+	stops := []GradientStop{
+		{Offset: 0, Color: color.RGBA{0xc0, 0x00, 0x00, 0xff}},
+		{Offset: 1, Color: color.RGBA{0x00, 0x00, 0xc0, 0xff}},
+	}
+	rotate := Concat(Aff3{0, -1, 0, 1, 0, 0}, Translate(4, -2))
+	scale := Concat(Scale(2), Translate(-8, 4))
+	skew := Aff3{1, 0.5, 3, 0.25, 1, -2}
+	testCases := []struct {
+		transform Aff3
+		got, want func(gen *Generator) error
+	}{{
+		rotate,
+		func(gen *Generator) error {
+			return gen.SetLinearGradient(-10, -5, 10, 5, GradientSpreadReflect, stops)
+		},
+		func(gen *Generator) error {
+			x1, y1 := MulAff3(-10, -5, rotate)
+			x2, y2 := MulAff3(10, 5, rotate)
+			return gen.SetLinearGradient(x1, y1, x2, y2, GradientSpreadReflect, stops)
+		},
+	}, {
+		scale,
+		func(gen *Generator) error {
+			return gen.SetCircularGradient(1, 2, 3, 4, GradientSpreadRepeat, stops)
+		},
+		func(gen *Generator) error {
+			cx, cy := MulAff3(1, 2, scale)
+			return gen.SetCircularGradient(cx, cy, 6, 8, GradientSpreadRepeat, stops)
+		},
+	}, {
+		skew,
+		func(gen *Generator) error {
+			return gen.SetEllipticalGradient(-2, 1, 0, 12, 15, 7, GradientSpreadReflect, stops)
+		},
+		func(gen *Generator) error {
+			cx, cy := MulAff3(-2, 1, skew)
+			rx, ry := MulAff3(0, 12, Aff3{skew[0], skew[1], 0, skew[3], skew[4], 0})
+			sx, sy := MulAff3(15, 7, Aff3{skew[0], skew[1], 0, skew[3], skew[4], 0})
+			return gen.SetEllipticalGradient(cx, cy, rx, ry, sx, sy, GradientSpreadReflect, stops)
+		},
+	}}
+	for _, tc := range testCases {
+		got, err := rasterize(func(gen *Generator) error {
+			gen.PushTransform(tc.transform)
+			err := tc.got(gen)
+			gen.PopTransform()
+			gen.Rect(-32, -32, 64, 64, 0)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := rasterize(func(gen *Generator) error {
+			err := tc.want(gen)
+			gen.Rect(-32, -32, 64, 64, 0)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range got.Pix {
+			if d := int(got.Pix[i]) - int(want.Pix[i]); d < -1 || +1 < d {
+				x, y := i%got.Stride/4, i/got.Stride
+				t.Errorf("%v: at (%d, %d): got %#02x, want %#02x", tc.transform, x, y, got.Pix[i], want.Pix[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package generate
+
+import "math"
+
+// Rect emits a rectangle with its top-left corner at (x, y), width w and
+// height h. A rectangle without area emits nothing.
+func (e *Generator) Rect(x, y, w, h float32, adj uint8) {
+	if !(w > 0) || !(h > 0) {
+		return
+	}
+	// Closing the path draws the left side.
+	e.emitPath([]pathCmd{
+		{'M', []float32{x, y}},
+		{'h', []float32{w}},
+		{'v', []float32{h}},
+		{'h', []float32{-w}},
+	}, adj)
+}
+
+// RoundedRect emits a rectangle with its top-left corner at (x, y), width w
+// and height h, whose corners are rounded by circular arcs. The radii of the
+// top-left, top-right, bottom-right and bottom-left corners are in r. Like in
+// CSS, all radii are scaled down by the same factor when the radii of two
+// adjacent corners add up to more than the side between them. A rectangle
+// without area emits nothing.
+func (e *Generator) RoundedRect(x, y, w, h float32, r [4]float32, adj uint8) {
+	if !(w > 0) || !(h > 0) {
+		return
+	}
+	f := float32(1)
+	for i := range r {
+		if !(r[i] > 0) {
+			r[i] = 0
+		}
+	}
+	for i, side := range [4]float32{w, h, w, h} {
+		if sum := r[i] + r[(i+1)%4]; sum > side && side/sum < f {
+			f = side / sum
+		}
+	}
+	for i := range r {
+		r[i] *= f
+	}
+
+	cmds := []pathCmd{{'M', []float32{x + r[0], y}}}
+	line := func(verb byte, l float32) {
+		if l != 0 {
+			cmds = append(cmds, pathCmd{verb, []float32{l}})
+		}
+	}
+	arc := func(r, dx, dy float32) {
+		if r != 0 {
+			cmds = append(cmds, pathCmd{'a', []float32{r, r, 0, 0, 1, dx, dy}})
+		}
+	}
+	line('h', w-r[0]-r[1])
+	arc(r[1], r[1], r[1])
+	line('v', h-r[1]-r[2])
+	arc(r[2], -r[2], r[2])
+	line('h', r[2]+r[3]-w)
+	arc(r[3], -r[3], -r[3])
+	if r[0] != 0 {
+		// Otherwise, closing the path draws the left side.
+		line('v', r[3]+r[0]-h)
+		arc(r[0], r[0], -r[0])
+	}
+	e.emitPath(cmds, adj)
+}
+
+// Circle emits a circle with center (cx, cy) and radius r. A circle without
+// area emits nothing.
+func (e *Generator) Circle(cx, cy, r float32, adj uint8) {
+	e.Ellipse(cx, cy, r, r, adj)
+}
+
+// Ellipse emits an ellipse with center (cx, cy), horizontal radius rx and
+// vertical radius ry. An ellipse without area emits nothing.
+func (e *Generator) Ellipse(cx, cy, rx, ry float32, adj uint8) {
+	if !(rx > 0) || !(ry > 0) {
+		return
+	}
+	e.emitPath([]pathCmd{
+		{'M', []float32{cx - rx, cy}},
+		{'a', []float32{rx, ry, 0, 1, 1, 2 * rx, 0}},
+		{'a', []float32{rx, ry, 0, 1, 1, -2 * rx, 0}},
+	}, adj)
+}
+
+// RegularPolygon emits a regular polygon with n sides, center (cx, cy) and
+// radius r, with a vertex straight above the center. A polygon with fewer
+// than 3 sides or without area emits nothing.
+func (e *Generator) RegularPolygon(cx, cy, r float32, n int, adj uint8) {
+	if n < 3 || !(r > 0) {
+		return
+	}
+	e.emitPath(polygon(cx, cy, n, func(int) float32 { return r }), adj)
+}
+
+// Star emits a star with n points, center (cx, cy), with its points at radius
+// outer and the corners between them at radius inner, with a point straight
+// above the center. A star with fewer than 2 points or without area emits
+// nothing.
+func (e *Generator) Star(cx, cy, outer, inner float32, n int, adj uint8) {
+	if n < 2 || !(outer > 0) || !(inner > 0) {
+		return
+	}
+	e.emitPath(polygon(cx, cy, 2*n, func(i int) float32 {
+		if i%2 == 0 {
+			return outer
+		}
+		return inner
+	}), adj)
+}
+
+// polygon returns the path of a polygon with n vertices around (cx, cy),
+// where vertex i is at radius r(i). The first vertex is straight above the
+// center, and the rest follow at equal angles.
+func polygon(cx, cy float32, n int, r func(i int) float32) []pathCmd {
+	cmds := make([]pathCmd, n)
+	for i := range cmds {
+		sin, cos := math.Sincos(2*math.Pi*float64(i)/float64(n) - math.Pi/2)
+		// Keep the vertices on the axes exact, which encode in fewer bytes.
+		if math.Abs(sin) < 1e-12 {
+			sin = 0
+		}
+		if math.Abs(cos) < 1e-12 {
+			cos = 0
+		}
+		cmds[i] = pathCmd{'L', []float32{cx + r(i)*float32(cos), cy + r(i)*float32(sin)}}
+	}
+	cmds[0].verb = 'M'
+	return cmds
+}
+
+// Polyline emits the polygon through the points (points[0], points[1]),
+// (points[2], points[3]) and so on, which is closed from the last point back
+// to the first. Fewer than 2 points emit nothing. It returns
+// PolylineOddCoordinates without emitting anything when the last point has no
+// y coordinate.
+func (e *Generator) Polyline(points []float32, adj uint8) error {
+	if len(points)%2 != 0 {
+		return PolylineOddCoordinates
+	}
+	if len(points) < 4 {
+		return nil
+	}
+	cmds := make([]pathCmd, len(points)/2)
+	for i := range cmds {
+		cmds[i] = pathCmd{'L', points[2*i : 2*i+2]}
+	}
+	cmds[0].verb = 'M'
+	e.emitPath(cmds, adj)
+	return nil
+}
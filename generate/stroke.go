@@ -0,0 +1,151 @@
+package generate
+
+import "github.com/reactivego/ivg/internal/stroke"
+
+// Cap is the shape at the ends of the open subpaths of a stroke.
+type Cap uint8
+
+const (
+	CapButt Cap = iota
+	CapRound
+	CapSquare
+)
+
+// Join is the shape at the corners of a stroke.
+type Join uint8
+
+const (
+	JoinMiter Join = iota
+	JoinRound
+	JoinBevel
+)
+
+// Stroke is the style of a stroke. Its fields have the meaning of the SVG
+// stroke-width, stroke-linecap, stroke-linejoin and stroke-miterlimit
+// properties, and its zero value but for the width is the SVG default.
+type Stroke struct {
+	Width float32
+	Cap   Cap
+	Join  Join
+	// MiterLimit is the limit on the ratio of the length of a miter join to
+	// the width of the stroke, beyond which a miter join becomes a bevel
+	// join. Zero means the SVG default of 4.
+	MiterLimit float32
+}
+
+// StrokePathData emits the outline of the stroke of the path described by
+// the SVG path data in d, as a path that is filled like one emitted by
+// SetPathData. Like in SVG, the path is stroked before the transformation
+// set by SetTransform is applied, so the transformation also applies to the
+// stroke width. Open subpaths get a cap at either end and closed subpaths get
+// a join where they start. A stroke that is not wider than zero emits
+// nothing.
+func (e *Generator) StrokePathData(d string, adj uint8, s Stroke) error {
+	p, err := parseStrokePath(d)
+	if err != nil || s.Width <= 0 {
+		return err
+	}
+	var o outline
+	p.Outline(stroke.Style{
+		Width:      float64(s.Width),
+		Cap:        stroke.Cap(s.Cap),
+		Join:       stroke.Join(s.Join),
+		MiterLimit: float64(s.MiterLimit),
+	}, &o)
+	e.emitPath(o, adj)
+	return nil
+}
+
+// parseStrokePath parses the SVG path data in d into a path to stroke, or to
+// fill with the evenodd rule.
+func parseStrokePath(d string) (*stroke.Path, error) {
+	p := new(stroke.Path)
+	// ctrl is the last control point of the previous command, when it was a
+	// quadratic ('q') or cubic ('c') Bézier curve, for smooth curves.
+	var ctrl [2]float64
+	var ctrlKind byte
+	err := parsePathData(d, func(verb byte, args *[7]float32, n int, pen, subpathStart [2]float32) error {
+		px, py := float64(pen[0]), float64(pen[1])
+		abs := func(i int) (float64, float64) {
+			x, y := float64(args[i]), float64(args[i+1])
+			if 'a' <= verb && verb <= 'z' {
+				x, y = x+px, y+py
+			}
+			return x, y
+		}
+		// reflect returns the reflection of ctrl in the pen when the previous
+		// command was of the given kind, and the pen otherwise.
+		reflect := func(kind byte) (float64, float64) {
+			if ctrlKind == kind {
+				return 2*px - ctrl[0], 2*py - ctrl[1]
+			}
+			return px, py
+		}
+		kind := byte(0)
+		switch verb {
+		case '@', 'M', 'm':
+			p.MoveTo(abs(0))
+		case 'Z', 'z':
+			p.Close()
+		case 'H':
+			p.LineTo(float64(args[0]), py)
+		case 'h':
+			p.LineTo(px+float64(args[0]), py)
+		case 'V':
+			p.LineTo(px, float64(args[0]))
+		case 'v':
+			p.LineTo(px, py+float64(args[0]))
+		case 'L', 'l':
+			p.LineTo(abs(0))
+		case 'Q', 'q', 'T', 't':
+			cx, cy := reflect('q')
+			x, y := abs(0)
+			if verb == 'Q' || verb == 'q' {
+				cx, cy = x, y
+				x, y = abs(2)
+			}
+			p.QuadTo(cx, cy, x, y)
+			ctrl, kind = [2]float64{cx, cy}, 'q'
+		case 'C', 'c', 'S', 's':
+			c1x, c1y := reflect('c')
+			c2x, c2y := abs(0)
+			x, y := abs(2)
+			if verb == 'C' || verb == 'c' {
+				c1x, c1y = c2x, c2y
+				c2x, c2y = x, y
+				x, y = abs(4)
+			}
+			p.CubeTo(c1x, c1y, c2x, c2y, x, y)
+			ctrl, kind = [2]float64{c2x, c2y}, 'c'
+		case 'A', 'a':
+			x, y := abs(5)
+			p.ArcTo(float64(args[0]), float64(args[1]), float64(args[2]), args[3] != 0, args[4] != 0, x, y)
+		}
+		ctrlKind = kind
+		return nil
+	})
+	return p, err
+}
+
+// outline collects the outline of a stroke as absolute path commands, with
+// round caps and joins as arcs, which encode more compactly than curves.
+type outline []pathCmd
+
+func (o *outline) MoveTo(x, y float64) {
+	*o = append(*o, pathCmd{'M', []float32{float32(x), float32(y)}})
+}
+
+func (o *outline) LineTo(x, y float64) {
+	*o = append(*o, pathCmd{'L', []float32{float32(x), float32(y)}})
+}
+
+func (o *outline) CubeTo(x1, y1, x2, y2, x, y float64) {
+	*o = append(*o, pathCmd{'C', []float32{float32(x1), float32(y1), float32(x2), float32(y2), float32(x), float32(y)}})
+}
+
+func (o *outline) ArcTo(r, x, y float64) {
+	*o = append(*o, pathCmd{'A', []float32{float32(r), float32(r), 0, 0, 0, float32(x), float32(y)}})
+}
+
+// Close does nothing, because emitPath closes each contour at the next one.
+func (o *outline) Close() {}
@@ -2,4 +2,7 @@ module github.com/reactivego/ivg
 
 go 1.17
 
-require golang.org/x/image v0.7.0
+require (
+	golang.org/x/image v0.7.0
+	golang.org/x/sys v0.7.0
+)
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package hit tests which path of an IconVG graphic is painted under a point,
+// for pointer input on icons.
+package hit
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/render"
+)
+
+// Test decodes the IconVG graphic src, rendered in the rectangle r like a
+// render.Renderer does, and returns the topmost path that paints the point
+// (x, y) in the coordinates of r. The layer is the index of the path in the
+// graphic, counting all its paths, and c is its alpha-premultiplied color at
+// the point. A path paints a point when the point is inside it by the nonzero
+// winding rule and its color there is not transparent, at the level of detail
+// of the height of r. When no path paints the point, the layer is -1.
+func Test(src []byte, r image.Rectangle, x, y float32, opts ...decode.DecodeOption) (layer int, c color.RGBA, err error) {
+	// The paths are in the space of r translated by -r.Min.
+	t := tester{z: rasterizer{x: x - float32(r.Min.X), y: y - float32(r.Min.Y), layer: -1}}
+	t.z.paths = &t.paths
+	t.SetRasterizer(&t.z, r)
+	if err := decode.Decode(&t, src, opts...); err != nil {
+		return -1, color.RGBA{}, err
+	}
+	return t.z.layer, t.z.color, nil
+}
+
+// tester renders a graphic onto its rasterizer and counts the paths that it
+// starts, including those that are not drawn.
+type tester struct {
+	render.Renderer
+	z     rasterizer
+	paths int
+}
+
+func (t *tester) StartPath(adj uint8, x, y float32) {
+	t.paths++
+	t.Renderer.StartPath(adj, x, y)
+}
+
+// rasterizer is a raster.Rasterizer that, instead of rasterizing its path,
+// computes the winding number of its path around the point (x, y), and
+// records the layer and color of the last path drawn that paints the point.
+type rasterizer struct {
+	// x and y are the point in the space of the paths.
+	x, y float32
+	// paths is the number of paths started, of which the last is drawn.
+	paths *int
+
+	size       image.Point
+	first, pen [2]float32
+	// winding is the winding number of the path around the point.
+	winding int
+
+	layer int
+	color color.RGBA
+}
+
+func (z *rasterizer) Reset(w, h int) {
+	z.size = image.Pt(w, h)
+	z.first, z.pen = [2]float32{}, [2]float32{}
+	z.winding = 0
+}
+
+func (z *rasterizer) Size() image.Point {
+	return z.size
+}
+
+func (z *rasterizer) Bounds() image.Rectangle {
+	return image.Rectangle{Max: z.size}
+}
+
+func (z *rasterizer) Pen() (x, y float32) {
+	return z.pen[0], z.pen[1]
+}
+
+// MoveTo closes the current path, like a vector.Rasterizer does.
+func (z *rasterizer) MoveTo(ax, ay float32) {
+	z.ClosePath()
+	z.first = [2]float32{ax, ay}
+	z.pen = z.first
+}
+
+// LineTo adds the winding of the line segment around the point, which is +1
+// or -1 when the segment crosses the ray from the point to the right going
+// down or up. A segment includes its top end, but not its bottom end.
+func (z *rasterizer) LineTo(bx, by float32) {
+	ax, ay := z.pen[0], z.pen[1]
+	z.pen = [2]float32{bx, by}
+	x, y := z.x, z.y
+	if (ay <= y) == (by <= y) {
+		return
+	}
+	// The segment crosses the ray when the point is left of it, which is
+	// where the cross product has the sign of by-ay.
+	cross := (bx-ax)*(y-ay) - (by-ay)*(x-ax)
+	if by > ay && cross > 0 {
+		z.winding++
+	} else if by < ay && cross < 0 {
+		z.winding--
+	}
+}
+
+// QuadTo adds the quadratic Bézier segment as line segments, as many as a
+// vector.Rasterizer would.
+func (z *rasterizer) QuadTo(bx, by, cx, cy float32) {
+	ax, ay := z.pen[0], z.pen[1]
+	n := segments(devSquared(ax, ay, bx, by, cx, cy))
+	for i := 1; i < n; i++ {
+		t := float32(i) / float32(n)
+		s := 1 - t
+		z.LineTo(s*s*ax+2*s*t*bx+t*t*cx, s*s*ay+2*s*t*by+t*t*cy)
+	}
+	z.LineTo(cx, cy)
+}
+
+// CubeTo adds the cubic Bézier segment as line segments, as many as a
+// vector.Rasterizer would.
+func (z *rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	ax, ay := z.pen[0], z.pen[1]
+	devsq := devSquared(ax, ay, bx, by, dx, dy)
+	if devsqAlt := devSquared(ax, ay, cx, cy, dx, dy); devsq < devsqAlt {
+		devsq = devsqAlt
+	}
+	n := segments(devsq)
+	for i := 1; i < n; i++ {
+		t := float32(i) / float32(n)
+		s := 1 - t
+		z.LineTo(
+			s*s*s*ax+3*s*s*t*bx+3*s*t*t*cx+t*t*t*dx,
+			s*s*s*ay+3*s*s*t*by+3*s*t*t*cy+t*t*t*dy,
+		)
+	}
+	z.LineTo(dx, dy)
+}
+
+func (z *rasterizer) ClosePath() {
+	z.LineTo(z.first[0], z.first[1])
+}
+
+// Draw records the layer and the color of src at the point when the point is
+// inside the path and the color is not transparent.
+func (z *rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	z.ClosePath()
+	x, y := z.x, z.y
+	if z.winding == 0 || !(0 <= x && x < float32(r.Dx()) && 0 <= y && y < float32(r.Dy())) {
+		return
+	}
+	px, py := int(math.Floor(float64(x))), int(math.Floor(float64(y)))
+	c := color.RGBAModel.Convert(src.At(sp.X+px, sp.Y+py)).(color.RGBA)
+	if c.A == 0 {
+		return
+	}
+	z.layer, z.color = *z.paths-1, c
+}
+
+// devSquared returns a measure of how curvy the sequence (ax, ay) to (bx, by)
+// to (cx, cy) is, like in the vector package.
+func devSquared(ax, ay, bx, by, cx, cy float32) float32 {
+	devx := ax - 2*bx + cx
+	devy := ay - 2*by + cy
+	return devx*devx + devy*devy
+}
+
+// segments returns the number of line segments that approximate a Bézier
+// curve of curviness devsq.
+func segments(devsq float32) int {
+	if devsq < 0.333 {
+		return 1
+	}
+	const tol = 3
+	return 1 + int(math.Sqrt(math.Sqrt(tol*float64(devsq))))
+}
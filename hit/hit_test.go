@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package hit
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/encode"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+var (
+	red   = color.RGBA{0xff, 0x00, 0x00, 0xff}
+	blue  = color.RGBA{0x00, 0x00, 0x80, 0x80}
+	green = color.RGBA{0x00, 0xff, 0x00, 0xff}
+	black = color.RGBA{0x00, 0x00, 0x00, 0xff}
+)
+
+// square adds a path that is a square from (x0, y0) to (x1, y1), clockwise.
+func square(e *encode.Encoder, x0, y0, x1, y1 float32) {
+	e.StartPath(0, x0, y0)
+	e.AbsHLineTo(x1)
+	e.AbsVLineTo(y1)
+	e.AbsHLineTo(x0)
+	e.ClosePathEndPath()
+}
+
+// graphic returns a graphic with a viewBox from (0, 0) to (64, 64).
+func graphic(t *testing.T) []byte {
+	var e encode.Encoder
+	e.Reset(ivg.ViewBox{MinX: 0, MinY: 0, MaxX: 64, MaxY: 64}, ivg.DefaultPalette)
+	e.SetCReg(0, false, ivg.RGBAColor(red))
+	square(&e, 8, 8, 40, 40)
+	e.SetCReg(0, false, ivg.RGBAColor(blue))
+	square(&e, 24, 24, 56, 56)
+	e.SetCReg(0, false, ivg.RGBAColor(color.RGBA{}))
+	square(&e, 0, 0, 64, 64)
+	e.SetCReg(0, false, ivg.RGBAColor(green))
+	e.SetLOD(0, 32)
+	square(&e, 0, 0, 64, 64)
+	e.SetLOD(0, float32(math.Inf(1)))
+	e.SetCReg(0, false, ivg.RGBAColor(black))
+	// A ring, of which the inner square goes the other way.
+	e.StartPath(0, 44, 44)
+	e.AbsHLineTo(60)
+	e.AbsVLineTo(60)
+	e.AbsHLineTo(44)
+	e.ClosePathAbsMoveTo(48, 48)
+	e.AbsVLineTo(56)
+	e.AbsHLineTo(56)
+	e.AbsVLineTo(48)
+	e.ClosePathEndPath()
+	// Two squares that go the same way, which overlap where their winding
+	// number is 2.
+	e.SetCReg(0, false, ivg.RGBAColor(red))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(6)
+	e.AbsVLineTo(6)
+	e.AbsHLineTo(0)
+	e.ClosePathAbsMoveTo(3, 3)
+	e.AbsHLineTo(9)
+	e.AbsVLineTo(9)
+	e.AbsHLineTo(3)
+	e.ClosePathEndPath()
+	data, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTest(t *testing.T) {
+	data := graphic(t)
+	testCases := []struct {
+		r         image.Rectangle
+		x, y      float32
+		wantLayer int
+		wantColor color.RGBA
+	}{
+		{image.Rect(100, 100, 164, 164), 110, 110, 0, red},
+		{image.Rect(100, 100, 164, 164), 130.5, 139.9, 1, blue},
+		{image.Rect(100, 100, 164, 164), 100.5, 140, -1, color.RGBA{}},
+		{image.Rect(100, 100, 164, 164), 90, 90, -1, color.RGBA{}},
+		{image.Rect(100, 100, 164, 164), 146, 158, 4, black},
+		{image.Rect(100, 100, 164, 164), 152, 152, 1, blue},
+		{image.Rect(100, 100, 164, 164), 161, 161, -1, color.RGBA{}},
+		{image.Rect(100, 100, 164, 164), 104.5, 104.5, 5, red},
+		{image.Rect(100, 100, 164, 164), 101, 101, 5, red},
+		// At a height below 32, the green square covers the others.
+		{image.Rect(0, 0, 16, 16), 3, 3, 3, green},
+		{image.Rect(0, 0, 16, 16), 11.5, 14.5, 4, black},
+		{image.Rect(0, 0, 16, 16), 13, 13, 3, green},
+	}
+	for _, tc := range testCases {
+		layer, c, err := Test(data, tc.r, tc.x, tc.y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if layer != tc.wantLayer || c != tc.wantColor {
+			t.Errorf("r %v, point (%v, %v): got layer %d and color %v, want %d and %v",
+				tc.r, tc.x, tc.y, layer, c, tc.wantLayer, tc.wantColor)
+		}
+	}
+}
+
+// TestRender tests that the pixels of the testdata files that render
+// transparent are not hit at their centers, and the pixels that render opaque
+// are.
+func TestRender(t *testing.T) {
+	const length = 48
+	for _, filename := range []string{
+		"../testdata/action-info.lores",
+		"../testdata/arcs",
+		"../testdata/cowbell",
+		"../testdata/elliptical",
+		"../testdata/favicon",
+		"../testdata/gradient",
+		"../testdata/lod-polygon",
+	} {
+		data, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst := image.NewAlpha(image.Rect(0, 0, length, length))
+		var z render.Renderer
+		z.SetRasterizer(img.NewRasterizer(dst), dst.Bounds())
+		if err := decode.Decode(&z, data); err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		for y := 0; y < length; y++ {
+			for x := 0; x < length; x++ {
+				a := dst.AlphaAt(x, y).A
+				if a != 0 && a != 0xff {
+					continue
+				}
+				layer, c, err := Test(data, dst.Bounds(), float32(x)+0.5, float32(y)+0.5)
+				if err != nil {
+					t.Fatalf("%s: %v", filename, err)
+				}
+				// A gradient can be so nearly transparent that it renders
+				// transparent.
+				if a == 0xff && layer < 0 || a == 0 && layer >= 0 && c.A > 1 {
+					t.Errorf("%s: pixel (%d, %d) with alpha %#02x: got layer %d", filename, x, y, a, layer)
+				}
+			}
+		}
+	}
+}
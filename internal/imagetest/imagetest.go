@@ -0,0 +1,60 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package imagetest compares the images that the tests render with the PNG
+// files that they are expected to match.
+package imagetest
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+)
+
+// DecodePNG decodes the PNG file srcFilename.
+func DecodePNG(srcFilename string) (image.Image, error) {
+	f, err := os.Open(srcFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// CheckApproxEqual returns an error describing the first pixel at which m0
+// and m1 differ by more than a rasterizer would, or that their bounds differ.
+func CheckApproxEqual(m0, m1 image.Image) error {
+	diff := func(a, b uint32) uint32 {
+		if a < b {
+			return b - a
+		}
+		return a - b
+	}
+
+	bounds0 := m0.Bounds()
+	bounds1 := m1.Bounds()
+	if bounds0 != bounds1 {
+		return fmt.Errorf("bounds differ: got %v, want %v", bounds0, bounds1)
+	}
+	for y := bounds0.Min.Y; y < bounds0.Max.Y; y++ {
+		for x := bounds0.Min.X; x < bounds0.Max.X; x++ {
+			r0, g0, b0, a0 := m0.At(x, y).RGBA()
+			r1, g1, b1, a1 := m1.At(x, y).RGBA()
+
+			// TODO: be more principled in picking this magic threshold, other
+			// than what the difference is, in practice, in x/image/vector's
+			// fixed and floating point rasterizer?
+			const D = 0xffff * 12 / 100 // Diff threshold of 12%.
+
+			if diff(r0, r1) > D || diff(g0, g1) > D || diff(b0, b1) > D || diff(a0, a1) > D {
+				return fmt.Errorf("at (%d, %d):\n"+
+					"got  RGBA %#04x, %#04x, %#04x, %#04x\n"+
+					"want RGBA %#04x, %#04x, %#04x, %#04x",
+					x, y, r0, g0, b0, a0, r1, g1, b1, a1)
+			}
+		}
+	}
+	return nil
+}
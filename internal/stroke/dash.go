@@ -0,0 +1,103 @@
+package stroke
+
+import "math"
+
+// Dash returns the path of the dashes of p, which are open subpaths. The
+// lengths of the dashes and the gaps between them alternate in pattern, which
+// is repeated along each subpath of p, starting at offset into the pattern.
+// Like in SVG, a pattern of odd length is repeated twice to make it even, and
+// a closed subpath that is a single dash stays closed. A pattern with a
+// negative length, or without any length, does not dash p, and Dash returns
+// p itself.
+func (p *Path) Dash(pattern []float64, offset float64) *Path {
+	total := 0.0
+	for _, l := range pattern {
+		if !(l >= 0) {
+			return p
+		}
+		total += l
+	}
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern[:len(pattern):len(pattern)], pattern...)
+		total *= 2
+	}
+	if !(total > 0) || math.IsInf(total, 0) || math.IsNaN(offset) || math.IsInf(offset, 0) {
+		return p
+	}
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+	d := &Path{pen: p.pen}
+	for _, sp := range p.subpaths {
+		// Find the dash or gap at offset, which may have zero length.
+		i, left := 0, offset
+		for left > pattern[i] || left == pattern[i] && left > 0 {
+			left -= pattern[i]
+			i = (i + 1) % len(pattern)
+		}
+		left = pattern[i] - left
+		on := i%2 == 0
+
+		segs := sp.segs
+		if sp.closed && len(segs) > 0 {
+			if last := segs[len(segs)-1].p[3]; last != sp.start {
+				segs = append(segs[:len(segs):len(segs)], lineSegment(last, sp.start))
+			}
+		}
+		if len(segs) == 0 {
+			if sp.drawn && on {
+				d.subpaths = append(d.subpaths, subpath{start: sp.start, drawn: true})
+			}
+			continue
+		}
+		first := len(d.subpaths)
+		if on {
+			d.subpaths = append(d.subpaths, subpath{start: sp.start, drawn: true})
+		}
+		split := false
+		for _, s := range segs {
+			l := s.length()
+			for l > left {
+				// The dash or gap ends within s.
+				t := s.paramAt(left, l)
+				a, b := s.splitAt(t)
+				if on {
+					d.subpaths[len(d.subpaths)-1].add(a)
+				}
+				s, l = b, l-left
+				i = (i + 1) % len(pattern)
+				left, on, split = pattern[i], !on, true
+				if on {
+					d.subpaths = append(d.subpaths, subpath{start: s.p[0], drawn: true})
+				}
+			}
+			left -= l
+			if on {
+				d.subpaths[len(d.subpaths)-1].add(s)
+			}
+		}
+		if sp.closed && !split && on {
+			d.subpaths[first].closed = true
+		}
+	}
+	return d
+}
+
+// paramAt returns the t at which the part of s from its start has length l,
+// where length is the length of all of s.
+func (s segment) paramAt(l, length float64) float64 {
+	if s.line {
+		return l / length
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 24; i++ {
+		t := (lo + hi) / 2
+		if a, _ := s.splitAt(t); a.length() < l {
+			lo = t
+		} else {
+			hi = t
+		}
+	}
+	return (lo + hi) / 2
+}
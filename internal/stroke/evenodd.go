@@ -0,0 +1,192 @@
+package stroke
+
+import (
+	"math"
+	"sort"
+)
+
+// flatSegments is the number of lines that a curve is flattened to, to find
+// out where the contours of a path lie relative to each other.
+const flatSegments = 16
+
+// EvenOdd sends the subpaths of p to dst as contours that, filled with the
+// nonzero winding rule, cover the area that p covers when filled with the
+// evenodd rule. A contour that is inside an odd number of other contours is a
+// hole, so it must go in the direction opposite to that of the innermost
+// contour that it is inside of, and EvenOdd reverses the contours that do
+// not. It returns whether it reversed any, because p is filled alike by
+// either rule when it did not. That is only exact when no contour crosses
+// itself or another contour, so EvenOdd sends nothing and returns false for
+// ok when one does.
+func (p *Path) EvenOdd(dst Sink) (reversed, ok bool) {
+	var contours []contour
+	for _, sp := range p.subpaths {
+		if len(sp.segs) > 0 {
+			contours = append(contours, flatten(sp.segs))
+		}
+	}
+	for i := range contours {
+		for j := i; j < len(contours); j++ {
+			if contours[i].crosses(&contours[j], i == j) {
+				return false, false
+			}
+		}
+	}
+
+	// The innermost contour that a contour is inside of is the one of them
+	// that is inside the most contours, and the directions of the contours
+	// are decided from the outermost ones in.
+	depth, parent := make([]int, len(contours)), make([]int, len(contours))
+	order := make([]int, len(contours))
+	for i := range contours {
+		var inside []int
+		for j := range contours {
+			if j != i && contours[j].contains(contours[i].points) {
+				inside = append(inside, j)
+			}
+		}
+		depth[i], parent[i], order[i] = len(inside), -1, i
+		for _, j := range inside {
+			if parent[i] < 0 || depth[j] > depth[parent[i]] {
+				parent[i] = j
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return depth[order[a]] < depth[order[b]] })
+	positive := make([]bool, len(contours))
+	flip := make([]bool, len(contours))
+	for _, i := range order {
+		positive[i] = contours[i].area() > 0
+		if j := parent[i]; j >= 0 && positive[i] == positive[j] {
+			positive[i], flip[i], reversed = !positive[i], true, true
+		}
+	}
+
+	for i, c := range contours {
+		segs := c.segs
+		if flip[i] {
+			segs = reverse(segs)
+		}
+		dst.MoveTo(segs[0].p[0].x, segs[0].p[0].y)
+		for _, s := range segs {
+			if s.line {
+				dst.LineTo(s.p[3].x, s.p[3].y)
+			} else {
+				dst.CubeTo(s.p[1].x, s.p[1].y, s.p[2].x, s.p[2].y, s.p[3].x, s.p[3].y)
+			}
+		}
+		dst.Close()
+	}
+	return reversed, true
+}
+
+// contour is a subpath with the polygon that it is flattened to, which is
+// implicitly closed.
+type contour struct {
+	segs     []segment
+	points   []vec2
+	min, max vec2
+}
+
+func flatten(segs []segment) contour {
+	c := contour{segs: segs, points: []vec2{segs[0].p[0]}}
+	for _, s := range segs {
+		if s.line {
+			c.points = append(c.points, s.p[3])
+			continue
+		}
+		for k := 1; k <= flatSegments; k++ {
+			c.points = append(c.points, s.point(float64(k)/flatSegments))
+		}
+	}
+	if n := len(c.points); n > 1 && c.points[n-1] == c.points[0] {
+		c.points = c.points[:n-1]
+	}
+	c.min, c.max = c.points[0], c.points[0]
+	for _, q := range c.points[1:] {
+		c.min = vec2{math.Min(c.min.x, q.x), math.Min(c.min.y, q.y)}
+		c.max = vec2{math.Max(c.max.x, q.x), math.Max(c.max.y, q.y)}
+	}
+	return c
+}
+
+// edge returns the i'th edge of the polygon of c.
+func (c *contour) edge(i int) (vec2, vec2) {
+	return c.points[i], c.points[(i+1)%len(c.points)]
+}
+
+// area returns the signed area of the polygon of c, which is positive when it
+// goes in the direction of positive angles.
+func (c *contour) area() float64 {
+	a := 0.0
+	for i := range c.points {
+		p, q := c.edge(i)
+		a += p.cross(q)
+	}
+	return a / 2
+}
+
+// crosses reports whether an edge of c crosses an edge of o, where self means
+// that o is c, whose adjacent edges meet without crossing.
+func (c *contour) crosses(o *contour, self bool) bool {
+	if c.max.x < o.min.x || o.max.x < c.min.x || c.max.y < o.min.y || o.max.y < c.min.y {
+		return false
+	}
+	n, m := len(c.points), len(o.points)
+	for i := 0; i < n; i++ {
+		a, b := c.edge(i)
+		j := 0
+		if self {
+			j = i + 2
+		}
+		for ; j < m; j++ {
+			if self && i == 0 && j == n-1 {
+				continue
+			}
+			if p, q := o.edge(j); cross(a, b, p, q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cross reports whether the line from a to b and the line from p to q cross,
+// rather than touch or overlap.
+func cross(a, b, p, q vec2) bool {
+	side := func(a, b, p vec2) float64 { return b.sub(a).cross(p.sub(a)) }
+	opposite := func(u, v float64) bool { return u < 0 && v > 0 || u > 0 && v < 0 }
+	return opposite(side(a, b, p), side(a, b, q)) && opposite(side(p, q, a), side(p, q, b))
+}
+
+// contains reports whether the points, which do not cross c, are inside c. It
+// decides by the first of the points that is not on the polygon of c, and
+// reports false when they all are.
+func (c *contour) contains(points []vec2) bool {
+	for _, q := range points {
+		if in, ok := c.inside(q); ok {
+			return in
+		}
+	}
+	return false
+}
+
+// inside reports whether q is inside the polygon of c, with ok false when q
+// is on it.
+func (c *contour) inside(q vec2) (in, ok bool) {
+	if q.x < c.min.x || c.max.x < q.x || q.y < c.min.y || c.max.y < q.y {
+		return false, true
+	}
+	for i := range c.points {
+		a, b := c.edge(i)
+		if b.sub(a).cross(q.sub(a)) == 0 &&
+			math.Min(a.x, b.x) <= q.x && q.x <= math.Max(a.x, b.x) &&
+			math.Min(a.y, b.y) <= q.y && q.y <= math.Max(a.y, b.y) {
+			return false, false
+		}
+		if (a.y > q.y) != (b.y > q.y) && q.x < a.x+(q.y-a.y)*(b.x-a.x)/(b.y-a.y) {
+			in = !in
+		}
+	}
+	return in, true
+}
@@ -0,0 +1,201 @@
+package stroke
+
+import "math"
+
+// vec2 is a point or a vector.
+type vec2 struct{ x, y float64 }
+
+func (a vec2) add(b vec2) vec2             { return vec2{a.x + b.x, a.y + b.y} }
+func (a vec2) sub(b vec2) vec2             { return vec2{a.x - b.x, a.y - b.y} }
+func (a vec2) mul(f float64) vec2          { return vec2{a.x * f, a.y * f} }
+func (a vec2) dot(b vec2) float64          { return a.x*b.x + a.y*b.y }
+func (a vec2) cross(b vec2) float64        { return a.x*b.y - a.y*b.x }
+func (a vec2) len() float64                { return math.Hypot(a.x, a.y) }
+func (a vec2) lerp(b vec2, t float64) vec2 { return a.add(b.sub(a).mul(t)) }
+
+// perp returns a rotated by 90 degrees, in the direction of positive angles.
+func (a vec2) perp() vec2 { return vec2{-a.y, a.x} }
+
+// unit returns a scaled to length 1, or the zero vector when a is zero.
+func (a vec2) unit() vec2 {
+	if l := a.len(); l != 0 {
+		return a.mul(1 / l)
+	}
+	return vec2{}
+}
+
+// segment is a cubic Bézier curve from p[0] to p[3] with control points p[1]
+// and p[2]. A line has its control points at its end points.
+type segment struct {
+	p    [4]vec2
+	line bool
+}
+
+func lineSegment(a, b vec2) segment {
+	return segment{p: [4]vec2{a, a, b, b}, line: true}
+}
+
+func (s segment) reverse() segment {
+	return segment{p: [4]vec2{s.p[3], s.p[2], s.p[1], s.p[0]}, line: s.line}
+}
+
+// startTangent returns the unit tangent at the start of s, skipping control
+// points that coincide with the start.
+func (s segment) startTangent() vec2 {
+	for _, q := range s.p[1:] {
+		if q != s.p[0] {
+			return q.sub(s.p[0]).unit()
+		}
+	}
+	return vec2{}
+}
+
+// endTangent returns the unit tangent at the end of s, skipping control
+// points that coincide with the end.
+func (s segment) endTangent() vec2 {
+	for i := 2; i >= 0; i-- {
+		if s.p[i] != s.p[3] {
+			return s.p[3].sub(s.p[i]).unit()
+		}
+	}
+	return vec2{}
+}
+
+func (s segment) point(t float64) vec2 {
+	a, b, c := s.p[0].lerp(s.p[1], t), s.p[1].lerp(s.p[2], t), s.p[2].lerp(s.p[3], t)
+	a, b = a.lerp(b, t), b.lerp(c, t)
+	return a.lerp(b, t)
+}
+
+// derivative returns the first and second derivatives of s at t.
+func (s segment) derivative(t float64) (vec2, vec2) {
+	a, b, c := s.p[1].sub(s.p[0]), s.p[2].sub(s.p[1]), s.p[3].sub(s.p[2])
+	d1 := a.lerp(b, t).lerp(b.lerp(c, t), t).mul(3)
+	d2 := b.sub(a).lerp(c.sub(b), t).mul(6)
+	return d1, d2
+}
+
+// split splits s at t = 0.5.
+func (s segment) split() (segment, segment) {
+	return s.splitAt(.5)
+}
+
+// splitAt splits s at t into the part before and the part after t. Lines
+// are split at the fraction t of their length.
+func (s segment) splitAt(t float64) (segment, segment) {
+	if s.line {
+		m := s.p[0].lerp(s.p[3], t)
+		return lineSegment(s.p[0], m), lineSegment(m, s.p[3])
+	}
+	a, b, c := s.p[0].lerp(s.p[1], t), s.p[1].lerp(s.p[2], t), s.p[2].lerp(s.p[3], t)
+	ab, bc := a.lerp(b, t), b.lerp(c, t)
+	m := ab.lerp(bc, t)
+	return segment{p: [4]vec2{s.p[0], a, ab, m}}, segment{p: [4]vec2{m, bc, c, s.p[3]}}
+}
+
+// length returns the arc length of s. It splits s until the length of the
+// control polygon of each part is close to the distance between its end
+// points, and then takes a weighted average of the two.
+func (s segment) length() float64 {
+	chord := s.p[3].sub(s.p[0]).len()
+	if s.line {
+		return chord
+	}
+	return s.lengthWithin(chord, 0)
+}
+
+func (s segment) lengthWithin(chord float64, depth int) float64 {
+	poly := s.p[1].sub(s.p[0]).len() + s.p[2].sub(s.p[1]).len() + s.p[3].sub(s.p[2]).len()
+	if depth >= 10 || poly-chord <= 1e-4*poly {
+		return (2*chord + poly) / 3
+	}
+	a, b := s.split()
+	return a.lengthWithin(a.p[3].sub(a.p[0]).len(), depth+1) + b.lengthWithin(b.p[3].sub(b.p[0]).len(), depth+1)
+}
+
+// subpath is a subpath of path data as absolute segments. It is drawn when it
+// has any command other than its moveto, even when all its segments have zero
+// length.
+type subpath struct {
+	start  vec2
+	segs   []segment
+	closed bool
+	drawn  bool
+}
+
+// add appends s to the subpath unless it has zero length.
+func (sp *subpath) add(s segment) {
+	sp.drawn = true
+	if s.p[0] != s.p[1] || s.p[0] != s.p[2] || s.p[0] != s.p[3] {
+		sp.segs = append(sp.segs, s)
+	}
+}
+
+// arcSegments converts the elliptical arc from a to b to cubic Bézier curves
+// of at most 90 degrees each. The radii rx and ry and the x-axis rotation phi,
+// in degrees, are as in SVG path data. See the SVG Implementation Notes,
+// section F.6.5 "Conversion from endpoint to center parameterization".
+func arcSegments(a vec2, rx, ry, phi float64, largeArc, sweep bool, b vec2) []segment {
+	if a == b {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []segment{lineSegment(a, b)}
+	}
+	sin, cos := math.Sincos(phi * math.Pi / 180)
+	hx, hy := (a.x-b.x)/2, (a.y-b.y)/2
+	x1, y1 := cos*hx+sin*hy, -sin*hx+cos*hy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		// The radii are too small to reach from a to b.
+		l = math.Sqrt(l)
+		rx, ry = rx*l, ry*l
+	}
+	coef := 0.0
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	if den := rx*rx*y1*y1 + ry*ry*x1*x1; num > 0 && den > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(a.x+b.x)/2, sin*cx1+cos*cy1+(a.y+b.y)/2
+	ux, uy := (x1-cx1)/rx, (y1-cy1)/ry
+	vx, vy := (-x1-cx1)/rx, (-y1-cy1)/ry
+	theta := math.Atan2(uy, ux)
+	delta := math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	point := func(t float64) vec2 {
+		s, c := math.Sincos(t)
+		return vec2{cx + rx*c*cos - ry*s*sin, cy + rx*c*sin + ry*s*cos}
+	}
+	derivative := func(t float64) vec2 {
+		s, c := math.Sincos(t)
+		return vec2{-rx*s*cos - ry*c*sin, -rx*s*sin + ry*c*cos}
+	}
+	n := int(math.Ceil(math.Abs(delta)/(math.Pi/2) - 1e-9))
+	if n < 1 {
+		n = 1
+	}
+	d := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(d/4)
+	segs := make([]segment, n)
+	for i := range segs {
+		t0, t1 := theta+float64(i)*d, theta+float64(i+1)*d
+		p0, p3 := point(t0), point(t1)
+		if i == 0 {
+			p0 = a
+		}
+		if i == n-1 {
+			p3 = b
+		}
+		segs[i].p = [4]vec2{p0, p0.add(derivative(t0).mul(k)), p3.sub(derivative(t1).mul(k)), p3}
+	}
+	return segs
+}
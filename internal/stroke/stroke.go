@@ -0,0 +1,416 @@
+// Package stroke converts the stroke of a path to the outline of the area it
+// covers, to be filled with the nonzero winding rule. It is shared by the
+// packages that stroke paths, which convert their own stroke styles to Style.
+// It also converts a path to be filled with the evenodd rule to one to be
+// filled with the nonzero rule.
+package stroke
+
+import "math"
+
+// Cap is the shape at the ends of the open subpaths of a stroke.
+type Cap uint8
+
+const (
+	CapButt Cap = iota
+	CapRound
+	CapSquare
+)
+
+// Join is the shape at the corners of a stroke.
+type Join uint8
+
+const (
+	JoinMiter Join = iota
+	JoinRound
+	JoinBevel
+)
+
+// Style is the style of a stroke. Its fields have the meaning of the SVG
+// stroke-width, stroke-linecap, stroke-linejoin and stroke-miterlimit
+// properties, and its zero value but for the width is the SVG default.
+type Style struct {
+	Width float64
+	Cap   Cap
+	Join  Join
+	// MiterLimit is the limit on the ratio of the length of a miter join to
+	// the width of the stroke, beyond which a miter join becomes a bevel
+	// join. Zero means the SVG default of 4.
+	MiterLimit float64
+}
+
+// tolerance is the distance, relative to half the width of a stroke, by which
+// the outline of a curve may deviate from the exact offset curve.
+const tolerance = 0.02
+
+// maxDepth is the maximum number of times an offset curve is split in half to
+// bring it within the tolerance.
+const maxDepth = 6
+
+// Path is a path of lines and cubic Bézier curves. Its zero value is an empty
+// path with the pen at (0, 0).
+type Path struct {
+	subpaths []subpath
+	pen      vec2
+}
+
+// Reset empties p and moves the pen back to (0, 0).
+func (p *Path) Reset() {
+	p.subpaths = p.subpaths[:0]
+	p.pen = vec2{}
+}
+
+// Pen returns the end point of the last command.
+func (p *Path) Pen() (x, y float64) {
+	return p.pen.x, p.pen.y
+}
+
+// MoveTo starts a new subpath at (x, y).
+func (p *Path) MoveTo(x, y float64) {
+	p.pen = vec2{x, y}
+	p.subpaths = append(p.subpaths, subpath{start: p.pen})
+}
+
+// current returns the subpath that the next segment is added to. Like in SVG,
+// a segment after a closed subpath starts a new subpath at the same start
+// point, and a segment before any MoveTo starts one at the pen.
+func (p *Path) current() *subpath {
+	n := len(p.subpaths)
+	if n == 0 {
+		p.subpaths = append(p.subpaths, subpath{start: p.pen})
+	} else if sp := p.subpaths[n-1]; sp.closed {
+		p.subpaths = append(p.subpaths, subpath{start: sp.start})
+	}
+	return &p.subpaths[len(p.subpaths)-1]
+}
+
+// LineTo adds a line from the pen to (x, y).
+func (p *Path) LineTo(x, y float64) {
+	q := vec2{x, y}
+	p.current().add(lineSegment(p.pen, q))
+	p.pen = q
+}
+
+// QuadTo adds a quadratic Bézier curve from the pen via (x1, y1) to (x, y).
+func (p *Path) QuadTo(x1, y1, x, y float64) {
+	c, q := vec2{x1, y1}, vec2{x, y}
+	// Elevate the quadratic curve to a cubic one.
+	p.current().add(segment{p: [4]vec2{p.pen, p.pen.lerp(c, 2.0/3), q.lerp(c, 2.0/3), q}})
+	p.pen = q
+}
+
+// CubeTo adds a cubic Bézier curve from the pen via (x1, y1) and (x2, y2) to
+// (x, y).
+func (p *Path) CubeTo(x1, y1, x2, y2, x, y float64) {
+	q := vec2{x, y}
+	p.current().add(segment{p: [4]vec2{p.pen, {x1, y1}, {x2, y2}, q}})
+	p.pen = q
+}
+
+// ArcTo adds an elliptical arc from the pen to (x, y). The radii rx and ry,
+// the x-axis rotation phi in degrees and the flags are as in SVG path data.
+func (p *Path) ArcTo(rx, ry, phi float64, largeArc, sweep bool, x, y float64) {
+	q := vec2{x, y}
+	sp := p.current()
+	sp.drawn = true
+	for _, s := range arcSegments(p.pen, rx, ry, phi, largeArc, sweep, q) {
+		sp.add(s)
+	}
+	p.pen = q
+}
+
+// Close closes the current subpath and moves the pen back to its start.
+func (p *Path) Close() {
+	if n := len(p.subpaths); n > 0 {
+		sp := &p.subpaths[n-1]
+		sp.closed, sp.drawn = true, true
+		p.pen = sp.start
+	}
+}
+
+// Sink receives the outline of a stroke, as contours that each start with a
+// MoveTo and end with a Close.
+type Sink interface {
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	CubeTo(x1, y1, x2, y2, x, y float64)
+	Close()
+}
+
+// ArcSink is a Sink that receives the round caps and joins of a stroke as
+// circular arcs, which are otherwise converted to cubic Bézier curves.
+type ArcSink interface {
+	Sink
+	// ArcTo adds a circular arc of radius r, of at most a half circle, from
+	// the current point to (x, y), which turns towards negative angles.
+	ArcTo(r, x, y float64)
+}
+
+// Outline sends the outline of the stroke of p in style s to dst. Open
+// subpaths get a cap at either end and closed subpaths get a join where they
+// start. A stroke that is not wider than zero has no outline.
+func (p *Path) Outline(s Style, dst Sink) {
+	if !(s.Width > 0) {
+		return
+	}
+	o := outliner{
+		h:          s.Width / 2,
+		cap:        s.Cap,
+		join:       s.Join,
+		miterLimit: s.MiterLimit,
+		dst:        dst,
+	}
+	if o.miterLimit == 0 {
+		o.miterLimit = 4
+	}
+	o.arcs, _ = dst.(ArcSink)
+	for _, sp := range p.subpaths {
+		o.subpath(sp)
+	}
+	o.close()
+}
+
+// outliner converts subpaths to the outline of their stroke. The outline of
+// a side of a subpath is its offset by h to the left, which is the direction
+// of positive angles, and the right side is the left side of the reversed
+// subpath. Outer corners get joins, which therefore always turn towards
+// negative angles, and inner corners go through the corner point, which is
+// inside the stroke.
+type outliner struct {
+	h          float64
+	cap        Cap
+	join       Join
+	miterLimit float64
+	dst        Sink
+	arcs       ArcSink
+	pen, start vec2
+	// line is a line to pen that is not yet sent to dst, because closing the
+	// contour draws it anyway when it ends at start.
+	line    bool
+	contour bool
+}
+
+func (o *outliner) moveTo(p vec2) {
+	o.close()
+	o.dst.MoveTo(p.x, p.y)
+	o.pen, o.start, o.contour = p, p, true
+}
+
+// close closes the current contour, if any.
+func (o *outliner) close() {
+	if o.line && o.pen.sub(o.start).len() > 1e-9*o.h {
+		o.dst.LineTo(o.pen.x, o.pen.y)
+	}
+	if o.contour {
+		o.dst.Close()
+	}
+	o.line, o.contour = false, false
+}
+
+// flush sends a pending line to dst.
+func (o *outliner) flush() {
+	if o.line {
+		o.dst.LineTo(o.pen.x, o.pen.y)
+		o.line = false
+	}
+}
+
+func (o *outliner) lineTo(p vec2) {
+	if p.sub(o.pen).len() <= 1e-9*o.h {
+		return
+	}
+	o.flush()
+	o.pen, o.line = p, true
+}
+
+func (o *outliner) cubeTo(c1, c2, p vec2) {
+	o.flush()
+	o.dst.CubeTo(c1.x, c1.y, c2.x, c2.y, p.x, p.y)
+	o.pen = p
+}
+
+// arcTo adds a circular arc of radius h that turns towards negative angles.
+func (o *outliner) arcTo(p vec2) {
+	o.flush()
+	if o.arcs != nil {
+		o.arcs.ArcTo(o.h, p.x, p.y)
+	} else {
+		for _, s := range arcSegments(o.pen, o.h, o.h, 0, false, false, p) {
+			o.dst.CubeTo(s.p[1].x, s.p[1].y, s.p[2].x, s.p[2].y, s.p[3].x, s.p[3].y)
+		}
+	}
+	o.pen = p
+}
+
+func (o *outliner) subpath(sp subpath) {
+	segs := sp.segs
+	if len(segs) == 0 {
+		if sp.drawn {
+			o.dot(sp.start)
+		}
+		return
+	}
+	if !sp.closed {
+		first, last := segs[0], segs[len(segs)-1]
+		o.moveTo(first.p[0].add(first.startTangent().perp().mul(o.h)))
+		o.side(segs, false)
+		o.capTo(last.p[3], last.endTangent())
+		o.side(reverse(segs), false)
+		o.capTo(first.p[0], first.startTangent().mul(-1))
+		return
+	}
+	if last := segs[len(segs)-1].p[3]; last != sp.start {
+		segs = append(segs[:len(segs):len(segs)], lineSegment(last, sp.start))
+	}
+	for _, s := range [][]segment{segs, reverse(segs)} {
+		o.moveTo(s[0].p[0].add(s[0].startTangent().perp().mul(o.h)))
+		o.side(s, true)
+	}
+}
+
+// dot draws the caps of a subpath of zero length at p, aligned with the
+// x-axis.
+func (o *outliner) dot(p vec2) {
+	h := o.h
+	switch o.cap {
+	case CapRound:
+		o.moveTo(vec2{p.x + h, p.y})
+		o.arcTo(vec2{p.x - h, p.y})
+		o.arcTo(vec2{p.x + h, p.y})
+	case CapSquare:
+		o.moveTo(vec2{p.x + h, p.y + h})
+		o.lineTo(vec2{p.x - h, p.y + h})
+		o.lineTo(vec2{p.x - h, p.y - h})
+		o.lineTo(vec2{p.x + h, p.y - h})
+	}
+}
+
+func reverse(segs []segment) []segment {
+	r := make([]segment, len(segs))
+	for i, s := range segs {
+		r[len(segs)-1-i] = s.reverse()
+	}
+	return r
+}
+
+// side adds the left side of segs, from the offset of the start of the first
+// segment, which must be the current point. Closed subpaths end with a join
+// back to that point.
+func (o *outliner) side(segs []segment, closed bool) {
+	for i, s := range segs {
+		if s.line {
+			o.lineTo(s.p[3].add(s.endTangent().perp().mul(o.h)))
+		} else {
+			o.offset(s, 0)
+		}
+		if i+1 < len(segs) {
+			o.joinTo(s, segs[i+1])
+		} else if closed {
+			o.joinTo(s, segs[0])
+		}
+	}
+}
+
+// capTo adds the cap at the end point p of a side with tangent t.
+func (o *outliner) capTo(p, t vec2) {
+	n := t.perp().mul(o.h)
+	switch o.cap {
+	case CapRound:
+		o.arcTo(p.sub(n))
+	case CapSquare:
+		t = t.mul(o.h)
+		o.lineTo(p.add(n).add(t))
+		o.lineTo(p.sub(n).add(t))
+		o.lineTo(p.sub(n))
+	default:
+		o.lineTo(p.sub(n))
+	}
+}
+
+// joinTo adds the join between the left sides of a and the segment b that
+// follows it.
+func (o *outliner) joinTo(a, b segment) {
+	v, t1, t2 := a.p[3], a.endTangent(), b.startTangent()
+	n1, n2 := t1.perp().mul(o.h), t2.perp().mul(o.h)
+	p := v.add(n2)
+	cross, dot := t1.cross(t2), t1.dot(t2)
+	if math.Abs(cross) <= 1e-9 && dot > 0 {
+		// The path goes straight on.
+		o.lineTo(p)
+		return
+	}
+	if cross > 0 {
+		// An inner corner.
+		o.lineTo(v)
+		o.lineTo(p)
+		return
+	}
+	switch o.join {
+	case JoinRound:
+		o.arcTo(p)
+	case JoinMiter:
+		// The ratio of the length of the miter to the width of the stroke is
+		// 1/sin(θ/2), where θ is the angle between the segments, which is
+		// sqrt(2/(1+dot)).
+		if 1+dot > 0 && 2 <= o.miterLimit*o.miterLimit*(1+dot) {
+			o.lineTo(v.add(n1.add(n2).mul(1 / (1 + dot))))
+		}
+		o.lineTo(p)
+	default:
+		o.lineTo(p)
+	}
+}
+
+// offset adds the offset of the curve s by h to its left, which starts at
+// the current point. It approximates the offset curve by a curve with the
+// same tangents at its end points, and splits s until that is within the
+// tolerance.
+func (o *outliner) offset(s segment, depth int) {
+	q := o.approximate(s)
+	if depth < maxDepth && o.deviation(s, q) > tolerance*o.h {
+		a, b := s.split()
+		o.offset(a, depth+1)
+		o.offset(b, depth+1)
+		return
+	}
+	o.cubeTo(q.p[1], q.p[2], q.p[3])
+}
+
+// approximate returns the curve whose end points are the offsets of those of
+// s, and whose control points are along the same tangents at distances that
+// are scaled to put its midpoint at the offset of the midpoint of s. That is
+// exact when s approximates a circular arc.
+func (o *outliner) approximate(s segment) segment {
+	t0, t3 := s.startTangent(), s.endTangent()
+	q0, q3 := s.p[0].add(t0.perp().mul(o.h)), s.p[3].add(t3.perp().mul(o.h))
+	l0, l3 := s.p[1].sub(s.p[0]), s.p[2].sub(s.p[3])
+	// The midpoint of the curve is (q0+q3)/2 + 3/8·k·(l0+l3) for scale k.
+	d1, _ := s.derivative(.5)
+	m := s.point(.5).add(d1.unit().perp().mul(o.h)).sub(q0.lerp(q3, .5))
+	k, l := 1.0, l0.add(l3)
+	if ll := l.dot(l); ll > 1e-12*(l0.dot(l0)+l3.dot(l3)) {
+		k = math.Max(0, m.dot(l)/(.375*ll))
+	}
+	return segment{p: [4]vec2{q0, q0.add(l0.mul(k)), q3.add(l3.mul(k)), q3}}
+}
+
+// deviation returns how far q deviates from the offset of s by h, measured
+// as the difference of h and the distance of points on q to s.
+func (o *outliner) deviation(s, q segment) float64 {
+	dev := 0.0
+	for _, t := range [...]float64{.25, .5, .75} {
+		p := q.point(t)
+		// Find the point on s closest to p by Newton's method.
+		u := t
+		for i := 0; i < 4; i++ {
+			d1, d2 := s.derivative(u)
+			r := s.point(u).sub(p)
+			den := d1.dot(d1) + r.dot(d2)
+			if den == 0 {
+				break
+			}
+			u = math.Max(0, math.Min(1, u-r.dot(d1)/den))
+		}
+		dev = math.Max(dev, math.Abs(s.point(u).sub(p).len()-o.h))
+	}
+	return dev
+}
@@ -0,0 +1,344 @@
+package stroke
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/vector"
+)
+
+// rasterizer is a Sink that fills an outline centered in a 64x64 image.
+type rasterizer struct{ vector.Rasterizer }
+
+func (z *rasterizer) MoveTo(x, y float64) { z.Rasterizer.MoveTo(float32(x)+32, float32(y)+32) }
+func (z *rasterizer) LineTo(x, y float64) { z.Rasterizer.LineTo(float32(x)+32, float32(y)+32) }
+func (z *rasterizer) Close()              { z.ClosePath() }
+
+func (z *rasterizer) CubeTo(x1, y1, x2, y2, x, y float64) {
+	z.Rasterizer.CubeTo(float32(x1)+32, float32(y1)+32, float32(x2)+32, float32(y2)+32, float32(x)+32, float32(y)+32)
+}
+
+func rasterize(p *Path, s Style) *image.Alpha {
+	var z rasterizer
+	z.Reset(64, 64)
+	p.Outline(s, &z)
+	dst := image.NewAlpha(image.Rect(0, 0, 64, 64))
+	z.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+	return dst
+}
+
+// points returns points along the segments of p, at most 1/256 of their
+// parameter range apart.
+func points(p *Path) []vec2 {
+	var points []vec2
+	for _, sp := range p.subpaths {
+		if sp.drawn && len(sp.segs) == 0 {
+			points = append(points, sp.start)
+		}
+		for _, s := range sp.segs {
+			for i := 0; i <= 256; i++ {
+				points = append(points, s.point(float64(i)/256))
+			}
+		}
+	}
+	return points
+}
+
+// checkCovers checks that a stroke with round caps and joins of width 6
+// covers the points within 2 of the points of want, and nothing further than
+// 4 away from them.
+func checkCovers(t *testing.T, name string, got *image.Alpha, want []vec2) {
+	t.Helper()
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			p, dist := vec2{float64(x) - 31.5, float64(y) - 31.5}, math.Inf(1)
+			for _, q := range want {
+				dist = math.Min(dist, p.sub(q).len())
+			}
+			a := got.AlphaAt(x, y).A
+			if (dist < 2 && a != 0xff) || (dist > 4 && a != 0) {
+				t.Errorf("%s: at (%d, %d), %g from the path: got alpha %#02x", name, x, y, dist, a)
+				return
+			}
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	// A stroke with round caps and joins covers the points that are within
+	// half its width of the path.
+	testCases := []struct {
+		name string
+		path func(p *Path)
+	}{
+		{"cubic", func(p *Path) {
+			p.MoveTo(-20, 10)
+			p.CubeTo(-20, -30, 20, 30, 20, -10)
+		}},
+		{"quads", func(p *Path) {
+			p.MoveTo(-20, 0)
+			p.QuadTo(-20, -20, 0, -20)
+			p.QuadTo(20, -20, 20, 0)
+			p.QuadTo(20, 20, 0, 20)
+			p.QuadTo(-20, 20, -20, 0)
+			p.Close()
+		}},
+		{"inflection", func(p *Path) {
+			p.MoveTo(-20, -20)
+			p.CubeTo(20, -20, -20, 20, 20, 20)
+		}},
+		{"zigzag", func(p *Path) {
+			p.MoveTo(-20, 20)
+			p.LineTo(-10, -20)
+			p.LineTo(0, 20)
+			p.LineTo(10, -20)
+			p.LineTo(20, 20)
+		}},
+		{"arc", func(p *Path) {
+			p.MoveTo(-10, 0)
+			p.ArcTo(10, 20, 30, true, true, 10, 0)
+			p.CubeTo(10, 0, 20, 20, 0, 20)
+		}},
+		{"dot", func(p *Path) {
+			p.MoveTo(0, 0)
+			p.LineTo(0, 0)
+		}},
+	}
+	for _, tc := range testCases {
+		var p Path
+		tc.path(&p)
+		got := rasterize(&p, Style{Width: 6, Cap: CapRound, Join: JoinRound})
+		checkCovers(t, tc.name, got, points(&p))
+	}
+}
+
+func TestDash(t *testing.T) {
+	type dash struct {
+		x0, x1 float64
+		closed bool
+	}
+	testCases := []struct {
+		pattern []float64
+		offset  float64
+		path    func(p *Path)
+		want    []dash
+	}{{
+		pattern: []float64{10, 5},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0) },
+		want:    []dash{{0, 10, false}, {15, 25, false}, {30, 40, false}},
+	}, {
+		pattern: []float64{10, 5},
+		offset:  12,
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0) },
+		want:    []dash{{3, 13, false}, {18, 28, false}, {33, 40, false}},
+	}, {
+		pattern: []float64{10, 5},
+		offset:  -3,
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(20, 0); p.LineTo(40, 0) },
+		want:    []dash{{3, 13, false}, {18, 28, false}, {33, 40, false}},
+	}, {
+		// A pattern of odd length is repeated twice.
+		pattern: []float64{10},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0) },
+		want:    []dash{{0, 10, false}, {20, 30, false}},
+	}, {
+		// Each subpath starts the pattern anew.
+		pattern: []float64{10, 5},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(12, 0); p.MoveTo(20, 0); p.LineTo(32, 0) },
+		want:    []dash{{0, 10, false}, {20, 30, false}},
+	}, {
+		// A zero-length dash is a dot.
+		pattern: []float64{0, 10},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(25, 0) },
+		want:    []dash{{0, 0, false}, {10, 10, false}, {20, 20, false}},
+	}, {
+		// A closed subpath that is a single dash stays closed.
+		pattern: []float64{100, 5},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0); p.Close() },
+		want:    []dash{{0, 0, true}},
+	}, {
+		pattern: []float64{30, 20},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0); p.Close() },
+		want:    []dash{{0, 30, false}, {30, 0, false}},
+	}, {
+		// A pattern with a negative length does not dash the path.
+		pattern: []float64{10, -5},
+		path:    func(p *Path) { p.MoveTo(0, 0); p.LineTo(40, 0) },
+		want:    []dash{{0, 40, false}},
+	}}
+	for i, tc := range testCases {
+		var p Path
+		tc.path(&p)
+		d := p.Dash(tc.pattern, tc.offset)
+		var got []dash
+		for _, sp := range d.subpaths {
+			x1 := sp.start.x
+			if n := len(sp.segs); n > 0 {
+				x1 = sp.segs[n-1].p[3].x
+			}
+			got = append(got, dash{sp.start.x, x1, sp.closed})
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("#%d: got %v, want %v", i, got, tc.want)
+			continue
+		}
+		for j := range got {
+			if math.Abs(got[j].x0-tc.want[j].x0) > 1e-9 || math.Abs(got[j].x1-tc.want[j].x1) > 1e-9 || got[j].closed != tc.want[j].closed {
+				t.Errorf("#%d: got %v, want %v", i, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDashLength(t *testing.T) {
+	// The dashes of a circle of radius 20 have the lengths of the pattern.
+	var p Path
+	p.MoveTo(-20, 0)
+	p.ArcTo(20, 20, 0, true, true, 20, 0)
+	p.ArcTo(20, 20, 0, true, true, -20, 0)
+	p.Close()
+	d := p.Dash([]float64{7, 3}, 0)
+	circumference := 0.0
+	for _, sp := range p.subpaths {
+		for _, s := range sp.segs {
+			circumference += s.length()
+		}
+	}
+	// The curves that approximate the circle are a little longer than it.
+	if got, want := circumference, 40*math.Pi; math.Abs(got-want) > 0.05 {
+		t.Errorf("circumference: got %g, want %g", got, want)
+	}
+	n := int(circumference / 10)
+	if len(d.subpaths) != n+1 {
+		t.Fatalf("got %d dashes, want %d", len(d.subpaths), n+1)
+	}
+	for i, sp := range d.subpaths[:n] {
+		l := 0.0
+		for _, s := range sp.segs {
+			l += s.length()
+		}
+		if math.Abs(l-7) > 1e-3 {
+			t.Errorf("dash %d: got length %g, want 7", i, l)
+		}
+	}
+
+	// The dashes are drawn along the circle.
+	got := rasterize(d, Style{Width: 6, Cap: CapRound, Join: JoinRound})
+	checkCovers(t, "dashed circle", got, points(d))
+}
+
+// recorder is a Sink that records the commands it receives, with their end
+// points.
+type recorder struct{ strings.Builder }
+
+func (r *recorder) point(verb byte, x, y float64) {
+	// Rounding hides rounding errors, and adding zero turns negative zeros
+	// positive.
+	x, y = math.Round(x*1e4)/1e4+0, math.Round(y*1e4)/1e4+0
+	fmt.Fprintf(r, "%c%g,%g ", verb, x, y)
+}
+
+func (r *recorder) MoveTo(x, y float64)                 { r.point('M', x, y) }
+func (r *recorder) LineTo(x, y float64)                 { r.point('L', x, y) }
+func (r *recorder) CubeTo(x1, y1, x2, y2, x, y float64) { r.point('C', x, y) }
+func (r *recorder) Close()                              { r.WriteString("Z") }
+
+// arcRecorder is a recorder that is an ArcSink.
+type arcRecorder struct{ recorder }
+
+func (r *arcRecorder) ArcTo(radius, x, y float64) { r.point('A', x, y) }
+
+func TestOutlineArcs(t *testing.T) {
+	// An ArcSink receives round caps as arcs, and any other Sink receives
+	// them as curves through the same points.
+	var p Path
+	p.MoveTo(-10, 0)
+	p.LineTo(10, 0)
+	s := Style{Width: 4, Cap: CapRound}
+	var arcs arcRecorder
+	var curves recorder
+	p.Outline(s, &arcs)
+	p.Outline(s, &curves)
+	if got, want := arcs.String(), "M-10,2 L10,2 A10,-2 L-10,-2 A-10,2 Z"; got != want {
+		t.Errorf("arcs: got %q, want %q", got, want)
+	}
+	if got, want := curves.String(), "M-10,2 L10,2 C12,0 C10,-2 L-10,-2 C-12,0 C-10,2 Z"; got != want {
+		t.Errorf("curves: got %q, want %q", got, want)
+	}
+}
+
+// square adds a closed subpath that is a square from (x0, y0) to (x1, y1),
+// which goes in the direction of positive angles when x0 < x1 and y0 < y1.
+func square(p *Path, x0, y0, x1, y1 float64) {
+	p.MoveTo(x0, y0)
+	p.LineTo(x1, y0)
+	p.LineTo(x1, y1)
+	p.LineTo(x0, y1)
+	p.Close()
+}
+
+func TestEvenOdd(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     func(p *Path)
+		want     string
+		reversed bool
+		ok       bool
+	}{{
+		name: "holes wound oppositely",
+		path: func(p *Path) {
+			square(p, 0, 0, 30, 30)
+			square(p, 20, 10, 10, 20)
+		},
+		want: "M0,0 L30,0 L30,30 L0,30 Z" + "M20,10 L10,10 L10,20 L20,20 Z",
+		ok:   true,
+	}, {
+		name: "holes wound alike",
+		path: func(p *Path) {
+			square(p, 0, 0, 30, 30)
+			square(p, 10, 10, 20, 20)
+			square(p, 12, 12, 18, 18)
+			square(p, 40, 40, 50, 50)
+		},
+		want: "M0,0 L30,0 L30,30 L0,30 Z" + "M10,20 L20,20 L20,10 L10,10 Z" +
+			"M12,12 L18,12 L18,18 L12,18 Z" + "M40,40 L50,40 L50,50 L40,50 Z",
+		reversed: true,
+		ok:       true,
+	}, {
+		name: "outer contour wound negatively",
+		path: func(p *Path) {
+			square(p, 30, 0, 0, 30)
+			square(p, 10, 10, 20, 20)
+		},
+		want: "M30,0 L0,0 L0,30 L30,30 Z" + "M10,10 L20,10 L20,20 L10,20 Z",
+		ok:   true,
+	}, {
+		name: "crossing contours",
+		path: func(p *Path) {
+			square(p, 0, 0, 20, 20)
+			square(p, 10, 10, 30, 30)
+		},
+	}, {
+		name: "contour crossing itself",
+		path: func(p *Path) {
+			p.MoveTo(0, 0)
+			p.LineTo(20, 20)
+			p.LineTo(20, 0)
+			p.LineTo(0, 20)
+			p.Close()
+		},
+	}}
+	for _, tc := range testCases {
+		var p Path
+		tc.path(&p)
+		var r recorder
+		reversed, ok := p.EvenOdd(&r)
+		if got := r.String(); got != tc.want || reversed != tc.reversed || ok != tc.ok {
+			t.Errorf("%s: got %q, %t, %t, want %q, %t, %t", tc.name, got, reversed, ok, tc.want, tc.reversed, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+// Package lint checks IconVG graphics for problems, without rendering them.
+//
+// It finds the paths that a render.Renderer silently does not draw, like
+// paths with invalid colors or gradients, and what makes a graphic larger or
+// harder to maintain than it needs to be, like color registers that are set
+// but never used.
+package lint
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/measure"
+	"github.com/reactivego/ivg/optimize"
+)
+
+var positiveInfinity = math.Float32frombits(0x7f800000)
+
+// Check is the kind of a problem.
+type Check string
+
+const (
+	// Bleed is a path that extends beyond the viewBox.
+	Bleed Check = "bleed"
+	// InvalidColor is a path, or a stop of its gradient, whose
+	// alpha-premultiplied color has a channel that exceeds its alpha. A
+	// render.Renderer does not draw the path.
+	InvalidColor Check = "invalid-color"
+	// GradientStops is a path whose gradient has fewer than two stops, or
+	// stop offsets that do not increase from 0 to 1. A render.Renderer does
+	// not draw the path.
+	GradientStops Check = "gradient-stops"
+	// UnreachableLOD is a path whose level of detail includes no height, so
+	// that it is never drawn.
+	UnreachableLOD Check = "unreachable-lod"
+	// UnusedCReg is a color register that is set, but not used before it is
+	// set again or the graphic ends.
+	UnusedCReg Check = "unused-creg"
+	// ZeroArea is a path whose points all lie on a line, so that it fills
+	// no pixels.
+	ZeroArea Check = "zero-area"
+	// UnusedPalette is a suggested palette that no color refers to.
+	UnusedPalette Check = "unused-palette"
+	// LongEncoding is a graphic that package optimize encodes in fewer
+	// bytes.
+	LongEncoding Check = "long-encoding"
+)
+
+// Problem is a problem of a graphic.
+type Problem struct {
+	Check Check `json:"check"`
+	// Path is the index of the path that the problem is about, counting all
+	// the paths of the graphic, or -1 when it is not about a path.
+	Path    int    `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Path < 0 {
+		return fmt.Sprintf("%s (%s)", p.Message, p.Check)
+	}
+	return fmt.Sprintf("path %d: %s (%s)", p.Path, p.Message, p.Check)
+}
+
+// Lint decodes the IconVG graphic src and returns its problems, those about
+// paths in the order of the paths and then those about the whole graphic.
+func Lint(src []byte) ([]Problem, error) {
+	var l Linter
+	if err := decode.Decode(&l, src); err != nil {
+		return nil, err
+	}
+	problems := l.Problems()
+	optimized, err := optimize.Optimize(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(optimized) < len(src) {
+		problems = append(problems, Problem{LongEncoding, -1,
+			fmt.Sprintf("the graphic can be encoded in %d bytes instead of %d", len(optimized), len(src))})
+	}
+	return problems, nil
+}
+
+// Linter is an ivg.Destination that checks the graphic sent to it for
+// problems, except for LongEncoding, which needs the encoded graphic.
+//
+// The zero value is usable.
+type Linter struct {
+	m measure.Measurer
+
+	reset bool
+	// declared is whether the palette differs from the default palette,
+	// which is what a graphic with a suggested palette mostly does, and
+	// referenced is whether a color refers to it.
+	declared   bool
+	referenced bool
+	palette    [64]color.RGBA
+
+	cSel, nSel uint8
+	lod0, lod1 float32
+	cReg       [64]register
+	rgba       [64]color.RGBA
+	nReg       [64]float32
+
+	// paths is the number of paths started.
+	paths int
+	// pen and first are the current point and the start of the subpath.
+	pen, first point
+	// line holds the first nLine distinct points of the subpath, which are
+	// the line that its other points lie on until area is true. pathArea is
+	// whether an earlier subpath of the path has area.
+	line     [2]point
+	nLine    int
+	area     bool
+	pathArea bool
+
+	problems []Problem
+}
+
+type point struct{ x, y float64 }
+
+// register is how a color register is set and used.
+type register struct {
+	// c is the color that the register is set to, when set is true.
+	// Otherwise it holds its color of the palette.
+	c    ivg.Color
+	set  bool
+	used bool
+}
+
+// Problems returns the problems of the graphic, those about paths in the
+// order of the paths and then those about the whole graphic.
+func (l *Linter) Problems() []Problem {
+	l.lazyReset()
+	problems := append([]Problem(nil), l.problems...)
+	metrics := l.m.Metrics()
+	vb := metrics.ViewBox
+	for i, layer := range metrics.Layers {
+		if b := layer.Bounds; !b.In(vb) {
+			problems = append(problems, Problem{Bleed, i,
+				fmt.Sprintf("bounds (%g, %g)-(%g, %g) extend beyond the viewBox (%g, %g)-(%g, %g)",
+					b.MinX, b.MinY, b.MaxX, b.MaxY, vb.MinX, vb.MinY, vb.MaxX, vb.MaxY)})
+		}
+	}
+	for i, r := range l.cReg {
+		if r.set && !r.used {
+			problems = append(problems, Problem{UnusedCReg, -1,
+				fmt.Sprintf("CREG[%d] is set to %v, but never used", i, r.c)})
+		}
+	}
+	if l.declared && !l.referenced {
+		problems = append(problems, Problem{UnusedPalette, -1,
+			"the suggested palette is not referred to by any color"})
+	}
+	// The problems about the graphic go last.
+	sort.SliceStable(problems, func(i, j int) bool {
+		pi, pj := problems[i].Path, problems[j].Path
+		return pj < 0 && pi >= 0 || pi >= 0 && pi < pj
+	})
+	return problems
+}
+
+// Reset resets the Linter for the given Metadata.
+func (l *Linter) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	*l = Linter{
+		m:        l.m,
+		reset:    true,
+		declared: palette != ivg.DefaultPalette,
+		palette:  palette,
+		rgba:     palette,
+		lod1:     positiveInfinity,
+	}
+	for i := range l.cReg {
+		l.cReg[i].c = ivg.PaletteIndexColor(uint8(i))
+	}
+	l.m.Reset(viewbox, palette)
+}
+
+func (l *Linter) lazyReset() {
+	if !l.reset {
+		l.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	}
+}
+
+func (l *Linter) problem(check Check, path int, format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{check, path, fmt.Sprintf(format, args...)})
+}
+
+func (l *Linter) CSel() uint8 { return l.cSel }
+func (l *Linter) NSel() uint8 { return l.nSel }
+
+func (l *Linter) SetCSel(cSel uint8) {
+	l.lazyReset()
+	l.cSel = cSel & 0x3f
+	l.m.SetCSel(cSel)
+}
+
+func (l *Linter) SetNSel(nSel uint8) {
+	l.lazyReset()
+	l.nSel = nSel & 0x3f
+	l.m.SetNSel(nSel)
+}
+
+func (l *Linter) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	l.lazyReset()
+	i := (l.cSel - adj) & 0x3f
+	if incr {
+		l.cSel = (l.cSel + 1) & 0x3f
+	}
+	l.read(c)
+	if r := l.cReg[i]; r.set && !r.used {
+		l.problem(UnusedCReg, -1, "CREG[%d] is set to %v, but not used before it is set again", i, r.c)
+	}
+	l.rgba[i] = c.Resolve(&l.palette, &l.rgba)
+	l.cReg[i] = register{c: c, set: true}
+	l.m.SetCReg(adj, incr, c)
+}
+
+// read marks the color registers and the palette that c refers to as used.
+func (l *Linter) read(c ivg.Color) {
+	if _, ok := c.Encode4(); ok {
+		return
+	}
+	if x, ok := c.Encode1(); ok {
+		switch {
+		case x >= 0xc0:
+			l.use(x & 0x3f)
+		case x >= 0x80:
+			l.referenced = true
+		}
+		return
+	}
+	x, _ := c.Encode3Indirect()
+	l.read(ivg.DecodeColor1(x[1]))
+	l.read(ivg.DecodeColor1(x[2]))
+}
+
+// use marks CREG[i] as used, and the palette when CREG[i] still holds its
+// color of the palette.
+func (l *Linter) use(i uint8) {
+	r := &l.cReg[i&0x3f]
+	r.used = true
+	if !r.set {
+		l.referenced = true
+	}
+}
+
+func (l *Linter) SetNReg(adj uint8, incr bool, f float32) {
+	l.lazyReset()
+	i := (l.nSel - adj) & 0x3f
+	if incr {
+		l.nSel = (l.nSel + 1) & 0x3f
+	}
+	l.nReg[i] = f
+	l.m.SetNReg(adj, incr, f)
+}
+
+func (l *Linter) SetLOD(lod0, lod1 float32) {
+	l.lazyReset()
+	l.lod0, l.lod1 = lod0, lod1
+	l.m.SetLOD(lod0, lod1)
+}
+
+func (l *Linter) StartPath(adj uint8, x, y float32) {
+	l.lazyReset()
+	l.paths++
+	path := l.paths - 1
+	i := (l.cSel - adj) & 0x3f
+	l.use(i)
+	switch c := l.rgba[i]; {
+	case ivg.ValidAlphaPremulColor(c):
+	case ivg.ValidGradient(c):
+		l.gradient(path, c)
+	default:
+		l.problem(InvalidColor, path, "color %02x%02x%02x%02x of CREG[%d] has a channel that exceeds its alpha", c.R, c.G, c.B, c.A, i)
+	}
+	// The heights are not negative.
+	if !(l.lod0 < l.lod1 && 0 < l.lod1) {
+		l.problem(UnreachableLOD, path, "level of detail [%g, %g) includes no height", l.lod0, l.lod1)
+	}
+	l.pathArea = false
+	l.moveTo(point{float64(x), float64(y)})
+	l.m.StartPath(adj, x, y)
+}
+
+// gradient checks the stops of the gradient that c describes, like a
+// render.Renderer does before it draws the path.
+func (l *Linter) gradient(path int, c color.RGBA) {
+	cBase, nBase, _, _, nStops := ivg.DecodeGradient(c)
+	increasing := true
+	offsets := make([]float32, nStops)
+	prev := float32(math.Inf(-1))
+	for k := uint8(0); k < nStops; k++ {
+		i := (cBase + k) & 0x3f
+		l.use(i)
+		if c := l.rgba[i]; !ivg.ValidAlphaPremulColor(c) {
+			l.problem(InvalidColor, path, "gradient stop %d color %02x%02x%02x%02x of CREG[%d] has a channel that exceeds its alpha", k, c.R, c.G, c.B, c.A, i)
+		}
+		n := l.nReg[(nBase+k)&0x3f]
+		if !(0 <= n && n <= 1) || !(n > prev) {
+			increasing = false
+		}
+		offsets[k], prev = n, n
+	}
+	if nStops < 2 {
+		l.problem(GradientStops, path, "gradient has %d stops, fewer than two", nStops)
+	} else if !increasing {
+		l.problem(GradientStops, path, "gradient stop offsets %v do not increase from 0 to 1", offsets)
+	}
+}
+
+func (l *Linter) ClosePathEndPath() {
+	l.lazyReset()
+	if !l.pathArea && !l.area {
+		l.problem(ZeroArea, l.paths-1, "points all lie on a line, so it fills no pixels")
+	}
+	l.m.ClosePathEndPath()
+}
+
+func (l *Linter) ClosePathAbsMoveTo(x, y float32) {
+	l.lazyReset()
+	l.moveTo(point{float64(x), float64(y)})
+	l.m.ClosePathAbsMoveTo(x, y)
+}
+
+// ClosePathRelMoveTo moves relative to the start of the subpath, which is
+// where closing it moves the pen to.
+func (l *Linter) ClosePathRelMoveTo(x, y float32) {
+	l.lazyReset()
+	l.pen = l.first
+	l.moveTo(l.rel(x, y))
+	l.m.ClosePathRelMoveTo(x, y)
+}
+
+func (l *Linter) AbsHLineTo(x float32) {
+	l.lazyReset()
+	l.to(point{float64(x), l.pen.y})
+	l.m.AbsHLineTo(x)
+}
+
+func (l *Linter) RelHLineTo(x float32) {
+	l.lazyReset()
+	l.to(l.rel(x, 0))
+	l.m.RelHLineTo(x)
+}
+
+func (l *Linter) AbsVLineTo(y float32) {
+	l.lazyReset()
+	l.to(point{l.pen.x, float64(y)})
+	l.m.AbsVLineTo(y)
+}
+
+func (l *Linter) RelVLineTo(y float32) {
+	l.lazyReset()
+	l.to(l.rel(0, y))
+	l.m.RelVLineTo(y)
+}
+
+func (l *Linter) AbsLineTo(x, y float32) {
+	l.lazyReset()
+	l.to(point{float64(x), float64(y)})
+	l.m.AbsLineTo(x, y)
+}
+
+func (l *Linter) RelLineTo(x, y float32) {
+	l.lazyReset()
+	l.to(l.rel(x, y))
+	l.m.RelLineTo(x, y)
+}
+
+// The implicit control point of a smooth curve is the reflection of the
+// previous control point about the pen, which lies on the line of the
+// subpath when the previous control point does, so it is not added.
+
+func (l *Linter) AbsSmoothQuadTo(x, y float32) {
+	l.lazyReset()
+	l.to(point{float64(x), float64(y)})
+	l.m.AbsSmoothQuadTo(x, y)
+}
+
+func (l *Linter) RelSmoothQuadTo(x, y float32) {
+	l.lazyReset()
+	l.to(l.rel(x, y))
+	l.m.RelSmoothQuadTo(x, y)
+}
+
+func (l *Linter) AbsQuadTo(x1, y1, x, y float32) {
+	l.lazyReset()
+	l.add(point{float64(x1), float64(y1)})
+	l.to(point{float64(x), float64(y)})
+	l.m.AbsQuadTo(x1, y1, x, y)
+}
+
+func (l *Linter) RelQuadTo(x1, y1, x, y float32) {
+	l.lazyReset()
+	l.add(l.rel(x1, y1))
+	l.to(l.rel(x, y))
+	l.m.RelQuadTo(x1, y1, x, y)
+}
+
+func (l *Linter) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	l.lazyReset()
+	l.add(point{float64(x2), float64(y2)})
+	l.to(point{float64(x), float64(y)})
+	l.m.AbsSmoothCubeTo(x2, y2, x, y)
+}
+
+func (l *Linter) RelSmoothCubeTo(x2, y2, x, y float32) {
+	l.lazyReset()
+	l.add(l.rel(x2, y2))
+	l.to(l.rel(x, y))
+	l.m.RelSmoothCubeTo(x2, y2, x, y)
+}
+
+func (l *Linter) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	l.lazyReset()
+	l.add(point{float64(x1), float64(y1)})
+	l.add(point{float64(x2), float64(y2)})
+	l.to(point{float64(x), float64(y)})
+	l.m.AbsCubeTo(x1, y1, x2, y2, x, y)
+}
+
+func (l *Linter) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	l.lazyReset()
+	l.add(l.rel(x1, y1))
+	l.add(l.rel(x2, y2))
+	l.to(l.rel(x, y))
+	l.m.RelCubeTo(x1, y1, x2, y2, x, y)
+}
+
+func (l *Linter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	l.lazyReset()
+	l.arcTo(rx, ry, point{float64(x), float64(y)})
+	l.m.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+}
+
+func (l *Linter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	l.lazyReset()
+	l.arcTo(rx, ry, l.rel(x, y))
+	l.m.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+}
+
+func (l *Linter) rel(x, y float32) point {
+	return point{l.pen.x + float64(x), l.pen.y + float64(y)}
+}
+
+// moveTo starts a subpath at p.
+func (l *Linter) moveTo(p point) {
+	l.pathArea = l.pathArea || l.area
+	l.nLine, l.area = 0, false
+	l.first = p
+	l.to(p)
+}
+
+// to adds p as the end point of a segment.
+func (l *Linter) to(p point) {
+	l.add(p)
+	l.pen = p
+}
+
+// arcTo adds an arc to p, which bulges off the line between the pen and p,
+// unless a radius is zero and it is that line, or p is the pen and the arc is
+// left out.
+func (l *Linter) arcTo(rx, ry float32, p point) {
+	if rx != 0 && ry != 0 && p != l.pen {
+		l.area = true
+	}
+	l.to(p)
+}
+
+// add adds the point p of the subpath, which has area when p does not lie on
+// the line of the earlier points.
+func (l *Linter) add(p point) {
+	switch {
+	case l.area:
+	case l.nLine == 0:
+		l.line[0], l.nLine = p, 1
+	case l.nLine == 1:
+		if p != l.line[0] {
+			l.line[1], l.nLine = p, 2
+		}
+	default:
+		a, b := l.line[0], l.line[1]
+		l.area = (b.x-a.x)*(p.y-a.y) != (b.y-a.y)*(p.x-a.x)
+	}
+}
@@ -0,0 +1,255 @@
+package lint
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/encode"
+)
+
+var (
+	red  = ivg.RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff})
+	blue = ivg.RGBAColor(color.RGBA{0x00, 0x00, 0xff, 0xff})
+)
+
+// square adds a path that is a square from (x0, y0) to (x1, y1).
+func square(d ivg.Destination, x0, y0, x1, y1 float32) {
+	d.StartPath(0, x0, y0)
+	d.AbsHLineTo(x1)
+	d.AbsVLineTo(y1)
+	d.AbsHLineTo(x0)
+	d.ClosePathEndPath()
+}
+
+type want struct {
+	check Check
+	path  int
+}
+
+func TestLinter(t *testing.T) {
+	testCases := []struct {
+		name  string
+		paths func(d ivg.Destination)
+		want  []want
+	}{{
+		name: "clean",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, red)
+			square(d, -16, -16, 16, 16)
+		},
+	}, {
+		name: "default palette",
+		paths: func(d ivg.Destination) {
+			square(d, -16, -16, 16, 16)
+		},
+	}, {
+		name: "bleed",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, red)
+			square(d, -16, -16, 16, 16)
+			square(d, 16, 16, 40, 40)
+		},
+		want: []want{{Bleed, 1}},
+	}, {
+		name: "invalid color",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, ivg.RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0x80}))
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{InvalidColor, 0}},
+	}, {
+		name: "invalid blend",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(1, false, ivg.RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0x80}))
+			d.SetCReg(0, false, ivg.BlendColor(0x40, 0xff, 0x80))
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{InvalidColor, 0}},
+	}, {
+		name: "gradient",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, true, ivg.RGBAColor(ivg.EncodeGradient(10, 10, 0, 0, 2)))
+			d.SetCSel(10)
+			d.SetCReg(0, true, red)
+			d.SetCReg(0, true, blue)
+			d.SetNSel(10)
+			d.SetNReg(0, true, 0)
+			d.SetNReg(0, true, 1)
+			d.SetCSel(0)
+			square(d, -16, -16, 16, 16)
+		},
+	}, {
+		name: "gradient stops",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, true, ivg.RGBAColor(ivg.EncodeGradient(10, 10, 0, 0, 3)))
+			d.SetCReg(0, true, ivg.RGBAColor(ivg.EncodeGradient(10, 10, 0, 0, 1)))
+			d.SetCSel(10)
+			d.SetCReg(0, true, red)
+			d.SetCReg(0, true, ivg.RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0x80}))
+			d.SetCReg(0, true, blue)
+			d.SetNSel(10)
+			d.SetNReg(0, true, 0)
+			d.SetNReg(0, true, 0.75)
+			d.SetNReg(0, true, 0.5)
+			d.SetCSel(0)
+			square(d, -16, -16, 16, 16)
+			d.SetCSel(1)
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{InvalidColor, 0}, {GradientStops, 0}, {GradientStops, 1}},
+	}, {
+		name: "unreachable lod",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, red)
+			d.SetLOD(0, 64)
+			square(d, -16, -16, 16, 16)
+			d.SetLOD(64, 64)
+			square(d, -16, -16, 16, 16)
+			d.SetLOD(-8, 0)
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{UnreachableLOD, 1}, {UnreachableLOD, 2}},
+	}, {
+		name: "unused creg",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, blue)
+			d.SetCReg(0, false, red)
+			d.SetCReg(1, false, red)
+			// CREG[62] is used through a blend.
+			d.SetCReg(2, false, blue)
+			d.SetCReg(3, false, ivg.BlendColor(0x80, 0xfe, 0xc0))
+			d.SetCSel(61)
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{UnusedCReg, -1}, {UnusedCReg, -1}},
+	}, {
+		name: "zero area",
+		paths: func(d ivg.Destination) {
+			d.SetCReg(0, false, red)
+			d.StartPath(0, 0, 0)
+			d.AbsLineTo(8, 8)
+			d.RelCubeTo(2, 2, -12, -12, 8, 8)
+			d.ClosePathEndPath()
+			d.StartPath(0, 0, 0)
+			d.AbsHLineTo(8)
+			d.ClosePathRelMoveTo(0, 8)
+			d.AbsHLineTo(8)
+			d.AbsHLineTo(0)
+			d.ClosePathEndPath()
+			// A path with a subpath that has area.
+			d.StartPath(0, 0, 0)
+			d.AbsHLineTo(8)
+			d.ClosePathRelMoveTo(0, 8)
+			d.AbsLineTo(8, 8)
+			d.AbsQuadTo(4, 12, 0, 8)
+			d.ClosePathEndPath()
+			d.StartPath(0, 0, 0)
+			d.AbsArcTo(4, 4, 0, false, true, 8, 0)
+			d.ClosePathEndPath()
+		},
+		want: []want{{ZeroArea, 0}, {ZeroArea, 1}},
+	}, {
+		name: "unused palette",
+		paths: func(d ivg.Destination) {
+			palette := ivg.DefaultPalette
+			palette[0] = color.RGBA{0xff, 0x00, 0x00, 0xff}
+			d.Reset(ivg.DefaultViewBox, palette)
+			d.SetCReg(0, false, blue)
+			square(d, -16, -16, 16, 16)
+		},
+		want: []want{{UnusedPalette, -1}},
+	}, {
+		name: "palette",
+		paths: func(d ivg.Destination) {
+			palette := ivg.DefaultPalette
+			palette[0] = color.RGBA{0xff, 0x00, 0x00, 0xff}
+			d.Reset(ivg.DefaultViewBox, palette)
+			d.SetCReg(0, false, ivg.BlendColor(0x80, 0xc0, 0x80))
+			square(d, -16, -16, 16, 16)
+		},
+	}}
+	for _, tc := range testCases {
+		var l Linter
+		tc.paths(&l)
+		var got []want
+		for _, p := range l.Problems() {
+			got = append(got, want{p.Check, p.Path})
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, l.Problems(), tc.want)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	var e encode.Encoder
+	e.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	e.SetCReg(0, false, red)
+	e.SetCReg(0, false, blue)
+	square(&e, 16, 16, 40, 40)
+	data, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := Lint(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Check
+	for _, p := range problems {
+		got = append(got, p.Check)
+	}
+	if want := []Check{Bleed, UnusedCReg}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", problems, want)
+	}
+	if _, err := Lint(data[:3]); err == nil {
+		t.Error("truncated graphic: got no error")
+	}
+
+	// The gradient testdata file can be encoded in fewer bytes.
+	data, err = os.ReadFile(filepath.FromSlash("../testdata/gradient.ivg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err = Lint(data); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(problems); n == 0 || problems[n-1].Check != LongEncoding {
+		t.Errorf("gradient: got %v, want %s last", problems, LongEncoding)
+	}
+}
+
+// TestTestdata tests that the testdata files have no problems other than
+// those that they are known to have.
+func TestTestdata(t *testing.T) {
+	for _, filename := range []string{
+		"../testdata/action-info.lores",
+		"../testdata/action-info.hires",
+		"../testdata/arcs",
+		"../testdata/blank",
+		"../testdata/cowbell",
+		"../testdata/elliptical",
+		"../testdata/favicon",
+		"../testdata/gradient",
+		"../testdata/lod-polygon",
+		"../testdata/video-005.primitive",
+	} {
+		data, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems, err := Lint(data)
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		for _, p := range problems {
+			if p.Check != Bleed && p.Check != LongEncoding {
+				t.Errorf("%s: %v", filename, p)
+			}
+		}
+	}
+}
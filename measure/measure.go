@@ -0,0 +1,477 @@
+// Package measure computes the geometry of IconVG graphics: the tight
+// bounding box of the paths that are drawn, and their number of paths and
+// segments, per path and in total.
+//
+// The bounding boxes are those of the exact geometry of the paths, so they
+// include the extrema of the curves and the arcs between their end points,
+// but not their control points. They are in the coordinates of the viewBox,
+// like the coordinates of the paths themselves.
+package measure
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+)
+
+var positiveInfinity = math.Float32frombits(0x7f800000)
+
+// Rect is a rectangle in the coordinates of the viewBox. It is empty when its
+// minimum is greater than its maximum, like that of a path without segments.
+// A single point has a rectangle that is not empty.
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float32
+}
+
+// emptyRect is the empty rectangle that any point extends.
+var emptyRect = Rect{positiveInfinity, positiveInfinity, -positiveInfinity, -positiveInfinity}
+
+// Empty reports whether r contains no points.
+func (r Rect) Empty() bool {
+	return !(r.MinX <= r.MaxX && r.MinY <= r.MaxY)
+}
+
+// Union returns the smallest rectangle that contains both r and s.
+func (r Rect) Union(s Rect) Rect {
+	if r.Empty() {
+		return s
+	}
+	if s.Empty() {
+		return r
+	}
+	if s.MinX < r.MinX {
+		r.MinX = s.MinX
+	}
+	if s.MinY < r.MinY {
+		r.MinY = s.MinY
+	}
+	if s.MaxX > r.MaxX {
+		r.MaxX = s.MaxX
+	}
+	if s.MaxY > r.MaxY {
+		r.MaxY = s.MaxY
+	}
+	return r
+}
+
+// In reports whether r lies within the viewBox vb, which is what an empty
+// rectangle does.
+func (r Rect) In(vb ivg.ViewBox) bool {
+	return r.Empty() || vb.MinX <= r.MinX && r.MaxX <= vb.MaxX && vb.MinY <= r.MinY && r.MaxY <= vb.MaxY
+}
+
+// ViewBox returns r as a viewBox, which crops a graphic to r.
+func (r Rect) ViewBox() ivg.ViewBox {
+	return ivg.ViewBox{MinX: r.MinX, MinY: r.MinY, MaxX: r.MaxX, MaxY: r.MaxY}
+}
+
+// Layer is the geometry of a path of a graphic.
+type Layer struct {
+	// Bounds is the bounding box of the path, also when it is not enabled.
+	Bounds Rect
+	// Segments is the number of lines, curves and arcs of the path. Its
+	// start and the closing of its subpaths are not segments.
+	Segments int
+	// Enabled is whether the path is drawn: whether its level of detail
+	// includes the height, and its color is valid and not transparent.
+	Enabled bool
+}
+
+// Metrics is the geometry of a graphic, at a height in pixels.
+type Metrics struct {
+	ViewBox ivg.ViewBox
+	// Bounds is the bounding box of the enabled paths.
+	Bounds Rect
+	// Paths and Segments are the number of enabled paths and their
+	// segments.
+	Paths    int
+	Segments int
+	// Layers are all the paths, in the order in which they are drawn.
+	Layers []Layer
+}
+
+// Bleeds reports whether the enabled paths extend beyond the viewBox.
+func (m *Metrics) Bleeds() bool {
+	return !m.Bounds.In(m.ViewBox)
+}
+
+// Measure decodes the IconVG graphic src and returns its geometry when it is
+// rendered height pixels high. The height selects the paths by their level
+// of detail, like a render.Renderer does.
+func Measure(src []byte, height float32, opts ...decode.DecodeOption) (Metrics, error) {
+	m := Measurer{Height: height}
+	if err := decode.Decode(&m, src, opts...); err != nil {
+		return Metrics{}, err
+	}
+	return m.Metrics(), nil
+}
+
+// Measurer is an ivg.Destination that measures the geometry of the graphic
+// sent to it.
+//
+// The zero value is usable, and measures at a height of 0 pixels, which
+// includes the paths at the default level of detail.
+type Measurer struct {
+	// Height is the height in pixels that selects the paths by their level
+	// of detail.
+	Height float32
+
+	metrics Metrics
+	reset   bool
+	palette [64]color.RGBA
+	cReg    [64]color.RGBA
+	cSel    uint8
+	nSel    uint8
+	lod0    float32
+	lod1    float32
+
+	// layer is the current path.
+	layer Layer
+	// pen is the current point and start that of the current subpath, and
+	// prevSmoothType and prevSmoothPoint are the kind and last control point
+	// of the previous segment, for the implicit control point of smooth
+	// curves.
+	pen, start      point
+	prevSmoothType  uint8
+	prevSmoothPoint point
+}
+
+const (
+	smoothTypeNone = iota
+	smoothTypeQuad
+	smoothTypeCube
+)
+
+type point struct{ x, y float64 }
+
+// Metrics returns the geometry of the paths sent so far.
+func (m *Measurer) Metrics() Metrics {
+	m.lazyReset()
+	return m.metrics
+}
+
+// Reset resets the Measurer for the given Metadata.
+func (m *Measurer) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	*m = Measurer{
+		Height:  m.Height,
+		metrics: Metrics{ViewBox: viewbox, Bounds: emptyRect},
+		reset:   true,
+		palette: palette,
+		cReg:    palette,
+		lod1:    positiveInfinity,
+	}
+}
+
+func (m *Measurer) lazyReset() {
+	if !m.reset {
+		m.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	}
+}
+
+func (m *Measurer) CSel() uint8 { return m.cSel }
+func (m *Measurer) NSel() uint8 { return m.nSel }
+
+func (m *Measurer) SetCSel(cSel uint8) {
+	m.lazyReset()
+	m.cSel = cSel & 0x3f
+}
+
+func (m *Measurer) SetNSel(nSel uint8) {
+	m.lazyReset()
+	m.nSel = nSel & 0x3f
+}
+
+func (m *Measurer) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	m.lazyReset()
+	m.cReg[(m.cSel-adj)&0x3f] = c.Resolve(&m.palette, &m.cReg)
+	if incr {
+		m.cSel = (m.cSel + 1) & 0x3f
+	}
+}
+
+func (m *Measurer) SetNReg(adj uint8, incr bool, f float32) {
+	m.lazyReset()
+	if incr {
+		m.nSel = (m.nSel + 1) & 0x3f
+	}
+}
+
+func (m *Measurer) SetLOD(lod0, lod1 float32) {
+	m.lazyReset()
+	m.lod0, m.lod1 = lod0, lod1
+}
+
+func (m *Measurer) StartPath(adj uint8, x, y float32) {
+	m.lazyReset()
+	c := m.cReg[(m.cSel-adj)&0x3f]
+	visible := ivg.ValidAlphaPremulColor(c) && c.A != 0 || ivg.ValidGradient(c)
+	m.layer = Layer{
+		Bounds:  emptyRect,
+		Enabled: visible && m.lod0 <= m.Height && m.Height < m.lod1,
+	}
+	m.moveTo(point{float64(x), float64(y)})
+}
+
+func (m *Measurer) ClosePathEndPath() {
+	l := m.layer
+	m.metrics.Layers = append(m.metrics.Layers, l)
+	if l.Enabled {
+		m.metrics.Bounds = m.metrics.Bounds.Union(l.Bounds)
+		m.metrics.Paths++
+		m.metrics.Segments += l.Segments
+	}
+}
+
+func (m *Measurer) ClosePathAbsMoveTo(x, y float32) {
+	m.moveTo(point{float64(x), float64(y)})
+}
+
+func (m *Measurer) ClosePathRelMoveTo(x, y float32) {
+	// Closing the subpath moves the pen back to its start.
+	m.moveTo(point{m.start.x + float64(x), m.start.y + float64(y)})
+}
+
+func (m *Measurer) AbsHLineTo(x float32) { m.lineTo(point{float64(x), m.pen.y}) }
+func (m *Measurer) RelHLineTo(x float32) { m.lineTo(m.rel(x, 0)) }
+func (m *Measurer) AbsVLineTo(y float32) { m.lineTo(point{m.pen.x, float64(y)}) }
+func (m *Measurer) RelVLineTo(y float32) { m.lineTo(m.rel(0, y)) }
+
+func (m *Measurer) AbsLineTo(x, y float32) { m.lineTo(point{float64(x), float64(y)}) }
+func (m *Measurer) RelLineTo(x, y float32) { m.lineTo(m.rel(x, y)) }
+
+func (m *Measurer) AbsSmoothQuadTo(x, y float32) {
+	m.quadTo(m.implicitSmoothPoint(smoothTypeQuad), point{float64(x), float64(y)})
+}
+
+func (m *Measurer) RelSmoothQuadTo(x, y float32) {
+	m.quadTo(m.implicitSmoothPoint(smoothTypeQuad), m.rel(x, y))
+}
+
+func (m *Measurer) AbsQuadTo(x1, y1, x, y float32) {
+	m.quadTo(point{float64(x1), float64(y1)}, point{float64(x), float64(y)})
+}
+
+func (m *Measurer) RelQuadTo(x1, y1, x, y float32) {
+	m.quadTo(m.rel(x1, y1), m.rel(x, y))
+}
+
+func (m *Measurer) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	m.cubeTo(m.implicitSmoothPoint(smoothTypeCube), point{float64(x2), float64(y2)}, point{float64(x), float64(y)})
+}
+
+func (m *Measurer) RelSmoothCubeTo(x2, y2, x, y float32) {
+	m.cubeTo(m.implicitSmoothPoint(smoothTypeCube), m.rel(x2, y2), m.rel(x, y))
+}
+
+func (m *Measurer) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	m.cubeTo(point{float64(x1), float64(y1)}, point{float64(x2), float64(y2)}, point{float64(x), float64(y)})
+}
+
+func (m *Measurer) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	m.cubeTo(m.rel(x1, y1), m.rel(x2, y2), m.rel(x, y))
+}
+
+func (m *Measurer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	m.arcTo(rx, ry, xAxisRotation, largeArc, sweep, point{float64(x), float64(y)})
+}
+
+func (m *Measurer) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	m.arcTo(rx, ry, xAxisRotation, largeArc, sweep, m.rel(x, y))
+}
+
+func (m *Measurer) rel(x, y float32) point {
+	return point{m.pen.x + float64(x), m.pen.y + float64(y)}
+}
+
+// implicitSmoothPoint returns the implicit first control point of a smooth
+// curve, which is the reflection of the last control point of the previous
+// segment when that is a curve of the same kind, like in render.Renderer.
+func (m *Measurer) implicitSmoothPoint(thisSmoothType uint8) point {
+	if m.prevSmoothType != thisSmoothType {
+		return m.pen
+	}
+	return point{2*m.pen.x - m.prevSmoothPoint.x, 2*m.pen.y - m.prevSmoothPoint.y}
+}
+
+// add extends the bounds of the current path with p.
+func (m *Measurer) add(p point) {
+	b := &m.layer.Bounds
+	x, y := float32(p.x), float32(p.y)
+	if x < b.MinX {
+		b.MinX = x
+	}
+	if x > b.MaxX {
+		b.MaxX = x
+	}
+	if y < b.MinY {
+		b.MinY = y
+	}
+	if y > b.MaxY {
+		b.MaxY = y
+	}
+}
+
+func (m *Measurer) moveTo(p point) {
+	m.pen, m.start = p, p
+	m.prevSmoothType = smoothTypeNone
+	m.add(p)
+}
+
+// segmentTo ends a segment at p.
+func (m *Measurer) segmentTo(p point, smoothType uint8) {
+	m.pen = p
+	m.prevSmoothType = smoothType
+	m.layer.Segments++
+	m.add(p)
+}
+
+func (m *Measurer) lineTo(p point) {
+	m.segmentTo(p, smoothTypeNone)
+}
+
+func (m *Measurer) quadTo(p1, p point) {
+	p0 := m.pen
+	// The derivative of each coordinate is zero where
+	// t = (p0 - p1) / (p0 - 2*p1 + p2).
+	for _, t := range [2]float64{
+		root1(p0.x-2*p1.x+p.x, p1.x-p0.x),
+		root1(p0.y-2*p1.y+p.y, p1.y-p0.y),
+	} {
+		if 0 < t && t < 1 {
+			s := 1 - t
+			m.add(point{
+				s*s*p0.x + 2*s*t*p1.x + t*t*p.x,
+				s*s*p0.y + 2*s*t*p1.y + t*t*p.y,
+			})
+		}
+	}
+	m.prevSmoothPoint = p1
+	m.segmentTo(p, smoothTypeQuad)
+}
+
+// root1 returns the root of a*t + b, or NaN if there is none.
+func root1(a, b float64) float64 {
+	if a == 0 {
+		return math.NaN()
+	}
+	return -b / a
+}
+
+func (m *Measurer) cubeTo(p1, p2, p point) {
+	p0 := m.pen
+	// The derivative of a coordinate is 3 times a*t² + 2*b*t + c.
+	coeffs := func(v0, v1, v2, v3 float64) (a, b, c float64) {
+		return -v0 + 3*v1 - 3*v2 + v3, v0 - 2*v1 + v2, v1 - v0
+	}
+	var ts [4]float64
+	ax, bx, cx := coeffs(p0.x, p1.x, p2.x, p.x)
+	ay, by, cy := coeffs(p0.y, p1.y, p2.y, p.y)
+	ts[0], ts[1] = roots2(ax, 2*bx, cx)
+	ts[2], ts[3] = roots2(ay, 2*by, cy)
+	for _, t := range ts {
+		if 0 < t && t < 1 {
+			s := 1 - t
+			m.add(point{
+				s*s*s*p0.x + 3*s*s*t*p1.x + 3*s*t*t*p2.x + t*t*t*p.x,
+				s*s*s*p0.y + 3*s*s*t*p1.y + 3*s*t*t*p2.y + t*t*t*p.y,
+			})
+		}
+	}
+	m.prevSmoothPoint = p2
+	m.segmentTo(p, smoothTypeCube)
+}
+
+// roots2 returns the real roots of a*t² + b*t + c, or NaN for the roots that
+// do not exist.
+func roots2(a, b, c float64) (t0, t1 float64) {
+	if a == 0 {
+		return root1(b, c), math.NaN()
+	}
+	d := b*b - 4*a*c
+	if d < 0 {
+		return math.NaN(), math.NaN()
+	}
+	// Avoid the cancellation of -b + sqrt(d) when b is about sqrt(d).
+	q := -0.5 * (b + math.Copysign(math.Sqrt(d), b))
+	if q == 0 {
+		return 0, math.NaN()
+	}
+	return q / a, c / q
+}
+
+// arcTo adds the elliptical arc from the pen to p, which is what the arc
+// parameters describe after the "Conversion from endpoint to center
+// parameterization" of https://www.w3.org/TR/SVG/implnote.html, with the same
+// corrections as render.Renderer.
+func (m *Measurer) arcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, p point) {
+	p0 := m.pen
+	Rx, Ry := math.Abs(float64(rx)), math.Abs(float64(ry))
+	if !(Rx > 0 && Ry > 0) || p0 == p {
+		m.lineTo(p)
+		return
+	}
+	phi := 2 * math.Pi * float64(xAxisRotation)
+	sinPhi, cosPhi := math.Sincos(phi)
+
+	// Step 1: Compute (x1′, y1′).
+	halfDx, halfDy := (p0.x-p.x)/2, (p0.y-p.y)/2
+	x1Prime := +cosPhi*halfDx + sinPhi*halfDy
+	y1Prime := -sinPhi*halfDx + cosPhi*halfDy
+
+	// Step 2: Compute (cx′, cy′), with radii that are large enough.
+	if check := x1Prime*x1Prime/(Rx*Rx) + y1Prime*y1Prime/(Ry*Ry); check > 1 {
+		c := math.Sqrt(check)
+		Rx, Ry = Rx*c, Ry*c
+	}
+	rxSq, rySq := Rx*Rx, Ry*Ry
+	denom := rxSq*y1Prime*y1Prime + rySq*x1Prime*x1Prime
+	step2 := 0.0
+	if a := rxSq*rySq/denom - 1; a > 0 {
+		step2 = math.Sqrt(a)
+	}
+	if largeArc == sweep {
+		step2 = -step2
+	}
+	cxPrime := +step2 * Rx * y1Prime / Ry
+	cyPrime := -step2 * Ry * x1Prime / Rx
+
+	// Step 3: Compute (cx, cy) from (cx′, cy′).
+	cx := +cosPhi*cxPrime - sinPhi*cyPrime + (p0.x+p.x)/2
+	cy := +sinPhi*cxPrime + cosPhi*cyPrime + (p0.y+p.y)/2
+
+	// Step 4: Compute θ1 and Δθ.
+	theta1 := math.Atan2((y1Prime-cyPrime)/Ry, (x1Prime-cxPrime)/Rx)
+	theta2 := math.Atan2((-y1Prime-cyPrime)/Ry, (-x1Prime-cxPrime)/Rx)
+	deltaTheta := theta2 - theta1
+	if sweep && deltaTheta < 0 {
+		deltaTheta += 2 * math.Pi
+	} else if !sweep && deltaTheta > 0 {
+		deltaTheta -= 2 * math.Pi
+	}
+
+	// The point at θ is (cx + Rx*cosφ*cosθ - Ry*sinφ*sinθ, cy + Rx*sinφ*cosθ
+	// + Ry*cosφ*sinθ), whose x and y are extreme where their derivatives
+	// are zero, at θx and θy and half a turn further.
+	thetaX := math.Atan2(-Ry*sinPhi, Rx*cosPhi)
+	thetaY := math.Atan2(Ry*cosPhi, Rx*sinPhi)
+	for _, theta := range [4]float64{thetaX, thetaX + math.Pi, thetaY, thetaY + math.Pi} {
+		// The angle from θ1 in the direction of the sweep, in [0, 2π).
+		d := math.Mod(theta-theta1, 2*math.Pi)
+		if deltaTheta < 0 {
+			d = -d
+		}
+		if d < 0 {
+			d += 2 * math.Pi
+		}
+		if d < math.Abs(deltaTheta) {
+			sin, cos := math.Sincos(theta)
+			m.add(point{
+				cx + Rx*cosPhi*cos - Ry*sinPhi*sin,
+				cy + Rx*sinPhi*cos + Ry*cosPhi*sin,
+			})
+		}
+	}
+	m.lineTo(p)
+}
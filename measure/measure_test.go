@@ -0,0 +1,231 @@
+package measure
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+var testdataFilenames = []string{
+	"../testdata/action-info.lores",
+	"../testdata/action-info.hires",
+	"../testdata/arcs",
+	"../testdata/blank",
+	"../testdata/cowbell",
+	"../testdata/elliptical",
+	"../testdata/favicon",
+	"../testdata/gradient",
+	"../testdata/lod-polygon",
+	"../testdata/video-005.primitive",
+}
+
+func nearlyEqual(r, s Rect) bool {
+	eq := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-4 }
+	return eq(r.MinX, s.MinX) && eq(r.MinY, s.MinY) && eq(r.MaxX, s.MaxX) && eq(r.MaxY, s.MaxY)
+}
+
+func TestBounds(t *testing.T) {
+	// a is the half width and height of an ellipse with radii 10 and 5 that
+	// is rotated by 45°.
+	a := float32(math.Sqrt(10*10/2.0 + 5*5/2.0))
+	// c is where the ellipse crosses its major axis.
+	c := float32(10 / math.Sqrt2)
+	testCases := []struct {
+		name string
+		path func(m *Measurer)
+		want Rect
+	}{{
+		name: "quad",
+		path: func(m *Measurer) { m.AbsQuadTo(10, 20, 20, 0) },
+		want: Rect{0, 0, 20, 10},
+	}, {
+		name: "smooth quad",
+		path: func(m *Measurer) {
+			m.AbsQuadTo(10, 20, 20, 0)
+			m.RelSmoothQuadTo(20, 0)
+		},
+		want: Rect{0, -10, 40, 10},
+	}, {
+		name: "cube",
+		path: func(m *Measurer) { m.RelCubeTo(0, 10, 10, 10, 10, 0) },
+		want: Rect{0, 0, 10, 7.5},
+	}, {
+		name: "smooth cube",
+		path: func(m *Measurer) {
+			m.AbsCubeTo(0, 10, 10, 10, 10, 0)
+			m.AbsSmoothCubeTo(20, -10, 20, 0)
+		},
+		want: Rect{0, -7.5, 20, 7.5},
+	}, {
+		name: "smooth cube after quad",
+		path: func(m *Measurer) {
+			m.AbsQuadTo(10, 20, 20, 0)
+			m.AbsSmoothCubeTo(20, 0, 20, 0)
+		},
+		want: Rect{0, 0, 20, 10},
+	}, {
+		name: "half circle",
+		path: func(m *Measurer) {
+			m.AbsLineTo(-5, 0)
+			m.AbsArcTo(5, 5, 0, false, true, 5, 0)
+		},
+		want: Rect{-5, -5, 5, 0},
+	}, {
+		name: "half circle the other way",
+		path: func(m *Measurer) {
+			m.AbsLineTo(-5, 0)
+			m.RelArcTo(5, 5, 0, false, false, 10, 0)
+		},
+		want: Rect{-5, 0, 5, 5},
+	}, {
+		name: "small radii",
+		path: func(m *Measurer) {
+			m.AbsLineTo(-5, 0)
+			m.AbsArcTo(1, 1, 0, true, true, 5, 0)
+		},
+		want: Rect{-5, -5, 5, 0},
+	}, {
+		name: "rotated ellipse",
+		path: func(m *Measurer) {
+			m.AbsLineTo(c, c)
+			m.AbsArcTo(10, 5, 0.125, false, true, -c, -c)
+			m.AbsArcTo(10, 5, 0.125, false, true, c, c)
+		},
+		want: Rect{-a, -a, a, a},
+	}, {
+		name: "arc without radius",
+		path: func(m *Measurer) { m.AbsArcTo(0, 5, 0, false, true, 5, 3) },
+		want: Rect{0, 0, 5, 3},
+	}, {
+		name: "lines",
+		path: func(m *Measurer) {
+			m.RelHLineTo(3)
+			m.RelVLineTo(-4)
+			m.ClosePathRelMoveTo(-2, 1)
+			m.AbsHLineTo(-1)
+			m.AbsVLineTo(6)
+		},
+		want: Rect{-2, -4, 3, 6},
+	}}
+	for _, tc := range testCases {
+		var m Measurer
+		m.StartPath(0, 0, 0)
+		tc.path(&m)
+		m.ClosePathEndPath()
+		got := m.Metrics()
+		if !nearlyEqual(got.Bounds, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got.Bounds, tc.want)
+		}
+		if got.Paths != 1 || len(got.Layers) != 1 || got.Layers[0].Bounds != got.Bounds {
+			t.Errorf("%s: got %d paths and layers %v", tc.name, got.Paths, got.Layers)
+		}
+	}
+}
+
+func TestLayers(t *testing.T) {
+	path := func(m *Measurer, x, y float32) {
+		m.StartPath(0, x, y)
+		m.RelLineTo(1, 1)
+		m.RelLineTo(-1, 1)
+		m.ClosePathEndPath()
+	}
+	graphic := func(m *Measurer) {
+		m.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+		m.SetLOD(0, 32)
+		path(m, -10, -10)
+		m.SetLOD(32, float32(math.Inf(1)))
+		path(m, 40, 40)
+		m.SetLOD(0, float32(math.Inf(1)))
+		m.SetCReg(0, false, ivg.RGBAColor(color.RGBA{}))
+		path(m, 0, 0)
+		m.SetCReg(0, false, ivg.RGBAColor(color.RGBA{0x40, 0, 0, 0x40}))
+		path(m, 5, 5)
+	}
+
+	m := Measurer{Height: 16}
+	graphic(&m)
+	got := m.Metrics()
+	if want := (Rect{-10, -10, 6, 7}); got.Bounds != want {
+		t.Errorf("height 16: Bounds: got %v, want %v", got.Bounds, want)
+	}
+	if got.Paths != 2 || got.Segments != 4 || len(got.Layers) != 4 {
+		t.Errorf("height 16: got %d paths, %d segments and %d layers, want 2, 4 and 4", got.Paths, got.Segments, len(got.Layers))
+	}
+	for i, want := range []bool{true, false, false, true} {
+		if l := got.Layers[i]; l.Enabled != want || l.Segments != 2 {
+			t.Errorf("height 16: layer %d: got %v", i, l)
+		}
+	}
+	if got.Bleeds() {
+		t.Errorf("height 16: Bleeds: got true, want false")
+	}
+
+	m.Height = 32
+	graphic(&m)
+	got = m.Metrics()
+	if want := (Rect{5, 5, 41, 42}); got.Bounds != want {
+		t.Errorf("height 32: Bounds: got %v, want %v", got.Bounds, want)
+	}
+	if !got.Bleeds() {
+		t.Errorf("height 32: Bleeds: got false, want true")
+	}
+}
+
+// TestRender tests that the bounds of the testdata files contain the pixels
+// that they render, and are no larger than the pixels that they touch.
+func TestRender(t *testing.T) {
+	const length = 256
+	for _, filename := range testdataFilenames {
+		src, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		metrics, err := Measure(src, length)
+		if err != nil {
+			t.Fatalf("%s: Measure: %v", filename, err)
+		}
+
+		dst := image.NewAlpha(image.Rect(0, 0, length, length))
+		var z render.Renderer
+		z.SetRasterizer(img.NewRasterizer(dst), dst.Bounds())
+		if err := decode.Decode(&z, src); err != nil {
+			t.Fatalf("%s: Decode: %v", filename, err)
+		}
+		touched := image.Rectangle{}
+		for y := 0; y < length; y++ {
+			for x := 0; x < length; x++ {
+				if dst.AlphaAt(x, y).A != 0 {
+					touched = touched.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+
+		// The pixels that the bounds touch.
+		vb := metrics.ViewBox
+		dx, dy := vb.Size()
+		b := metrics.Bounds
+		bounds := image.Rect(
+			int(math.Floor(float64((b.MinX-vb.MinX)*length/dx))),
+			int(math.Floor(float64((b.MinY-vb.MinY)*length/dy))),
+			int(math.Ceil(float64((b.MaxX-vb.MinX)*length/dx))),
+			int(math.Ceil(float64((b.MaxY-vb.MinY)*length/dy))),
+		).Intersect(dst.Bounds())
+		if b.Empty() {
+			bounds = image.Rectangle{}
+		}
+		if !touched.In(bounds) {
+			t.Errorf("%s: rendered pixels %v are not in the bounds %v", filename, touched, bounds)
+		}
+		if !bounds.In(touched.Inset(-1)) {
+			t.Errorf("%s: bounds %v are larger than the rendered pixels %v", filename, bounds, touched)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Package optimize re-encodes IconVG graphics in fewer bytes.
+//
+// The encoding it produces draws the same graphic: every path has the same
+// geometry and the same fill, at the same level of detail, whatever palette
+// the graphic is decoded with. Within a path, it picks between the absolute
+// and relative form of each segment, turns lines into horizontal or vertical
+// lines and curves into smooth curves when possible, and groups consecutive
+// segments of the same kind under one opcode. It drops segments that draw
+// nothing, like lines of zero length and lines that end where the closing of
+// the path would take them anyway.
+//
+// For the styling opcodes, it picks the cheapest encoding of each color,
+// including a reference to a color register that is known to hold the same
+// color, and folds blends of known colors into a single color. It drops
+// writes of values that a register already holds, and only sets CSEL and NSEL
+// when a register cannot be reached through a selector adjustment from their
+// current value.
+//
+// Coordinates are kept exactly as decoded; they are not quantized further.
+package optimize
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/encode"
+)
+
+var positiveInfinity = math.Float32frombits(0x7f800000)
+
+// Optimize decodes the IconVG graphic src and returns the smallest encoding of
+// the same graphic that it finds.
+func Optimize(src []byte) ([]byte, error) {
+	var o Optimizer
+	if err := decode.Decode(&o, src); err != nil {
+		return nil, err
+	}
+	return o.Bytes()
+}
+
+// Optimizer is an ivg.Destination that encodes the graphic sent to it in
+// fewer bytes than encode.Encoder would. Paths are encoded when they end.
+//
+// The zero value is usable. Calling Reset, which is optional, sets the
+// Metadata for the subsequent encoded form.
+type Optimizer struct {
+	enc   encode.Encoder
+	reset bool
+
+	// cSel and nSel are the selectors that the graphic sent to the Optimizer
+	// has set, while encCSel and encNSel are the selectors that the encoded
+	// form has set.
+	cSel, encCSel uint8
+	nSel, encNSel uint8
+	lod0, lod1    float32
+
+	// cReg is what is known about the values of the color registers, in the
+	// form of the colors that set them, and nReg holds the values of the
+	// number registers.
+	cReg [64]register
+	nReg [64]float32
+
+	path path
+}
+
+// register is what is known about the value of a color register.
+type register struct {
+	// c is the value of the register when known is true. It is either an
+	// RGBA color or, for a value that depends on the palette that the
+	// graphic is decoded with, a palette index color.
+	c     ivg.Color
+	known bool
+}
+
+// Bytes returns the encoded form.
+func (o *Optimizer) Bytes() ([]byte, error) {
+	o.lazyReset()
+	return o.enc.Bytes()
+}
+
+// Reset resets the Optimizer for the given Metadata.
+func (o *Optimizer) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	*o = Optimizer{enc: o.enc, reset: true, lod1: positiveInfinity}
+	o.enc.Reset(viewbox, palette)
+	// The coordinates sent to the Optimizer are encoded exactly.
+	o.enc.HighResolutionCoordinates = true
+	// The color registers start out holding the palette.
+	for i := range o.cReg {
+		o.cReg[i] = register{ivg.PaletteIndexColor(uint8(i)), true}
+	}
+}
+
+func (o *Optimizer) lazyReset() {
+	if !o.reset {
+		o.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	}
+}
+
+func (o *Optimizer) CSel() uint8 { return o.cSel }
+func (o *Optimizer) NSel() uint8 { return o.nSel }
+
+func (o *Optimizer) SetCSel(cSel uint8) {
+	o.lazyReset()
+	o.cSel = cSel & 0x3f
+}
+
+func (o *Optimizer) SetNSel(nSel uint8) {
+	o.lazyReset()
+	o.nSel = nSel & 0x3f
+}
+
+// selectCReg returns the adjustment that selects CREG[i] in the encoded
+// form, first setting CSEL when CREG[i] cannot be reached from its current
+// value.
+func (o *Optimizer) selectCReg(i uint8) (adj uint8) {
+	if adj := (o.encCSel - i) & 0x3f; adj <= 6 {
+		return adj
+	}
+	o.encCSel = o.cSel
+	o.enc.SetCSel(o.cSel)
+	return (o.encCSel - i) & 0x3f
+}
+
+// selectNReg is like selectCReg, for NREG[i].
+func (o *Optimizer) selectNReg(i uint8) (adj uint8) {
+	if adj := (o.encNSel - i) & 0x3f; adj <= 6 {
+		return adj
+	}
+	o.encNSel = o.nSel
+	o.enc.SetNSel(o.nSel)
+	return (o.encNSel - i) & 0x3f
+}
+
+func (o *Optimizer) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	o.lazyReset()
+	i := (o.cSel - adj) & 0x3f
+	if incr {
+		o.cSel = (o.cSel + 1) & 0x3f
+	}
+	r := o.resolve(c)
+	if r.known && o.cReg[i] == r {
+		return
+	}
+	c = o.cheapest(c, r)
+	o.cReg[i] = r
+	if incr {
+		if adj := (o.encCSel - i) & 0x3f; adj > 6 {
+			o.encCSel = i
+			o.enc.SetCSel(i)
+		}
+		if o.encCSel == i {
+			o.enc.SetCReg(0, true, c)
+			o.encCSel = (o.encCSel + 1) & 0x3f
+			return
+		}
+	}
+	o.enc.SetCReg(o.selectCReg(i), false, c)
+}
+
+func (o *Optimizer) SetNReg(adj uint8, incr bool, f float32) {
+	o.lazyReset()
+	i := (o.nSel - adj) & 0x3f
+	if incr {
+		o.nSel = (o.nSel + 1) & 0x3f
+	}
+	if math.Float32bits(o.nReg[i]) == math.Float32bits(f) {
+		return
+	}
+	o.nReg[i] = f
+	if incr {
+		if adj := (o.encNSel - i) & 0x3f; adj > 6 {
+			o.encNSel = i
+			o.enc.SetNSel(i)
+		}
+		if o.encNSel == i {
+			o.enc.SetNReg(0, true, f)
+			o.encNSel = (o.encNSel + 1) & 0x3f
+			return
+		}
+	}
+	o.enc.SetNReg(o.selectNReg(i), false, f)
+}
+
+func (o *Optimizer) SetLOD(lod0, lod1 float32) {
+	o.lazyReset()
+	if lod0 == o.lod0 && lod1 == o.lod1 {
+		return
+	}
+	o.lod0, o.lod1 = lod0, lod1
+	o.enc.SetLOD(lod0, lod1)
+}
+
+// resolve returns what is known about the value of a register set to c.
+func (o *Optimizer) resolve(c ivg.Color) register {
+	if _, ok := c.Encode4(); ok {
+		return register{c, true}
+	}
+	if x, ok := c.Encode1(); ok {
+		if x >= 0xc0 {
+			return o.cReg[x&0x3f]
+		}
+		return register{c, true}
+	}
+	// A blend of two known RGBA colors is itself a known RGBA color.
+	x, _ := c.Encode3Indirect()
+	c0, c1 := o.resolve(ivg.DecodeColor1(x[1])), o.resolve(ivg.DecodeColor1(x[2]))
+	rgba0, ok0 := c0.c.Encode4()
+	rgba1, ok1 := c1.c.Encode4()
+	if !c0.known || !c1.known || !ok0 || !ok1 {
+		return register{}
+	}
+	var palette, cReg [64]color.RGBA
+	cReg[0] = color.RGBA{rgba0[0], rgba0[1], rgba0[2], rgba0[3]}
+	cReg[1] = color.RGBA{rgba1[0], rgba1[1], rgba1[2], rgba1[3]}
+	return register{ivg.RGBAColor(ivg.BlendColor(x[0], 0xc0, 0xc1).Resolve(&palette, &cReg)), true}
+}
+
+// cheapest returns the color with the shortest encoding that sets a register
+// to r, given the color c that was sent to the Optimizer.
+func (o *Optimizer) cheapest(c ivg.Color, r register) ivg.Color {
+	if !r.known {
+		return c
+	}
+	best, bestCost := c, colorCost(c)
+	if cost := colorCost(r.c); cost < bestCost {
+		best, bestCost = r.c, cost
+	}
+	if bestCost > 1 {
+		for i := range o.cReg {
+			if o.cReg[i] == r {
+				return ivg.CRegColor(uint8(i))
+			}
+		}
+	}
+	return best
+}
+
+// colorCost returns the number of bytes that encode.Encoder encodes c in.
+func colorCost(c ivg.Color) int {
+	if _, ok := c.Encode1(); ok {
+		return 1
+	} else if _, ok := c.Encode2(); ok {
+		return 2
+	} else if _, ok := c.Encode3Direct(); ok {
+		return 3
+	} else if _, ok := c.Encode4(); ok {
+		return 4
+	}
+	return 3
+}
+
+func (o *Optimizer) StartPath(adj uint8, x, y float32) {
+	o.lazyReset()
+	o.enc.StartPath(o.selectCReg((o.cSel-adj)&0x3f), x, y)
+	o.path.start(x, y)
+}
+
+func (o *Optimizer) ClosePathEndPath() {
+	o.path.closePath(nil)
+	o.path.encode(&o.enc)
+	o.enc.ClosePathEndPath()
+}
+
+func (o *Optimizer) ClosePathAbsMoveTo(x, y float32) {
+	o.path.closePath(&point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) ClosePathRelMoveTo(x, y float32) {
+	p := o.path.startPoint.add(point{float64(x), float64(y)})
+	o.path.closePath(&p)
+}
+
+func (o *Optimizer) AbsHLineTo(x float32) {
+	o.path.lineTo(point{float64(x), o.path.pen.y})
+}
+
+func (o *Optimizer) RelHLineTo(x float32) {
+	o.path.lineTo(o.path.pen.add(point{float64(x), 0}))
+}
+
+func (o *Optimizer) AbsVLineTo(y float32) {
+	o.path.lineTo(point{o.path.pen.x, float64(y)})
+}
+
+func (o *Optimizer) RelVLineTo(y float32) {
+	o.path.lineTo(o.path.pen.add(point{0, float64(y)}))
+}
+
+func (o *Optimizer) AbsLineTo(x, y float32) {
+	o.path.lineTo(point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelLineTo(x, y float32) {
+	o.path.lineTo(o.path.rel(x, y))
+}
+
+func (o *Optimizer) AbsSmoothQuadTo(x, y float32) {
+	o.path.quadTo(o.path.implicitSmoothPoint(segmentQuad), point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelSmoothQuadTo(x, y float32) {
+	o.path.quadTo(o.path.implicitSmoothPoint(segmentQuad), o.path.rel(x, y))
+}
+
+func (o *Optimizer) AbsQuadTo(x1, y1, x, y float32) {
+	o.path.quadTo(point{float64(x1), float64(y1)}, point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelQuadTo(x1, y1, x, y float32) {
+	o.path.quadTo(o.path.rel(x1, y1), o.path.rel(x, y))
+}
+
+func (o *Optimizer) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	o.path.cubeTo(o.path.implicitSmoothPoint(segmentCube), point{float64(x2), float64(y2)}, point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelSmoothCubeTo(x2, y2, x, y float32) {
+	o.path.cubeTo(o.path.implicitSmoothPoint(segmentCube), o.path.rel(x2, y2), o.path.rel(x, y))
+}
+
+func (o *Optimizer) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	o.path.cubeTo(point{float64(x1), float64(y1)}, point{float64(x2), float64(y2)}, point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	o.path.cubeTo(o.path.rel(x1, y1), o.path.rel(x2, y2), o.path.rel(x, y))
+}
+
+func (o *Optimizer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	o.path.arcTo(rx, ry, xAxisRotation, largeArc, sweep, point{float64(x), float64(y)})
+}
+
+func (o *Optimizer) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	o.path.arcTo(rx, ry, xAxisRotation, largeArc, sweep, o.path.rel(x, y))
+}
@@ -0,0 +1,159 @@
+package optimize
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg/assemble"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+var testdataFilenames = []string{
+	"../testdata/action-info.lores",
+	"../testdata/action-info.hires",
+	"../testdata/arcs",
+	"../testdata/blank",
+	"../testdata/cowbell",
+	"../testdata/elliptical",
+	"../testdata/favicon",
+	"../testdata/gradient",
+	"../testdata/lod-polygon",
+	"../testdata/video-005.primitive",
+}
+
+func rasterize(t *testing.T, ivgData []byte, length int, opts ...decode.DecodeOption) *image.RGBA {
+	t.Helper()
+	dst := image.NewRGBA(image.Rect(0, 0, length, length))
+	var z render.Renderer
+	z.SetRasterizer(&img.Rasterizer{Dst: dst, DrawOp: draw.Src}, dst.Bounds())
+	if err := decode.Decode(&z, ivgData, opts...); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return dst
+}
+
+// checkNearlyEqual checks that the pixels of m0 and m1 differ by at most 1 in
+// each channel. The renderer adds relative coordinates to the pen in float32
+// pixel space, so the absolute and relative form of a segment can round
+// differently.
+func checkNearlyEqual(m0, m1 *image.RGBA) error {
+	for i := range m0.Pix {
+		if d := int(m0.Pix[i]) - int(m1.Pix[i]); d < -1 || +1 < d {
+			x, y := i%m0.Stride/4, i/m0.Stride
+			return fmt.Errorf("at (%d, %d): got %#02x, want %#02x", x, y, m0.Pix[i], m1.Pix[i])
+		}
+	}
+	return nil
+}
+
+// TestOptimize tests that the optimized form of the testdata files is no
+// larger than the original and renders the same, also with another palette.
+func TestOptimize(t *testing.T) {
+	pink := decode.WithColorAt(0, color.RGBA{0xfe, 0x76, 0xea, 0xff})
+	for _, filename := range testdataFilenames {
+		src, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		got, err := Optimize(src)
+		if err != nil {
+			t.Errorf("%s: Optimize: %v", filename, err)
+			continue
+		}
+		if len(got) > len(src) {
+			t.Errorf("%s: got %d bytes, want at most %d", filename, len(got), len(src))
+		}
+		for _, length := range []int{64, 256} {
+			if err := checkNearlyEqual(rasterize(t, got, length), rasterize(t, src, length)); err != nil {
+				t.Errorf("%s: %d×%d: %v", filename, length, length, err)
+			}
+			if err := checkNearlyEqual(rasterize(t, got, length, pink), rasterize(t, src, length, pink)); err != nil {
+				t.Errorf("%s: %d×%d pink: %v", filename, length, length, err)
+			}
+		}
+		again, err := Optimize(got)
+		if err != nil {
+			t.Errorf("%s: Optimize again: %v", filename, err)
+		} else if len(again) > len(got) {
+			t.Errorf("%s: optimizing again: got %d bytes, want at most %d", filename, len(again), len(got))
+		}
+	}
+}
+
+func TestOptimizeEncodings(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		src, want string
+	}{{
+		desc: "relative coordinates",
+		src:  "start 0 20.5 20.5\nL 21.5 22.5\nL 23.5 21.5\nz\n",
+		want: "start 0 20.5 20.5\nl 1 2 2 -1\nz\n",
+	}, {
+		desc: "horizontal and vertical lines",
+		src:  "start 0 20.5 20.5\nL 30.5 20.5\nL 30.5 30.5\nz\n",
+		want: "start 0 20.5 20.5\nh 10\nv 10\nz\n",
+	}, {
+		desc: "smooth curves",
+		src: "start 0 20.5 20.5\n" +
+			"q 10 0 10 10\nq 0 10 -10 10\n" +
+			"c -10 0 -10 -10 -20 -10\nc -10 0 -10 -10 -20 -10\nz\n",
+		want: "start 0 20.5 20.5\n" +
+			"q 10 0 10 10\nt -10 10\n" +
+			"c -10 0 -10 -10 -20 -10\ns -10 -10 -20 -10\nz\n",
+	}, {
+		desc: "lines that draw nothing",
+		src:  "start 0 20.5 20.5\nl 10 0 0 0 0 10 -10 -10\nz\n",
+		want: "start 0 20.5 20.5\nh 10\nv 10\nz\n",
+	}, {
+		desc: "subpaths",
+		src:  "start 0 20.5 20.5\nl 10 10\nz M 21.5 21.5\nl 10 10\nz\n",
+		want: "start 0 20.5 20.5\nl 10 10\nz m 1 1\nl 10 10\nz\n",
+	}, {
+		desc: "redundant styling",
+		src: "csel 10\ncreg 0 RGBA 336699ff\ncreg 0 RGBA 336699ff\nlod 0 80\nlod 0 80\n" +
+			"start 0 0.5 0.5\nl 10 10\nz\n" +
+			"csel 0\nstart 0 0.5 0.5\nl 10 10\nz\n" +
+			"csel 1\nstart 1 0.5 0.5\nl 10 10\nz\n",
+		want: "csel 10\ncreg 0 RGBA 336699ff\nlod 0 80\n" +
+			"start 0 0.5 0.5\nl 10 10\nz\n" +
+			"csel 0\nstart 0 0.5 0.5\nl 10 10\nz\n" +
+			"start 0 0.5 0.5\nl 10 10\nz\n",
+	}, {
+		desc: "colors held by registers",
+		src:  "creg 0 RGBA 336699ff\ncsel 1\ncreg 0 RGBA 336699ff\n",
+		want: "creg 0 RGBA 336699ff\ncsel 1\ncreg 0 CREG[0]\n",
+	}, {
+		desc: "cheaper colors",
+		src:  "creg 0 RGBA 00000000\ncreg 1 RGBA 336699ff\ncreg 2 blend (127:128) (RGBA 000000ff:RGBA ffffffff)\n",
+		want: "creg 0 RGBA 00000000\ncreg 1 RGBA 336699ff\ncreg 2 RGBA 808080ff\n",
+	}}
+	for _, tc := range testCases {
+		src, err := assemble.Assemble([]byte(tc.src))
+		if err != nil {
+			t.Errorf("%s: Assemble: %v", tc.desc, err)
+			continue
+		}
+		want, err := assemble.Assemble([]byte(tc.want))
+		if err != nil {
+			t.Errorf("%s: Assemble: %v", tc.desc, err)
+			continue
+		}
+		got, err := Optimize(src)
+		if err != nil {
+			t.Errorf("%s: Optimize: %v", tc.desc, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			formatted, _ := assemble.Format(got)
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.desc, formatted, tc.want)
+		}
+	}
+}
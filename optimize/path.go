@@ -0,0 +1,412 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/reactivego/ivg/encode"
+)
+
+type point struct{ x, y float64 }
+
+func (p point) add(q point) point { return point{p.x + q.x, p.y + q.y} }
+func (p point) sub(q point) point { return point{p.x - q.x, p.y - q.y} }
+
+// reflect returns the reflection of q in p.
+func (p point) reflect(q point) point { return point{2*p.x - q.x, 2*p.y - q.y} }
+
+type segmentKind uint8
+
+const (
+	segmentLine segmentKind = iota
+	segmentQuad
+	segmentCube
+	segmentArc
+	// segmentMove closes the path and starts a new one at p[2].
+	segmentMove
+)
+
+// segment is a path segment in absolute coordinates. The end point is p[2],
+// the control point of a quadratic Bézier curve is p[0] and those of a cubic
+// one are p[0] and p[1].
+type segment struct {
+	kind segmentKind
+	p    [3]point
+
+	rx, ry, xAxisRotation float32
+	largeArc, sweep       bool
+}
+
+// path collects the segments of a path, so that they can be encoded once the
+// path is complete. The coordinates are float64s, which hold the sums and
+// differences of the float32 coordinates of a path exactly.
+type path struct {
+	segments []segment
+	// first is the start point of the path and startPoint that of the
+	// current subpath.
+	first      point
+	startPoint point
+	pen        point
+	// prevKind and prevControl are the kind and last control point of the
+	// previous segment, for the implicit control point of smooth curves.
+	prevKind    segmentKind
+	prevControl point
+}
+
+func (p *path) start(x, y float32) {
+	p.segments = p.segments[:0]
+	p.first = point{float64(x), float64(y)}
+	p.startPoint, p.pen = p.first, p.first
+	p.prevKind = segmentLine
+}
+
+func (p *path) rel(x, y float32) point {
+	return p.pen.add(point{float64(x), float64(y)})
+}
+
+// implicitSmoothPoint returns the implicit first control point of a smooth
+// curve of the given kind.
+func (p *path) implicitSmoothPoint(kind segmentKind) point {
+	if p.prevKind != kind {
+		return p.pen
+	}
+	return p.pen.reflect(p.prevControl)
+}
+
+func (p *path) add(s segment) {
+	p.segments = append(p.segments, s)
+	p.pen = s.p[2]
+	p.prevKind = s.kind
+	switch s.kind {
+	case segmentQuad:
+		p.prevControl = s.p[0]
+	case segmentCube:
+		p.prevControl = s.p[1]
+	}
+}
+
+func (p *path) lineTo(q point) {
+	p.add(segment{kind: segmentLine, p: [3]point{2: q}})
+}
+
+func (p *path) quadTo(q1, q point) {
+	p.add(segment{kind: segmentQuad, p: [3]point{q1, {}, q}})
+}
+
+func (p *path) cubeTo(q1, q2, q point) {
+	p.add(segment{kind: segmentCube, p: [3]point{q1, q2, q}})
+}
+
+func (p *path) arcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, q point) {
+	p.add(segment{
+		kind: segmentArc, p: [3]point{2: q},
+		rx: rx, ry: ry, xAxisRotation: xAxisRotation,
+		largeArc: largeArc, sweep: sweep,
+	})
+}
+
+// closePath closes the current path and, if q is not nil, starts a new one
+// at *q.
+func (p *path) closePath(q *point) {
+	if q == nil {
+		// Mark the end of the path like a move to the start point, so that a
+		// line back to the start point can be dropped.
+		p.segments = append(p.segments, segment{kind: segmentMove, p: [3]point{2: p.startPoint}})
+		return
+	}
+	p.segments = append(p.segments, segment{kind: segmentMove, p: [3]point{2: *q}})
+	p.startPoint, p.pen = *q, *q
+	p.prevKind = segmentLine
+}
+
+// op is a drawing opcode and its arguments, one way of encoding a segment.
+type op struct {
+	verb byte
+	args []float64
+	cost int
+}
+
+// maxReps is the number of times that encode.Encoder can repeat each drawing
+// opcode with a single opcode byte.
+var maxReps = [256]int{
+	'L': 32, 'l': 32,
+	'T': 16, 't': 16, 'Q': 16, 'q': 16,
+	'S': 16, 's': 16, 'C': 16, 'c': 16,
+	'A': 16, 'a': 16,
+	'H': 1, 'h': 1, 'V': 1, 'v': 1,
+	'Y': 1, 'y': 1,
+}
+
+// encode encodes the path, except for its start and final closing, which
+// were or will be encoded by the caller. It picks the sequence of opcodes
+// with the smallest total size.
+func (p *path) encode(e *encode.Encoder) {
+	candidates := p.candidates()
+
+	// steps[i] holds, for each state after encoding the first i segments, the
+	// cheapest way to get there.
+	steps := make([]map[state]choice, len(candidates)+1)
+	steps[0] = map[state]choice{{}: {}}
+	for i, ops := range candidates {
+		steps[i+1] = map[state]choice{}
+		for s, c := range steps[i] {
+			for j, o := range ops {
+				next, cost := state{o.verb, 1}, c.cost+o.cost
+				if o.verb == s.verb && s.reps < maxReps[o.verb] {
+					next.reps = s.reps + 1
+				} else {
+					cost++
+				}
+				if best, ok := steps[i+1][next]; !ok || cost < best.cost ||
+					cost == best.cost && s.less(best.prev) {
+					steps[i+1][next] = choice{cost, s, j}
+				}
+			}
+		}
+	}
+
+	// Walk back from the cheapest final state to find the opcodes.
+	var last state
+	var lastChoice choice
+	first := true
+	for s, c := range steps[len(candidates)] {
+		if first || c.cost < lastChoice.cost || c.cost == lastChoice.cost && s.less(last) {
+			last, lastChoice, first = s, c, false
+		}
+	}
+	ops := make([]op, len(candidates))
+	for i := len(candidates); i > 0; i-- {
+		c := steps[i][last]
+		ops[i-1] = candidates[i-1][c.op]
+		last = c.prev
+	}
+
+	for _, o := range ops {
+		a := make([]float32, len(o.args))
+		for i, x := range o.args {
+			a[i] = float32(x)
+		}
+		switch o.verb {
+		case 'L':
+			e.AbsLineTo(a[0], a[1])
+		case 'l':
+			e.RelLineTo(a[0], a[1])
+		case 'H':
+			e.AbsHLineTo(a[0])
+		case 'h':
+			e.RelHLineTo(a[0])
+		case 'V':
+			e.AbsVLineTo(a[0])
+		case 'v':
+			e.RelVLineTo(a[0])
+		case 'T':
+			e.AbsSmoothQuadTo(a[0], a[1])
+		case 't':
+			e.RelSmoothQuadTo(a[0], a[1])
+		case 'Q':
+			e.AbsQuadTo(a[0], a[1], a[2], a[3])
+		case 'q':
+			e.RelQuadTo(a[0], a[1], a[2], a[3])
+		case 'S':
+			e.AbsSmoothCubeTo(a[0], a[1], a[2], a[3])
+		case 's':
+			e.RelSmoothCubeTo(a[0], a[1], a[2], a[3])
+		case 'C':
+			e.AbsCubeTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		case 'c':
+			e.RelCubeTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		case 'A':
+			e.AbsArcTo(a[0], a[1], a[2], a[3] != 0, a[4] != 0, a[5], a[6])
+		case 'a':
+			e.RelArcTo(a[0], a[1], a[2], a[3] != 0, a[4] != 0, a[5], a[6])
+		case 'Y':
+			e.ClosePathAbsMoveTo(a[0], a[1])
+		case 'y':
+			e.ClosePathRelMoveTo(a[0], a[1])
+		}
+	}
+}
+
+// state is the state of encode.Encoder while encoding a path: the last
+// drawing opcode and how many times it has been repeated.
+type state struct {
+	verb byte
+	reps int
+}
+
+// less orders states, so that the choice between equally cheap sequences of
+// opcodes does not depend on the iteration order of maps.
+func (s state) less(t state) bool {
+	return s.verb < t.verb || s.verb == t.verb && s.reps < t.reps
+}
+
+// choice is the cheapest way to get to a state: its total cost, the previous
+// state and the index of the chosen op.
+type choice struct {
+	cost int
+	prev state
+	op   int
+}
+
+// candidates returns, for each segment that draws something, the ways to
+// encode it. The final segmentMove, which ends the path, is left out.
+func (p *path) candidates() [][]op {
+	var candidates [][]op
+	start := p.first
+	pen := start
+	prevKind, prevControl := segmentLine, point{}
+	for i, s := range p.segments {
+		end := s.p[2]
+		switch s.kind {
+		case segmentLine:
+			// A line of zero length draws nothing, and neither does a line
+			// to the start point that is followed by closing the path.
+			if end == pen || end == start && p.segments[i+1].kind == segmentMove {
+				continue
+			}
+			d := end.sub(pen)
+			ops := []op{
+				newOp('L', end.x, end.y),
+				newOp('l', d.x, d.y),
+			}
+			if d.y == 0 {
+				ops = append(ops, newOp('H', end.x), newOp('h', d.x))
+			}
+			if d.x == 0 {
+				ops = append(ops, newOp('V', end.y), newOp('v', d.y))
+			}
+			candidates = append(candidates, ops)
+
+		case segmentQuad:
+			c, d := s.p[0].sub(pen), end.sub(pen)
+			ops := []op{
+				newOp('Q', s.p[0].x, s.p[0].y, end.x, end.y),
+				newOp('q', c.x, c.y, d.x, d.y),
+			}
+			if smoothPoint(pen, prevKind, prevControl, segmentQuad) == s.p[0] {
+				ops = append(ops, newOp('T', end.x, end.y), newOp('t', d.x, d.y))
+			}
+			candidates = append(candidates, ops)
+			prevControl = s.p[0]
+
+		case segmentCube:
+			c1, c2, d := s.p[0].sub(pen), s.p[1].sub(pen), end.sub(pen)
+			ops := []op{
+				newOp('C', s.p[0].x, s.p[0].y, s.p[1].x, s.p[1].y, end.x, end.y),
+				newOp('c', c1.x, c1.y, c2.x, c2.y, d.x, d.y),
+			}
+			if smoothPoint(pen, prevKind, prevControl, segmentCube) == s.p[0] {
+				ops = append(ops,
+					newOp('S', s.p[1].x, s.p[1].y, end.x, end.y),
+					newOp('s', c2.x, c2.y, d.x, d.y),
+				)
+			}
+			candidates = append(candidates, ops)
+			prevControl = s.p[1]
+
+		case segmentArc:
+			d := end.sub(pen)
+			rx, ry, rot := float64(s.rx), float64(s.ry), float64(s.xAxisRotation)
+			large, sweep := 0.0, 0.0
+			if s.largeArc {
+				large = 1
+			}
+			if s.sweep {
+				sweep = 1
+			}
+			candidates = append(candidates, []op{
+				newOp('A', rx, ry, rot, large, sweep, end.x, end.y),
+				newOp('a', rx, ry, rot, large, sweep, d.x, d.y),
+			})
+
+		case segmentMove:
+			if i == len(p.segments)-1 {
+				break
+			}
+			d := end.sub(start)
+			candidates = append(candidates, []op{
+				newOp('Y', end.x, end.y),
+				newOp('y', d.x, d.y),
+			})
+			start = end
+		}
+		pen, prevKind = end, s.kind
+	}
+
+	// Leave out the ways of encoding that cannot represent the segments
+	// exactly. Each segment keeps at least the way that it was decoded from.
+	for i, ops := range candidates {
+		n := 0
+		for _, o := range ops {
+			if o.cost < unencodable {
+				ops[n] = o
+				n++
+			}
+		}
+		candidates[i] = ops[:n]
+	}
+	return candidates
+}
+
+func smoothPoint(pen point, prevKind segmentKind, prevControl point, kind segmentKind) point {
+	if prevKind != kind {
+		return pen
+	}
+	return pen.reflect(prevControl)
+}
+
+// unencodable is the cost of a number that cannot be encoded exactly.
+const unencodable = 1 << 20
+
+func newOp(verb byte, args ...float64) op {
+	cost := 0
+	for i, x := range args {
+		if verb == 'A' || verb == 'a' {
+			switch i {
+			case 2:
+				cost += angleCost(x)
+				continue
+			case 3:
+				// The large arc and sweep flags are encoded as one byte.
+				cost++
+				continue
+			case 4:
+				continue
+			}
+		}
+		cost += coordinateCost(x)
+	}
+	return op{verb, args, cost}
+}
+
+// coordinateCost returns the number of bytes that encode.Encoder encodes the
+// coordinate x in, without quantizing it, or unencodable when that would not
+// encode x exactly.
+func coordinateCost(x float64) int {
+	f := float32(x)
+	if float64(f) != x {
+		return unencodable
+	}
+	if i := int32(f); -64 <= i && i < +64 && float32(i) == f {
+		return 1
+	}
+	if i := int32(f * 64); -128*64 <= i && i < +128*64 && float32(i) == f*64 {
+		return 2
+	}
+	if math.Float32bits(f)&0x03 == 0 {
+		return 4
+	}
+	return unencodable
+}
+
+// angleCost returns the number of bytes that encode.Encoder encodes the
+// x-axis rotation x of an arc in. The rotation was decoded, so it is exact.
+func angleCost(x float64) int {
+	f := float32(x)
+	if u := uint32(f * 15120); float32(u) == f*15120 && u < 15120 {
+		if u%126 == 0 {
+			return 1
+		}
+		return 2
+	}
+	return 4
+}
@@ -21,6 +21,9 @@ var (
 	MaxInf = float32(math.Inf(1))
 )
 
+// Rasterizer adds the paths it draws as clip operations to Ops. It implements
+// raster.StrokeRasterizer by filling the outline of strokes, because Gio's
+// clip.Stroke has a width but no caps, joins or dashes to choose from.
 type Rasterizer struct {
 	Ops *op.Ops
 
@@ -29,6 +32,10 @@ type Rasterizer struct {
 	clipOp     clip.Op
 	minX, minY float32
 	maxX, maxY float32
+
+	// stroker records the paths to stroke, while its stroke is wider than
+	// zero.
+	stroker raster.Stroker
 }
 
 func NewRasterizer(ops *op.Ops, w, h int) *Rasterizer {
@@ -55,6 +62,9 @@ func (v *Rasterizer) Path() *clip.Path {
 }
 
 func (v *Rasterizer) Op() clip.Op {
+	if v.stroking() {
+		v.stroker.Outline(fill{v})
+	}
 	if v.path != nil {
 		v.clipOp = clip.Outline{Path: v.path.End()}.Op()
 		v.path = nil
@@ -63,6 +73,7 @@ func (v *Rasterizer) Op() clip.Op {
 }
 
 func (v *Rasterizer) Reset(w, h int) {
+	v.stroker = raster.Stroker{}
 	v.size = image.Pt(w, h)
 	v.minX, v.minY = MaxInf, MaxInf
 	v.maxX, v.maxY = MinInf, MinInf
@@ -77,7 +88,21 @@ func (v *Rasterizer) Bounds() image.Rectangle {
 	return image.Rectangle{Max: v.size}
 }
 
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset. A stroke that is not
+// wider than zero fills the paths again.
+func (v *Rasterizer) SetStroke(s raster.Stroke) {
+	v.stroker.Stroke = s
+}
+
+func (v *Rasterizer) stroking() bool {
+	return v.stroker.Stroke.Width > 0
+}
+
 func (v *Rasterizer) Pen() (x, y float32) {
+	if v.stroking() {
+		return v.stroker.Pen()
+	}
 	pos := v.path.Pos()
 	return pos.X, pos.Y
 }
@@ -99,23 +124,63 @@ func (v *Rasterizer) To(x, y float32) f32.Point {
 }
 
 func (v *Rasterizer) MoveTo(ax, ay float32) {
-	v.Path().MoveTo(v.To(ax, ay))
+	if v.stroking() {
+		v.stroker.MoveTo(ax, ay)
+	} else {
+		fill{v}.MoveTo(ax, ay)
+	}
 }
 
 func (v *Rasterizer) LineTo(bx, by float32) {
-	v.Path().LineTo(v.To(bx, by))
+	if v.stroking() {
+		v.stroker.LineTo(bx, by)
+	} else {
+		fill{v}.LineTo(bx, by)
+	}
 }
 
 func (v *Rasterizer) QuadTo(bx, by, cx, cy float32) {
-	v.Path().QuadTo(f32.Pt(bx, by), v.To(cx, cy))
+	if v.stroking() {
+		v.stroker.QuadTo(bx, by, cx, cy)
+	} else {
+		v.Path().QuadTo(f32.Pt(bx, by), v.To(cx, cy))
+	}
 }
 
 func (v *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
-	v.Path().CubeTo(f32.Pt(bx, by), f32.Pt(cx, cy), v.To(dx, dy))
+	if v.stroking() {
+		v.stroker.CubeTo(bx, by, cx, cy, dx, dy)
+	} else {
+		fill{v}.CubeTo(bx, by, cx, cy, dx, dy)
+	}
 }
 
 func (v *Rasterizer) ClosePath() {
-	v.Path().Close()
+	if v.stroking() {
+		v.stroker.ClosePath()
+	} else {
+		fill{v}.ClosePath()
+	}
+}
+
+// fill adds paths to the path that is filled, which is where the outline of
+// a stroke goes as well.
+type fill struct{ v *Rasterizer }
+
+func (f fill) MoveTo(ax, ay float32) {
+	f.v.Path().MoveTo(f.v.To(ax, ay))
+}
+
+func (f fill) LineTo(bx, by float32) {
+	f.v.Path().LineTo(f.v.To(bx, by))
+}
+
+func (f fill) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	f.v.Path().CubeTo(f32.Pt(bx, by), f32.Pt(cx, cy), f.v.To(dx, dy))
+}
+
+func (f fill) ClosePath() {
+	f.v.Path().Close()
 }
 
 func (v *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
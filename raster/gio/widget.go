@@ -4,11 +4,14 @@ import (
 	"image"
 	"image/color"
 
+	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/op/clip"
 	"gioui.org/unit"
 	"github.com/reactivego/ivg"
 	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/hit"
 )
 
 type Option = func(*option)
@@ -16,14 +19,27 @@ type Option = func(*option)
 type option struct {
 	Paint   PaintFunc
 	Options []decode.DecodeOption
+	Click   ClickFunc
 }
 
+// ClickFunc is called with the index of the topmost path of an icon under a
+// pointer press, and the color of the path there.
+type ClickFunc func(layer int, c color.RGBA)
+
 func WithImageBackend() Option {
 	return func(o *option) {
 		o.Paint = ImagePaint
 	}
 }
 
+// WithClick makes the widget call click for the pointer presses on the
+// painted area of the icon. Presses on its transparent parts are ignored.
+func WithClick(click ClickFunc) Option {
+	return func(o *option) {
+		o.Click = click
+	}
+}
+
 func WithColors(colors ...color.Color) Option {
 	return func(o *option) {
 		for idx, c := range colors {
@@ -40,7 +56,8 @@ func WithColors(colors ...color.Color) Option {
 //
 // The data parameter accepts the raw IconVG bytes, while width and height specify the desired
 // dimensions in device-independent pixels (Dp). Additional rendering options can be provided
-// through the variadic options parameter.
+// through the variadic options parameter. With WithClick, the widget reports
+// pointer presses on the paths of the icon, which it finds with hit.Test.
 func Widget(data []byte, width, height unit.Dp, options ...Option) (layout.Widget, error) {
 	viewBox, err := decode.DecodeViewBox(data)
 	if err != nil {
@@ -64,6 +81,19 @@ func Widget(data []byte, width, height unit.Dp, options ...Option) (layout.Widge
 			callOp = macro.Stop()
 		}
 		callOp.Add(gtx.Ops)
+		if o.Click != nil {
+			for _, e := range gtx.Events(o) {
+				if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+					layer, c, err := hit.Test(data, rect, e.Position.X, e.Position.Y, o.Options...)
+					if err == nil && layer >= 0 {
+						o.Click(layer, c)
+					}
+				}
+			}
+			area := clip.Rect(image.Rectangle{Max: newSize}).Push(gtx.Ops)
+			pointer.InputOp{Tag: o, Types: pointer.Press}.Add(gtx.Ops)
+			area.Pop()
+		}
 		return layout.Dimensions{Size: newSize}
 	}
 	return widget, nil
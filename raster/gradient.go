@@ -21,3 +21,12 @@ type GradientConfig interface {
 	// | d e f |
 	Transform() (a, b, c, d, e, f float64)
 }
+
+// GradientSpanner is a GradientConfig that computes the colors of a row of
+// pixels at once, which is faster than calling At for every pixel.
+type GradientSpanner interface {
+	GradientConfig
+	// Span sets dst[i] to the alpha-premultiplied color of the pixel
+	// (x+i, y), the color that At returns for it.
+	Span(dst []color.RGBA64, x, y int)
+}
@@ -4,14 +4,20 @@ package img
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
+	"math"
 
 	"golang.org/x/image/vector"
+
+	"github.com/reactivego/ivg/raster"
 )
 
 // Rasterizer that wraps an inner "golang.org/x/image/vector" Rasterizer. The
 // dst image normally passed to a call Draw is set as a field so Draw does not
-// have to take it as a parameter.
+// have to take it as a parameter. It implements raster.StrokeRasterizer by
+// filling the outline of strokes. When Dst is an *image.RGBA, it fills sources
+// that implement raster.GradientSpanner a row at a time.
 type Rasterizer struct {
 	vector.Rasterizer
 
@@ -22,6 +28,23 @@ type Rasterizer struct {
 	// next call to the Draw method. After that call finishes, DrawOp is set to
 	// draw.Over.
 	DrawOp draw.Op
+
+	// LinearLight makes Draw composite src onto Dst in linear light, instead
+	// of on the sRGB values, so that the anti-aliased edges and translucent
+	// colors of a path do not blend darker than they should.
+	LinearLight bool
+
+	// mask holds the coverage of the path, while compositing in linear light.
+	mask *image.Alpha
+
+	// coverage and span hold the coverage of the path and a row of colors,
+	// while filling a gradient.
+	coverage coverage
+	span     []color.RGBA64
+
+	// stroker records the paths to stroke, while its stroke is wider than
+	// zero.
+	stroker raster.Stroker
 }
 
 // NewRasterizer returns a rasterizer for dst image, with the dst size used to
@@ -38,7 +61,241 @@ func NewRasterizer(dst draw.Image) *Rasterizer {
 // of the DrawOp field is used for drawing. But note, after drawing the DrawOp
 // is reset to draw.Over.
 func (z *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
-	z.Rasterizer.DrawOp = z.DrawOp
-	z.Rasterizer.Draw(z.Dst, r, src, sp)
+	if z.stroking() {
+		z.stroker.Outline(&z.Rasterizer)
+	}
+	dst, rgba := z.Dst.(*image.RGBA)
+	g, spanner := src.(raster.GradientSpanner)
+	if z.LinearLight {
+		z.drawLinear(r, src, sp)
+	} else if rgba && spanner {
+		z.drawGradient(dst, r, g, sp)
+	} else {
+		z.Rasterizer.DrawOp = z.DrawOp
+		z.Rasterizer.Draw(z.Dst, r, src, sp)
+	}
 	z.DrawOp = draw.Over
 }
+
+// drawGradient draws like Draw, but fills the gradient g into dst a row at a
+// time. Its pixels are the same as those of the vector package, which calls At
+// for every pixel.
+func (z *Rasterizer) drawGradient(dst *image.RGBA, r image.Rectangle, g raster.GradientSpanner, sp image.Point) {
+	size := z.Rasterizer.Size()
+	z.coverage.reset(size)
+	z.Rasterizer.DrawOp = draw.Src
+	z.Rasterizer.Draw(&z.coverage, z.coverage.Bounds(), image.Opaque, image.Point{})
+
+	x1, y1 := r.Dx(), r.Dy()
+	if x1 > size.X {
+		x1 = size.X
+	}
+	if y1 > size.Y {
+		y1 = size.Y
+	}
+	if cap(z.span) < x1 {
+		z.span = make([]color.RGBA64, x1)
+	}
+	span := z.span[:x1]
+	for y := 0; y < y1; y++ {
+		g.Span(span, sp.X, sp.Y+y)
+		mask := z.coverage.pix[y*size.X:]
+		pix := dst.Pix[dst.PixOffset(r.Min.X, r.Min.Y+y):]
+		for x, c := range span {
+			ma := mask[x]
+			sr, sg, sb, sa := uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+			i := 4 * x
+			// These formulas come from the vector package, which takes them
+			// from the standard library's image/draw package.
+			if z.DrawOp == draw.Over {
+				a := 0xffff - (sa * ma / 0xffff)
+				pix[i+0] = uint8(((uint32(pix[i+0])*0x101*a + sr*ma) / 0xffff) >> 8)
+				pix[i+1] = uint8(((uint32(pix[i+1])*0x101*a + sg*ma) / 0xffff) >> 8)
+				pix[i+2] = uint8(((uint32(pix[i+2])*0x101*a + sb*ma) / 0xffff) >> 8)
+				pix[i+3] = uint8(((uint32(pix[i+3])*0x101*a + sa*ma) / 0xffff) >> 8)
+			} else {
+				pix[i+0] = uint8((sr * ma / 0xffff) >> 8)
+				pix[i+1] = uint8((sg * ma / 0xffff) >> 8)
+				pix[i+2] = uint8((sb * ma / 0xffff) >> 8)
+				pix[i+3] = uint8((sa * ma / 0xffff) >> 8)
+			}
+		}
+	}
+}
+
+// coverage is an image that records the 16-bit coverage of a path, when the
+// vector package draws image.Opaque into it with draw.Src.
+type coverage struct {
+	size image.Point
+	pix  []uint32
+}
+
+func (c *coverage) reset(size image.Point) {
+	c.size = size
+	if n := size.X * size.Y; cap(c.pix) < n {
+		c.pix = make([]uint32, n)
+	} else {
+		c.pix = c.pix[:n]
+	}
+}
+
+func (c *coverage) ColorModel() color.Model {
+	return color.Alpha16Model
+}
+
+func (c *coverage) Bounds() image.Rectangle {
+	return image.Rectangle{Max: c.size}
+}
+
+func (c *coverage) At(x, y int) color.Color {
+	return color.Alpha16{A: uint16(c.pix[y*c.size.X+x])}
+}
+
+func (c *coverage) Set(x, y int, a color.Color) {
+	if a, ok := a.(*color.RGBA64); ok {
+		c.pix[y*c.size.X+x] = uint32(a.A)
+		return
+	}
+	_, _, _, c.pix[y*c.size.X+x] = a.RGBA()
+}
+
+// drawLinear draws like Draw, but composites in linear light. It rasterizes
+// the coverage of the path into mask, and then blends src with Dst one pixel
+// at a time.
+func (z *Rasterizer) drawLinear(r image.Rectangle, src image.Image, sp image.Point) {
+	size := z.Rasterizer.Size()
+	if z.mask == nil || z.mask.Rect.Size() != size {
+		z.mask = image.NewAlpha(image.Rectangle{Max: size})
+	}
+	z.Rasterizer.DrawOp = draw.Src
+	z.Rasterizer.Draw(z.mask, z.mask.Rect, image.Opaque, image.Point{})
+
+	b := r.Intersect(z.mask.Rect.Add(r.Min)).Intersect(z.Dst.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			m := float64(z.mask.Pix[(y-r.Min.Y)*z.mask.Stride+x-r.Min.X]) / 0xff
+			if m == 0 && z.DrawOp == draw.Over {
+				continue
+			}
+			s := linear(src.At(sp.X+x-r.Min.X, sp.Y+y-r.Min.Y).RGBA())
+			d := linear(z.Dst.At(x, y).RGBA())
+			// The weight of Dst is 1-m for draw.Src, like in the vector
+			// package, and 1-m*alpha of src for draw.Over.
+			k := 1 - m
+			if z.DrawOp == draw.Over {
+				k = 1 - m*s[3]
+			}
+			for i := range d {
+				d[i] = m*s[i] + k*d[i]
+			}
+			z.Dst.Set(x, y, nonlinear(d))
+		}
+	}
+}
+
+// linear converts an alpha-premultiplied 16-bit sRGB color to an
+// alpha-premultiplied color in linear light, with components from 0 to 1.
+func linear(r, g, b, a uint32) [4]float64 {
+	if a == 0 {
+		return [4]float64{}
+	}
+	fa := float64(a)
+	v := func(x uint32) float64 {
+		return srgbToLinear(math.Min(float64(x)/fa, 1)) * fa / 0xffff
+	}
+	return [4]float64{v(r), v(g), v(b), fa / 0xffff}
+}
+
+// nonlinear converts an alpha-premultiplied color in linear light back to
+// an alpha-premultiplied 16-bit sRGB color.
+func nonlinear(c [4]float64) color.RGBA64 {
+	a := math.Min(c[3], 1)
+	if !(a > 0) {
+		return color.RGBA64{}
+	}
+	v := func(x float64) uint16 {
+		return uint16(linearToSRGB(math.Max(0, math.Min(x/a, 1)))*a*0xffff + 0.5)
+	}
+	return color.RGBA64{v(c[0]), v(c[1]), v(c[2]), uint16(a*0xffff + 0.5)}
+}
+
+// srgbToLinear converts an sRGB component from 0 to 1 to linear light.
+func srgbToLinear(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear light component from 0 to 1 to sRGB.
+func linearToSRGB(x float64) float64 {
+	if x <= 0.0031308 {
+		return x * 12.92
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
+
+// Reset resets the inner rasterizer to width w and height h, and stops
+// stroking paths.
+func (z *Rasterizer) Reset(w, h int) {
+	z.stroker = raster.Stroker{}
+	z.Rasterizer.Reset(w, h)
+}
+
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset. A stroke that is not
+// wider than zero fills the paths again.
+func (z *Rasterizer) SetStroke(s raster.Stroke) {
+	z.stroker.Stroke = s
+}
+
+func (z *Rasterizer) stroking() bool {
+	return z.stroker.Stroke.Width > 0
+}
+
+func (z *Rasterizer) Pen() (x, y float32) {
+	if z.stroking() {
+		return z.stroker.Pen()
+	}
+	return z.Rasterizer.Pen()
+}
+
+func (z *Rasterizer) MoveTo(ax, ay float32) {
+	if z.stroking() {
+		z.stroker.MoveTo(ax, ay)
+	} else {
+		z.Rasterizer.MoveTo(ax, ay)
+	}
+}
+
+func (z *Rasterizer) LineTo(bx, by float32) {
+	if z.stroking() {
+		z.stroker.LineTo(bx, by)
+	} else {
+		z.Rasterizer.LineTo(bx, by)
+	}
+}
+
+func (z *Rasterizer) QuadTo(bx, by, cx, cy float32) {
+	if z.stroking() {
+		z.stroker.QuadTo(bx, by, cx, cy)
+	} else {
+		z.Rasterizer.QuadTo(bx, by, cx, cy)
+	}
+}
+
+func (z *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	if z.stroking() {
+		z.stroker.CubeTo(bx, by, cx, cy, dx, dy)
+	} else {
+		z.Rasterizer.CubeTo(bx, by, cx, cy, dx, dy)
+	}
+}
+
+func (z *Rasterizer) ClosePath() {
+	if z.stroking() {
+		z.stroker.ClosePath()
+	} else {
+		z.Rasterizer.ClosePath()
+	}
+}
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package img
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/render"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+// mask draws the paths added by f into a new 32x32 image.
+func mask(f func(z *Rasterizer)) *image.Alpha {
+	dst := image.NewAlpha(image.Rect(0, 0, 32, 32))
+	z := NewRasterizer(dst)
+	f(z)
+	z.Draw(dst.Bounds(), image.Opaque, image.Point{})
+	return dst
+}
+
+// rects adds rectangles with corners (r[0], r[1]) and (r[2], r[3]).
+func rects(z *Rasterizer, rs ...[4]float32) {
+	for _, r := range rs {
+		z.MoveTo(r[0], r[1])
+		z.LineTo(r[2], r[1])
+		z.LineTo(r[2], r[3])
+		z.LineTo(r[0], r[3])
+		z.ClosePath()
+	}
+}
+
+func TestStroke(t *testing.T) {
+	testCases := []struct {
+		name   string
+		stroke func(z *Rasterizer)
+		fill   func(z *Rasterizer)
+	}{{
+		name: "butt",
+		stroke: func(z *Rasterizer) {
+			z.SetStroke(raster.Stroke{Width: 4})
+			z.MoveTo(4, 8)
+			z.LineTo(28, 8)
+		},
+		fill: func(z *Rasterizer) { rects(z, [4]float32{4, 6, 28, 10}) },
+	}, {
+		name: "square",
+		stroke: func(z *Rasterizer) {
+			z.SetStroke(raster.Stroke{Width: 4, Cap: raster.CapSquare})
+			z.MoveTo(4, 8)
+			z.LineTo(28, 8)
+		},
+		fill: func(z *Rasterizer) { rects(z, [4]float32{2, 6, 30, 10}) },
+	}, {
+		name: "closed",
+		stroke: func(z *Rasterizer) {
+			z.SetStroke(raster.Stroke{Width: 2})
+			z.MoveTo(8, 8)
+			z.LineTo(24, 8)
+			z.LineTo(24, 24)
+			z.LineTo(8, 24)
+			z.ClosePath()
+		},
+		fill: func(z *Rasterizer) {
+			rects(z, [4]float32{7, 7, 25, 9}, [4]float32{23, 9, 25, 23},
+				[4]float32{7, 23, 25, 25}, [4]float32{7, 9, 9, 23})
+		},
+	}, {
+		name: "dashes",
+		stroke: func(z *Rasterizer) {
+			z.SetStroke(raster.Stroke{Width: 4, Dashes: []float32{6, 2}, DashOffset: 2})
+			z.MoveTo(4, 8)
+			z.LineTo(28, 8)
+		},
+		fill: func(z *Rasterizer) {
+			rects(z, [4]float32{4, 6, 8, 10}, [4]float32{10, 6, 16, 10},
+				[4]float32{18, 6, 24, 10}, [4]float32{26, 6, 28, 10})
+		},
+	}, {
+		name: "reset",
+		stroke: func(z *Rasterizer) {
+			z.SetStroke(raster.Stroke{Width: 4})
+			z.Reset(32, 32)
+			rects(z, [4]float32{4, 6, 28, 10})
+		},
+		fill: func(z *Rasterizer) { rects(z, [4]float32{4, 6, 28, 10}) },
+	}}
+	for _, tc := range testCases {
+		got, want := mask(tc.stroke), mask(tc.fill)
+		for i := range got.Pix {
+			if got.Pix[i] != want.Pix[i] {
+				x, y := i%got.Stride, i/got.Stride
+				t.Errorf("%s: at (%d, %d): got %#02x, want %#02x", tc.name, x, y, got.Pix[i], want.Pix[i])
+				break
+			}
+		}
+	}
+}
+
+func TestStrokePen(t *testing.T) {
+	z := NewRasterizer(image.NewAlpha(image.Rect(0, 0, 32, 32)))
+	z.SetStroke(raster.Stroke{Width: 1})
+	z.MoveTo(1, 2)
+	z.QuadTo(3, 4, 5, 6)
+	if x, y := z.Pen(); x != 5 || y != 6 {
+		t.Errorf("got pen (%g, %g), want (5, 6)", x, y)
+	}
+	z.ClosePath()
+	if x, y := z.Pen(); x != 1 || y != 2 {
+		t.Errorf("after ClosePath: got pen (%g, %g), want (1, 2)", x, y)
+	}
+}
+
+func TestLinearLight(t *testing.T) {
+	testCases := []struct {
+		name   string
+		src    color.Color
+		linear bool
+		want   [3]uint8
+	}{
+		{"srgb", color.White, false, [3]uint8{0xff, 0x80, 0x00}},
+		{"linear", color.White, true, [3]uint8{0xff, 0xbc, 0x00}},
+		{"linear translucent", color.RGBA{0x80, 0x80, 0x80, 0x80}, true, [3]uint8{0xbc, 0x89, 0x00}},
+	}
+	for _, tc := range testCases {
+		dst := image.NewRGBA(image.Rect(0, 0, 8, 4))
+		draw.Draw(dst, dst.Bounds(), image.Black, image.Point{}, draw.Src)
+		z := NewRasterizer(dst)
+		z.LinearLight = tc.linear
+		// The rectangle covers half of the pixels in column 4.
+		rects(z, [4]float32{0, 0, 4.5, 4})
+		z.Draw(dst.Bounds(), image.NewUniform(tc.src), image.Point{})
+		for i, x := range []int{0, 4, 5} {
+			got := dst.RGBAAt(x, 2)
+			if d := int(got.R) - int(tc.want[i]); d < -1 || d > 1 || got.A != 0xff {
+				t.Errorf("%s: pixel (%d, 2): got %v, want gray %#02x", tc.name, x, got, tc.want[i])
+			}
+		}
+	}
+}
+
+// atOnly hides the Span method of the gradients that it draws, so that they
+// are drawn through their At method.
+type atOnly struct {
+	*Rasterizer
+}
+
+func (z atOnly) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	if _, ok := src.(raster.GradientConfig); ok {
+		src = struct{ image.Image }{src}
+	}
+	z.Rasterizer.Draw(r, src, sp)
+}
+
+// renderFile renders the IconVG file onto z in the rectangle r.
+func renderFile(t *testing.T, filename string, z raster.Rasterizer, r image.Rectangle, i render.Interpolation) {
+	data, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rd render.Renderer
+	rd.SetInterpolation(i)
+	rd.SetRasterizer(z, r)
+	if err := decode.Decode(&rd, data); err != nil {
+		t.Fatalf("%s: %v", filename, err)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	b := image.Rect(0, 0, 80, 80)
+	r := image.Rect(3, 5, 75, 77)
+	for _, filename := range []string{"../../testdata/cowbell", "../../testdata/gradient"} {
+		for _, i := range []render.Interpolation{render.InterpolateSRGB, render.InterpolateOKLab} {
+			for _, op := range []draw.Op{draw.Over, draw.Src} {
+				got, want := image.NewRGBA(b), image.NewRGBA(b)
+				for _, dst := range []*image.RGBA{got, want} {
+					draw.Draw(dst, b, image.NewUniform(color.RGBA{0x10, 0x20, 0x30, 0x40}), image.Point{}, draw.Src)
+				}
+				z := NewRasterizer(got)
+				z.DrawOp = op
+				renderFile(t, filename, z, r, i)
+				zw := NewRasterizer(want)
+				zw.DrawOp = op
+				renderFile(t, filename, atOnly{zw}, r, i)
+				if !bytes.Equal(got.Pix, want.Pix) {
+					t.Errorf("%s: interpolation %d, op %v: pixels differ", filename, i, op)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkGradient(b *testing.B, span bool) {
+	data, err := os.ReadFile(filepath.FromSlash("../../testdata/gradient.ivg"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	z := raster.Rasterizer(NewRasterizer(dst))
+	if !span {
+		z = atOnly{z.(*Rasterizer)}
+	}
+	var rd render.Renderer
+	rd.SetRasterizer(z, dst.Bounds())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if err := decode.Decode(&rd, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGradientSpan(b *testing.B) { benchmarkGradient(b, true) }
+func BenchmarkGradientAt(b *testing.B)   { benchmarkGradient(b, false) }
@@ -0,0 +1,456 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package pdf provides a rasterizer that writes the paths it draws as vector
+// graphics to a single-page PDF document, or to a Form XObject for another
+// PDF document.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"github.com/reactivego/ivg/raster"
+)
+
+// Rasterizer records the paths added via the XxxTo calls in pixel space, and
+// writes them as PDF path operators per Draw call, filled with the nonzero
+// winding rule. Sources that are an *image.Uniform become fill colors, with
+// their alpha in an ExtGState. Sources that implement raster.GradientConfig
+// become axial and radial shadings clipped by the path, with a soft mask for
+// translucent stops. Any other source becomes an image clipped by the path,
+// of the part of the source that is drawn.
+//
+// A pixel is a PDF unit, 1/72 inch, and the y-axis points down like in pixel
+// space. It implements raster.StrokeRasterizer by filling the outline of
+// strokes, which works for every kind of source. Reset only resets the
+// current path, so a document can be built by drawing many paths, like a
+// render.Renderer does.
+type Rasterizer struct {
+	size image.Point
+
+	path       []byte
+	pen, start [2]float32
+	// stroker records the paths to stroke, while its stroke is wider than
+	// zero, and whose outline Draw fills.
+	stroker raster.Stroker
+	// min and max are the bounds of the points of the path, including the
+	// control points, which contain the path.
+	min, max [2]float32
+
+	content bytes.Buffer
+	// gstates, shadings and images are the resources that content uses, as
+	// /GSi, /Shi and /Imi.
+	gstates  []gstate
+	alphas   map[float64]int
+	shadings []string
+	images   []int
+	// streams are the streams that the resources refer to.
+	streams []stream
+}
+
+// gstate is an ExtGState with an alpha for filling, or with the soft mask
+// in stream smask when that is not negative.
+type gstate struct {
+	alpha float64
+	smask int
+}
+
+// stream is a stream object, whose dictionary refers to other streams by
+// their index.
+type stream struct {
+	dict func(ref func(i int) string) string
+	data []byte
+}
+
+// NewRasterizer returns a rasterizer for a page of width w and height h.
+func NewRasterizer(w, h int) *Rasterizer {
+	z := &Rasterizer{alphas: make(map[float64]int)}
+	z.Reset(w, h)
+	return z
+}
+
+// Reset empties the current path, stops stroking paths and sets the size of
+// the page to width w and height h. It does not remove the paths drawn so far
+// from the page.
+func (z *Rasterizer) Reset(w, h int) {
+	z.size = image.Pt(w, h)
+	z.path = z.path[:0]
+	z.pen, z.start = [2]float32{}, [2]float32{}
+	inf := float32(math.Inf(1))
+	z.min, z.max = [2]float32{inf, inf}, [2]float32{-inf, -inf}
+	z.stroker = raster.Stroker{}
+}
+
+func (z *Rasterizer) Size() image.Point {
+	return z.size
+}
+
+func (z *Rasterizer) Bounds() image.Rectangle {
+	return image.Rectangle{Max: z.size}
+}
+
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset. A stroke that is not
+// wider than zero fills the paths again.
+func (z *Rasterizer) SetStroke(s raster.Stroke) {
+	z.stroker.Stroke = s
+}
+
+func (z *Rasterizer) stroking() bool {
+	return z.stroker.Stroke.Width > 0
+}
+
+func (z *Rasterizer) Pen() (x, y float32) {
+	if z.stroking() {
+		return z.stroker.Pen()
+	}
+	return z.pen[0], z.pen[1]
+}
+
+func (z *Rasterizer) MoveTo(ax, ay float32) {
+	if z.stroking() {
+		z.stroker.MoveTo(ax, ay)
+	} else {
+		fill{z}.MoveTo(ax, ay)
+	}
+}
+
+func (z *Rasterizer) LineTo(bx, by float32) {
+	if z.stroking() {
+		z.stroker.LineTo(bx, by)
+	} else {
+		fill{z}.LineTo(bx, by)
+	}
+}
+
+// QuadTo adds the quadratic Bézier segment as the cubic one it is, because
+// PDF has no quadratic segments.
+func (z *Rasterizer) QuadTo(bx, by, cx, cy float32) {
+	if z.stroking() {
+		z.stroker.QuadTo(bx, by, cx, cy)
+		return
+	}
+	ax, ay := z.pen[0], z.pen[1]
+	fill{z}.CubeTo(ax+(bx-ax)*2/3, ay+(by-ay)*2/3, cx+(bx-cx)*2/3, cy+(by-cy)*2/3, cx, cy)
+}
+
+func (z *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	if z.stroking() {
+		z.stroker.CubeTo(bx, by, cx, cy, dx, dy)
+	} else {
+		fill{z}.CubeTo(bx, by, cx, cy, dx, dy)
+	}
+}
+
+func (z *Rasterizer) ClosePath() {
+	if z.stroking() {
+		z.stroker.ClosePath()
+	} else {
+		fill{z}.ClosePath()
+	}
+}
+
+// fill adds paths to the path that is filled, which is where the outline of
+// strokes goes.
+type fill struct{ z *Rasterizer }
+
+func (f fill) MoveTo(ax, ay float32) {
+	z := f.z
+	z.path = appendOp(z.path, "m", z.points(ax, ay)...)
+	z.pen = [2]float32{ax, ay}
+	z.start = z.pen
+}
+
+func (f fill) LineTo(bx, by float32) {
+	z := f.z
+	z.path = appendOp(z.path, "l", z.points(bx, by)...)
+	z.pen = [2]float32{bx, by}
+}
+
+func (f fill) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	z := f.z
+	z.path = appendOp(z.path, "c", z.points(bx, by, cx, cy, dx, dy)...)
+	z.pen = [2]float32{dx, dy}
+}
+
+func (f fill) ClosePath() {
+	z := f.z
+	z.path = append(z.path, "h\n"...)
+	z.pen = z.start
+}
+
+// points extends the bounds of the path to the points in xys, which it
+// returns as the operands of a path operator.
+func (z *Rasterizer) points(xys ...float32) []float32 {
+	for i, v := range xys {
+		if v < z.min[i%2] {
+			z.min[i%2] = v
+		}
+		if v > z.max[i%2] {
+			z.max[i%2] = v
+		}
+	}
+	return xys
+}
+
+// Draw writes the PDF operators that paint the current path, translated by
+// r.Min, with src aligned such that sp in src is at r.Min. Unlike rasterizers
+// that draw into an image, it does not clip the path to r. After that, the
+// current path is empty.
+func (z *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	defer func() { z.path = z.path[:0] }()
+	if z.stroking() {
+		z.stroker.Outline(fill{z})
+	}
+	if len(z.path) == 0 {
+		return
+	}
+	var paint []byte
+	switch s := src.(type) {
+	case *image.Uniform:
+		c := color.NRGBAModel.Convert(s.C).(color.NRGBA)
+		if c.A == 0 {
+			return
+		}
+		if c.A != 0xff {
+			paint = appendOp(paint, "/GS"+strconv.Itoa(z.alpha(float64(c.A)/0xff))+" gs")
+		}
+		paint = appendOp(paint, "rg", float32(c.R)/0xff, float32(c.G)/0xff, float32(c.B)/0xff)
+		paint = append(paint, z.path...)
+		paint = append(paint, "f\n"...)
+	case raster.GradientConfig:
+		var ok bool
+		if paint, ok = z.gradient(s, sp); !ok {
+			return
+		}
+	default:
+		var ok bool
+		if paint, ok = z.image(r, src, sp); !ok {
+			return
+		}
+	}
+	z.content.WriteString("q\n")
+	if r.Min != (image.Point{}) {
+		z.content.Write(appendOp(nil, "cm", 1, 0, 0, 1, float32(r.Min.X), float32(r.Min.Y)))
+	}
+	z.content.Write(paint)
+	z.content.WriteString("Q\n")
+}
+
+// clip returns the operators that clip to the current path.
+func (z *Rasterizer) clip() []byte {
+	return append(z.path[:len(z.path):len(z.path)], "W n\n"...)
+}
+
+// alpha returns the index of the ExtGState for filling with alpha a.
+func (z *Rasterizer) alpha(a float64) int {
+	if i, ok := z.alphas[a]; ok {
+		return i
+	}
+	z.gstates = append(z.gstates, gstate{alpha: a, smask: -1})
+	z.alphas[a] = len(z.gstates) - 1
+	return len(z.gstates) - 1
+}
+
+// gradient returns the operators that paint g clipped by the current path,
+// with sp at the origin of the path. It returns false if g has no stops or
+// its transformation is degenerate.
+func (z *Rasterizer) gradient(g raster.GradientConfig, sp image.Point) ([]byte, bool) {
+	sh, ok := newShading(g, sp, z.min, z.max)
+	if !ok {
+		return nil, false
+	}
+	ops := z.clip()
+	if sh.translucent() {
+		// Paint the alpha of the stops as a luminosity soft mask in a
+		// transparency group that covers the path.
+		form := appendOp(nil, "cm", sh.matrix[:]...)
+		form = appendOp(form, "/Sh0 sh")
+		bbox := numbers(float64(z.min[0]), float64(z.min[1]), float64(z.max[0]), float64(z.max[1]))
+		alpha := sh.dict(true)
+		z.streams = append(z.streams, stream{
+			dict: func(func(int) string) string {
+				return "<< /Type /XObject /Subtype /Form /BBox " + bbox +
+					" /Group << /S /Transparency /CS /DeviceGray >> /Resources << /Shading << /Sh0 " +
+					alpha + " >> >> /Length " + strconv.Itoa(len(form)) + " >>"
+			},
+			data: form,
+		})
+		z.gstates = append(z.gstates, gstate{smask: len(z.streams) - 1})
+		ops = appendOp(ops, "/GS"+strconv.Itoa(len(z.gstates)-1)+" gs")
+	}
+	z.shadings = append(z.shadings, sh.dict(false))
+	ops = appendOp(ops, "cm", sh.matrix[:]...)
+	ops = appendOp(ops, "/Sh"+strconv.Itoa(len(z.shadings)-1)+" sh")
+	return ops, true
+}
+
+// image returns the operators that paint the part of src that is drawn within
+// r, clipped by the current path. It returns false if no part of src is
+// drawn.
+func (z *Rasterizer) image(r image.Rectangle, src image.Image, sp image.Point) ([]byte, bool) {
+	// The bounds of the part of src that is drawn, in the space of the path.
+	b := src.Bounds().Sub(sp).Intersect(r.Sub(r.Min))
+	if b.Empty() {
+		return nil, false
+	}
+	m := image.NewNRGBA(b)
+	draw.Draw(m, b, src, b.Min.Add(sp), draw.Src)
+	rgb := make([]byte, 0, 3*b.Dx()*b.Dy())
+	alpha := make([]byte, 0, b.Dx()*b.Dy())
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := m.NRGBAAt(x, y)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+	smask := -1
+	if !opaque {
+		data := deflate(alpha)
+		z.streams = append(z.streams, stream{dict: imageDict(b, "DeviceGray", len(data), -1), data: data})
+		smask = len(z.streams) - 1
+	}
+	data := deflate(rgb)
+	z.streams = append(z.streams, stream{dict: imageDict(b, "DeviceRGB", len(data), smask), data: data})
+	z.images = append(z.images, len(z.streams)-1)
+
+	ops := z.clip()
+	// The image is the unit square with its first row at the top.
+	ops = appendOp(ops, "cm", float32(b.Dx()), 0, 0, -float32(b.Dy()), float32(b.Min.X), float32(b.Max.Y))
+	ops = appendOp(ops, "/Im"+strconv.Itoa(len(z.images)-1)+" Do")
+	return ops, true
+}
+
+// imageDict returns the dictionary of an image of the size of b, compressed
+// to length bytes, with the soft mask in stream smask when that is not
+// negative.
+func imageDict(b image.Rectangle, colorSpace string, length, smask int) func(ref func(int) string) string {
+	return func(ref func(int) string) string {
+		extra := ""
+		if smask >= 0 {
+			extra = " /SMask " + ref(smask)
+		}
+		return fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8%s /Filter /FlateDecode /Length %d >>",
+			b.Dx(), b.Dy(), colorSpace, extra, length)
+	}
+}
+
+// FormObjects returns the objects of a Form XObject that draws the page, for
+// use in another PDF document. The objects are numbered from first, which is
+// the number of the Form XObject itself, and each is a complete indirect
+// object, from "obj" to "endobj". The Form XObject has the size of the page
+// as its bounding box, with its origin at the bottom left.
+func (z *Rasterizer) FormObjects(first int) [][]byte {
+	ref := func(i int) string { return strconv.Itoa(first+1+i) + " 0 R" }
+	var res bytes.Buffer
+	if len(z.gstates) > 0 {
+		res.WriteString(" /ExtGState <<")
+		for i, gs := range z.gstates {
+			if gs.smask >= 0 {
+				fmt.Fprintf(&res, " /GS%d << /SMask << /Type /Mask /S /Luminosity /G %s >> >>", i, ref(gs.smask))
+			} else {
+				fmt.Fprintf(&res, " /GS%d << /ca %s >>", i, ftoa(gs.alpha))
+			}
+		}
+		res.WriteString(" >>")
+	}
+	if len(z.shadings) > 0 {
+		res.WriteString(" /Shading <<")
+		for i, sh := range z.shadings {
+			fmt.Fprintf(&res, " /Sh%d %s", i, sh)
+		}
+		res.WriteString(" >>")
+	}
+	if len(z.images) > 0 {
+		res.WriteString(" /XObject <<")
+		for i, s := range z.images {
+			fmt.Fprintf(&res, " /Im%d %s", i, ref(s))
+		}
+		res.WriteString(" >>")
+	}
+	w, h := float64(z.size.X), float64(z.size.Y)
+	form := fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox %s /Matrix %s /Resources <<%s >> /Length %d >>",
+		numbers(0, 0, w, h), numbers(1, 0, 0, -1, 0, h), res.String(), z.content.Len())
+
+	objects := [][]byte{streamObject(first, form, z.content.Bytes())}
+	for i, s := range z.streams {
+		objects = append(objects, streamObject(first+1+i, s.dict(ref), s.data))
+	}
+	return objects
+}
+
+// Bytes returns a PDF document with a single page, of the size of the
+// rasterizer, for the paths drawn so far.
+func (z *Rasterizer) Bytes() []byte {
+	w, h := float64(z.size.X), float64(z.size.Y)
+	content := "/Fm0 Do\n"
+	objects := [][]byte{
+		object(1, "<< /Type /Catalog /Pages 2 0 R >>"),
+		object(2, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"),
+		object(3, "<< /Type /Page /Parent 2 0 R /MediaBox "+numbers(0, 0, w, h)+
+			" /Resources << /XObject << /Fm0 5 0 R >> >> /Contents 4 0 R >>"),
+		streamObject(4, "<< /Length "+strconv.Itoa(len(content))+" >>", []byte(content)),
+	}
+	objects = append(objects, z.FormObjects(5)...)
+
+	var b bytes.Buffer
+	// The comment with bytes above 127 marks the file as binary.
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = b.Len()
+		b.Write(o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n\r\n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func object(n int, dict string) []byte {
+	return []byte(strconv.Itoa(n) + " 0 obj\n" + dict + "\nendobj\n")
+}
+
+func streamObject(n int, dict string, data []byte) []byte {
+	b := []byte(strconv.Itoa(n) + " 0 obj\n" + dict + "\nstream\n")
+	b = append(b, data...)
+	return append(b, "\nendstream\nendobj\n"...)
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// appendOp appends the operator op with its operands to b.
+func appendOp(b []byte, op string, operands ...float32) []byte {
+	for _, v := range operands {
+		b = append(b, ftoa(float64(v))...)
+		b = append(b, ' ')
+	}
+	b = append(b, op...)
+	return append(b, '\n')
+}
+
+// ftoa formats f as a PDF number, which has no exponent.
+func ftoa(f float64) string {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "0" // Avoid "-0".
+	}
+	return strconv.FormatFloat(f, 'f', -1, 32)
+}
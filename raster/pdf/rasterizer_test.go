@@ -0,0 +1,363 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/render"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+// parse parses the PDF object in s, of dictionaries, arrays, numbers, names
+// and booleans, into map[string]interface{}, []interface{}, float64, string
+// and bool values.
+func parse(s string) (interface{}, error) {
+	s = strings.NewReplacer("<<", " << ", ">>", " >> ", "[", " [ ", "]", " ] ").Replace(s)
+	tokens := strings.Fields(s)
+	v, rest, err := parseTokens(tokens)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("trailing tokens %q", rest)
+	}
+	return v, err
+}
+
+func parseTokens(tokens []string) (interface{}, []string, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("missing token")
+	}
+	t, tokens := tokens[0], tokens[1:]
+	switch {
+	case t == "<<":
+		d := make(map[string]interface{})
+		for len(tokens) > 0 && tokens[0] != ">>" {
+			key := tokens[0]
+			if !strings.HasPrefix(key, "/") {
+				return nil, nil, fmt.Errorf("key %q is not a name", key)
+			}
+			v, rest, err := parseTokens(tokens[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			d[key[1:]], tokens = v, rest
+		}
+		if len(tokens) == 0 {
+			return nil, nil, fmt.Errorf("missing >>")
+		}
+		return d, tokens[1:], nil
+	case t == "[":
+		a := []interface{}{}
+		for len(tokens) > 0 && tokens[0] != "]" {
+			v, rest, err := parseTokens(tokens)
+			if err != nil {
+				return nil, nil, err
+			}
+			a, tokens = append(a, v), rest
+		}
+		if len(tokens) == 0 {
+			return nil, nil, fmt.Errorf("missing ]")
+		}
+		return a, tokens[1:], nil
+	case strings.HasPrefix(t, "/"):
+		return t[1:], tokens, nil
+	case t == "true" || t == "false":
+		return t == "true", tokens, nil
+	}
+	if strings.ContainsAny(t, "eE") {
+		return nil, nil, fmt.Errorf("number %q has an exponent", t)
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	return f, tokens, err
+}
+
+func numbersOf(v interface{}) []float64 {
+	var a []float64
+	for _, x := range v.([]interface{}) {
+		a = append(a, x.(float64))
+	}
+	return a
+}
+
+// evalFunction evaluates a PDF function of type 2, with N 1, or of type 3.
+func evalFunction(f map[string]interface{}, x float64) []float64 {
+	domain := numbersOf(f["Domain"])
+	x = math.Max(domain[0], math.Min(domain[1], x))
+	switch f["FunctionType"].(float64) {
+	case 2:
+		c0, c1 := numbersOf(f["C0"]), numbersOf(f["C1"])
+		t := (x - domain[0]) / (domain[1] - domain[0])
+		y := make([]float64, len(c0))
+		for i := range y {
+			y[i] = c0[i] + t*(c1[i]-c0[i])
+		}
+		return y
+	case 3:
+		functions := f["Functions"].([]interface{})
+		bounds := append(append([]float64{domain[0]}, numbersOf(f["Bounds"])...), domain[1])
+		encode := numbersOf(f["Encode"])
+		i := 0
+		for i+1 < len(functions) && x >= bounds[i+1] {
+			i++
+		}
+		t := 0.0
+		if bounds[i+1] > bounds[i] {
+			t = (x - bounds[i]) / (bounds[i+1] - bounds[i])
+		}
+		e0, e1 := encode[2*i], encode[2*i+1]
+		return evalFunction(functions[i].(map[string]interface{}), e0+t*(e1-e0))
+	}
+	panic("unsupported function type")
+}
+
+// evalShading evaluates the shading dictionary s at (x, y) in the space of
+// its coordinates. It returns false where the shading paints nothing.
+func evalShading(s map[string]interface{}, x, y float64) ([]float64, bool) {
+	coords, domain := numbersOf(s["Coords"]), numbersOf(s["Domain"])
+	extend, _ := s["Extend"].([]interface{})
+	var u float64
+	switch s["ShadingType"].(float64) {
+	case 2:
+		dx, dy := coords[2]-coords[0], coords[3]-coords[1]
+		u = ((x-coords[0])*dx + (y-coords[1])*dy) / (dx*dx + dy*dy)
+	case 3:
+		if coords[0] != 0 || coords[1] != 0 || coords[2] != 0 || coords[3] != 0 || coords[4] != 0 {
+			panic("unsupported radial shading")
+		}
+		u = math.Hypot(x, y) / coords[5]
+	}
+	if u < 0 || u > 1 {
+		if extend == nil || !extend[0].(bool) || !extend[1].(bool) {
+			return nil, false
+		}
+		u = math.Max(0, math.Min(1, u))
+	}
+	return evalFunction(s["Function"].(map[string]interface{}), domain[0]+u*(domain[1]-domain[0])), true
+}
+
+func TestShading(t *testing.T) {
+	const n = 32
+	stopSets := [][]render.Stop{{
+		{Offset: 0, RGBA64: color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{Offset: 1, RGBA64: color.RGBA64{0, 0, 0xffff, 0xffff}},
+	}, {
+		{Offset: 0.25, RGBA64: color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{Offset: 0.5, RGBA64: color.RGBA64{0, 0xffff, 0, 0xffff}},
+		{Offset: 0.5, RGBA64: color.RGBA64{0, 0, 0xffff, 0xffff}},
+		{Offset: 0.75, RGBA64: color.RGBA64{0, 0, 0x8080, 0x8080}},
+	}}
+	transforms := []render.Aff3{
+		{0.1, 0.05, -0.5, 0, 0, 0},
+		{0.1, 0.03, -1.6, 0.02, 0.08, -1.2},
+	}
+	for shape := render.ShapeLinear; shape <= render.ShapeRadial; shape++ {
+		for spread := render.SpreadNone; spread <= render.SpreadRepeat; spread++ {
+			for si, stops := range stopSets {
+				var g render.Gradient
+				if !g.Init(shape, spread, transforms[shape], stops) {
+					t.Fatal("Init failed")
+				}
+				sh, ok := newShading(&g, image.Pt(3, -2), [2]float32{0, 0}, [2]float32{n, n})
+				if !ok {
+					t.Fatal("newShading failed")
+				}
+				name := fmt.Sprintf("shape %d, spread %d, stops %d", shape, spread, si)
+				if sh.translucent() != (si == 1) {
+					t.Errorf("%s: got translucent %t", name, sh.translucent())
+				}
+				checkShading(t, name, sh, &g)
+			}
+		}
+	}
+}
+
+// checkShading checks that sh paints the colors of g, at the centers of the
+// pixels in the space of the path, which is the pixel space of g translated
+// by (-3, 2).
+func checkShading(t *testing.T, name string, sh *shading, g *render.Gradient) {
+	t.Helper()
+	var dicts [2]map[string]interface{}
+	for i, alpha := range [2]bool{false, true} {
+		d, err := parse(sh.dict(alpha))
+		if err != nil {
+			t.Fatalf("%s: %v in %s", name, err, sh.dict(alpha))
+		}
+		dicts[i] = d.(map[string]interface{})
+	}
+	m := sh.matrix
+	det := float64(m[0]*m[3] - m[1]*m[2])
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			// Map the point back by the inverse of the cm operator.
+			px, py := float64(x)+0.5-float64(m[4]), float64(y)+0.5-float64(m[5])
+			qx := (float64(m[3])*px - float64(m[2])*py) / det
+			qy := (-float64(m[1])*px + float64(m[0])*py) / det
+			want := color.RGBA64Model.Convert(g.At(x+3, y-2)).(color.RGBA64)
+
+			var got [4]float64
+			if rgb, ok := evalShading(dicts[0], qx, qy); ok {
+				a, _ := evalShading(dicts[1], qx, qy)
+				for i := range rgb {
+					got[i] = rgb[i] * a[0]
+				}
+				got[3] = a[0]
+			}
+			for i, w := range [4]uint16{want.R, want.G, want.B, want.A} {
+				// Colors are interpolated without alpha premultiplication,
+				// which is the same here as the stops that change alpha
+				// have the same color.
+				if d := math.Abs(got[i] - float64(w)/0xffff); d > 0.03 {
+					t.Errorf("%s: at (%d, %d): got %.3f, want %.3f", name, x, y, got, [4]float64{
+						float64(want.R) / 0xffff, float64(want.G) / 0xffff, float64(want.B) / 0xffff, float64(want.A) / 0xffff})
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	z := NewRasterizer(32, 16)
+	z.MoveTo(1, 2)
+	z.LineTo(3, 4)
+	z.QuadTo(6, 7, 9, 10)
+	z.ClosePath()
+	if x, y := z.Pen(); x != 1 || y != 2 {
+		t.Errorf("Pen: got (%g, %g), want (1, 2)", x, y)
+	}
+	z.Draw(z.Bounds(), image.NewUniform(color.RGBA{0x80, 0x00, 0x00, 0x80}), image.Point{})
+
+	z.Reset(32, 16)
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.LineTo(8, 8)
+	z.ClosePath()
+	z.Draw(image.Rect(4, 5, 32, 16), image.Black, image.Point{})
+
+	// A transparent source draws nothing.
+	z.Reset(32, 16)
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.ClosePath()
+	z.Draw(z.Bounds(), image.Transparent, image.Point{})
+
+	var g render.Gradient
+	g.Init(render.ShapeRadial, render.SpreadReflect, render.Aff3{0.1, 0, -1, 0, 0.1, -1}, []render.Stop{
+		{Offset: 0, RGBA64: color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{Offset: 1, RGBA64: color.RGBA64{0, 0, 0x8080, 0x8080}},
+	})
+	z.MoveTo(0, 0)
+	z.LineTo(16, 0)
+	z.LineTo(16, 16)
+	z.ClosePath()
+	z.Draw(z.Bounds(), &g, image.Point{})
+
+	m := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	m.Pix[3] = 0x80
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.LineTo(8, 8)
+	z.ClosePath()
+	z.Draw(image.Rect(0, 0, 2, 3), m, image.Pt(1, 1))
+
+	data := z.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF file:\n%s", data)
+	}
+
+	// The cross-reference table has the offsets of the objects.
+	i := bytes.LastIndex(data, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(data[i:]))[1])
+	if err != nil || !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+	fields := strings.Fields(string(data[xref:]))
+	count, _ := strconv.Atoi(fields[2])
+	if want := 9; count != want {
+		t.Errorf("got %d objects, want %d", count, want)
+	}
+	objects := map[int]string{}
+	for n := 1; n < count; n++ {
+		offset, _ := strconv.Atoi(fields[3+3*n])
+		prefix := strconv.Itoa(n) + " 0 obj\n"
+		if !bytes.HasPrefix(data[offset:], []byte(prefix)) {
+			t.Fatalf("object %d is not at offset %d", n, offset)
+		}
+		end := bytes.Index(data[offset:], []byte("endobj\n"))
+		objects[n] = string(data[offset+len(prefix) : offset+end])
+	}
+
+	// The streams have their length, and the dictionaries parse.
+	streamRE := regexp.MustCompile(`(?s)^(<<.*>>)\nstream\n(.*)\nendstream\n$`)
+	for n := 1; n < count; n++ {
+		dict := objects[n]
+		if m := streamRE.FindStringSubmatch(objects[n]); m != nil {
+			dict = m[1]
+			if want := fmt.Sprintf("/Length %d >>", len(m[2])); !strings.HasSuffix(dict, want) {
+				t.Errorf("object %d: got %s, want length %d", n, dict, len(m[2]))
+			}
+		}
+		// Parse the dictionary without its references.
+		if _, err := parse(regexp.MustCompile(`\d+ 0 R`).ReplaceAllString(dict, "0")); err != nil {
+			t.Errorf("object %d: %v in %s", n, err, dict)
+		}
+	}
+
+	form := objects[5]
+	for _, want := range []string{
+		"/BBox [0 0 32 16] /Matrix [1 0 0 -1 0 16]",
+		"/ExtGState << /GS0 << /ca 0.5019608 >> /GS1 << /SMask << /Type /Mask /S /Luminosity /G 6 0 R >> >> >>",
+		"/Shading << /Sh0 << /ShadingType 3 /ColorSpace /DeviceRGB /Coords [0 0 0 0 0 2] /Domain [0 2]",
+		"/XObject << /Im0 8 0 R >>",
+		"stream\n" +
+			"q\n/GS0 gs\n1 0 0 rg\n1 2 m\n3 4 l\n5 6 7 8 9 10 c\nh\nf\nQ\n" +
+			"q\n1 0 0 1 4 5 cm\n0 0 0 rg\n0 0 m\n8 0 l\n8 8 l\nh\nf\nQ\n" +
+			"q\n0 0 m\n16 0 l\n16 16 l\nh\nW n\n/GS1 gs\n10 0 0 10 10 10 cm\n/Sh0 sh\nQ\n" +
+			"q\n0 0 m\n8 0 l\n8 8 l\nh\nW n\n2 0 0 -3 0 3 cm\n/Im0 Do\nQ\n",
+	} {
+		if !strings.Contains(form, want) {
+			t.Errorf("form does not contain %q:\n%s", want, form)
+		}
+	}
+	if want := "/Subtype /Image /Width 2 /Height 3 /ColorSpace /DeviceRGB /BitsPerComponent 8 /SMask 7 0 R"; !strings.Contains(objects[8], want) {
+		t.Errorf("image does not contain %q:\n%s", want, objects[8])
+	}
+}
+
+func TestStroke(t *testing.T) {
+	z := NewRasterizer(32, 16)
+	z.SetStroke(raster.Stroke{Width: 2})
+	z.MoveTo(1, 2)
+	z.LineTo(9, 2)
+	if x, y := z.Pen(); x != 9 || y != 2 {
+		t.Errorf("Pen: got (%g, %g), want (9, 2)", x, y)
+	}
+	z.Draw(z.Bounds(), image.Black, image.Point{})
+	got := z.content.String()
+	if !strings.HasPrefix(got, "q\n0 0 0 rg\n") || !strings.HasSuffix(got, "h\nf\nQ\n") {
+		t.Errorf("got %q, want a filled outline", got)
+	}
+	if z.min != [2]float32{1, 1} || z.max != [2]float32{9, 3} {
+		t.Errorf("got bounds %v %v, want [1 1] [9 3]", z.min, z.max)
+	}
+
+	// Reset stops stroking.
+	z.Reset(32, 16)
+	z.content.Reset()
+	z.MoveTo(1, 2)
+	z.LineTo(9, 2)
+	z.LineTo(9, 4)
+	z.Draw(z.Bounds(), image.Black, image.Point{})
+	if got, want := z.content.String(), "q\n0 0 0 rg\n1 2 m\n9 2 l\n9 4 l\nf\nQ\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package pdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/reactivego/ivg/raster"
+)
+
+// maxRepeats is the maximum number of times that a shading repeats or
+// reflects its stops, beyond which it pads.
+const maxRepeats = 256
+
+// shading is an axial or radial shading for a gradient. PDF shadings only
+// pad, or extend not at all, past their stops, so a shading that repeats or
+// reflects its stops does so over a domain that covers the path.
+type shading struct {
+	radial bool
+	// matrix maps the space of coords to the space of the path, as the
+	// operands of a cm operator.
+	matrix [6]float32
+	// coords are the coordinates of the start and end points of an axial
+	// shading, or the centers and radii of the start and end circles of a
+	// radial shading, which are at t = domain[0] and t = domain[1].
+	coords []float64
+	domain [2]float64
+	extend bool
+	spread int
+	stops  []stop
+}
+
+// stop is a gradient stop with a color that is not alpha-premultiplied.
+type stop struct {
+	offset     float64
+	r, g, b, a float64
+}
+
+// newShading returns the shading for g, with sp at the origin of the path,
+// which has its points within min and max. It returns false if g has no stops
+// or its transformation is degenerate.
+func newShading(g raster.GradientConfig, sp image.Point, min, max [2]float32) (*shading, bool) {
+	colors, offsets := g.StopColors(), g.StopOffsets()
+	if len(colors) == 0 || len(colors) != len(offsets) {
+		return nil, false
+	}
+	sh := &shading{
+		radial: g.GradientShape() != 0,
+		matrix: [6]float32{1, 0, 0, 1, 0, 0},
+		domain: [2]float64{0, 1},
+		extend: g.SpreadMethod() != 0,
+		spread: g.SpreadMethod(),
+		stops:  make([]stop, len(colors)),
+	}
+	for i, c := range colors {
+		sh.stops[i] = unpremul(offsets[i], c)
+	}
+
+	// The transformation maps the pixel space of g to gradient space. The
+	// path is in the pixel space of g translated by -sp.
+	a, b, c, d, e, f := g.Transform()
+	c += a*float64(sp.X) + b*float64(sp.Y)
+	f += d*float64(sp.X) + e*float64(sp.Y)
+	corners := [4][2]float64{
+		{float64(min[0]), float64(min[1])}, {float64(max[0]), float64(min[1])},
+		{float64(min[0]), float64(max[1])}, {float64(max[0]), float64(max[1])},
+	}
+
+	if !sh.radial {
+		// The offset is a*x + b*y + c, which is t at the point (t-c)*(a, b)/dd
+		// on the line through the origin in the direction (a, b).
+		dd := a*a + b*b
+		if dd == 0 || math.IsNaN(dd) || math.IsInf(dd, 0) {
+			return nil, false
+		}
+		if sh.spread >= 2 {
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, p := range corners {
+				t := a*p[0] + b*p[1] + c
+				lo, hi = math.Min(lo, t), math.Max(hi, t)
+			}
+			sh.domain = repeatDomain(lo, hi)
+		}
+		t0, t1 := sh.domain[0], sh.domain[1]
+		sh.coords = []float64{(t0 - c) * a / dd, (t0 - c) * b / dd, (t1 - c) * a / dd, (t1 - c) * b / dd}
+		return sh, true
+	}
+
+	// The transformation maps pixel space to gradient space, where the
+	// offset is the distance to the origin. The matrix maps the other way.
+	det := a*e - b*d
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return nil, false
+	}
+	ia, ib, id, ie := e/det, -b/det, -d/det, a/det
+	ic, iF := -(ia*c + ib*f), -(id*c + ie*f)
+	sh.matrix = [6]float32{float32(ia), float32(id), float32(ib), float32(ie), float32(ic), float32(iF)}
+	if sh.spread >= 2 {
+		hi := 0.0
+		for _, p := range corners {
+			gx, gy := a*p[0]+b*p[1]+c, d*p[0]+e*p[1]+f
+			hi = math.Max(hi, math.Hypot(gx, gy))
+		}
+		sh.domain = repeatDomain(0, hi)
+	}
+	sh.coords = []float64{0, 0, 0, 0, 0, sh.domain[1]}
+	return sh, true
+}
+
+// repeatDomain returns the domain of a shading that repeats or reflects its
+// stops, which covers the offsets from lo to hi with whole repetitions.
+func repeatDomain(lo, hi float64) [2]float64 {
+	t0, t1 := math.Floor(lo), math.Ceil(hi)
+	if !(t1 > t0) {
+		t1 = t0 + 1
+	}
+	if t1-t0 > maxRepeats {
+		// Repeat around the offsets from 0 to 1.
+		t0 = math.Max(t0, -maxRepeats/2)
+		t1 = t0 + maxRepeats
+	}
+	return [2]float64{t0, t1}
+}
+
+func unpremul(offset float64, c color.RGBA) stop {
+	s := stop{offset: offset, a: float64(c.A) / 0xff}
+	if c.A != 0 {
+		s.r = math.Min(1, float64(c.R)/float64(c.A))
+		s.g = math.Min(1, float64(c.G)/float64(c.A))
+		s.b = math.Min(1, float64(c.B)/float64(c.A))
+	}
+	return s
+}
+
+// translucent returns whether any stop of sh is translucent.
+func (sh *shading) translucent() bool {
+	for _, s := range sh.stops {
+		if s.a != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// dict returns the shading dictionary of sh, in DeviceRGB, or in DeviceGray
+// for the alpha of its stops.
+func (sh *shading) dict(alpha bool) string {
+	var b strings.Builder
+	b.WriteString("<< /ShadingType ")
+	if sh.radial {
+		b.WriteString("3")
+	} else {
+		b.WriteString("2")
+	}
+	if alpha {
+		b.WriteString(" /ColorSpace /DeviceGray")
+	} else {
+		b.WriteString(" /ColorSpace /DeviceRGB")
+	}
+	b.WriteString(" /Coords " + numbers(sh.coords...))
+	b.WriteString(" /Domain " + numbers(sh.domain[:]...))
+	b.WriteString(" /Function " + sh.function(alpha))
+	if sh.extend {
+		b.WriteString(" /Extend [true true]")
+	}
+	b.WriteString(" >>")
+	return b.String()
+}
+
+// function returns the function from t in the domain of sh to the color, or
+// alpha, of the stops.
+func (sh *shading) function(alpha bool) string {
+	f := sh.stopFunction(alpha)
+	if sh.spread < 2 {
+		return f
+	}
+	// Stitch together a copy of the stop function for each repetition, which
+	// is reversed for odd repetitions of a reflection.
+	t0, t1 := int(sh.domain[0]), int(sh.domain[1])
+	var functions, bounds, encode []string
+	for t := t0; t < t1; t++ {
+		functions = append(functions, f)
+		if t > t0 {
+			bounds = append(bounds, strconv.Itoa(t))
+		}
+		if sh.spread == 2 && t%2 != 0 {
+			encode = append(encode, "1 0")
+		} else {
+			encode = append(encode, "0 1")
+		}
+	}
+	return stitch(sh.domain, functions, bounds, encode)
+}
+
+// stopFunction returns the function from the offset from 0 to 1 to the color,
+// or alpha, of the stops, which is constant before the first and after the
+// last stop.
+func (sh *shading) stopFunction(alpha bool) string {
+	value := func(s stop) string {
+		if alpha {
+			return numbers(s.a)
+		}
+		return numbers(s.r, s.g, s.b)
+	}
+	linear := func(s0, s1 stop) string {
+		return "<< /FunctionType 2 /Domain [0 1] /C0 " + value(s0) + " /C1 " + value(s1) + " /N 1 >>"
+	}
+	var functions, bounds, encode []string
+	add := func(s0, s1 stop) {
+		if len(functions) > 0 {
+			bounds = append(bounds, ftoa(s0.offset))
+		}
+		functions = append(functions, linear(s0, s1))
+		encode = append(encode, "0 1")
+	}
+	first, last := sh.stops[0], sh.stops[len(sh.stops)-1]
+	if first.offset > 0 {
+		add(stop{offset: 0, r: first.r, g: first.g, b: first.b, a: first.a}, first)
+	}
+	for i := 0; i+1 < len(sh.stops); i++ {
+		if sh.stops[i].offset < sh.stops[i+1].offset {
+			add(sh.stops[i], sh.stops[i+1])
+		}
+	}
+	if last.offset < 1 || len(functions) == 0 {
+		add(last, stop{offset: 1, r: last.r, g: last.g, b: last.b, a: last.a})
+	}
+	if len(functions) == 1 {
+		return functions[0]
+	}
+	return stitch([2]float64{0, 1}, functions, bounds, encode)
+}
+
+// stitch returns a stitching function of the functions, over the domain
+// split at the bounds.
+func stitch(domain [2]float64, functions, bounds, encode []string) string {
+	return "<< /FunctionType 3 /Domain " + numbers(domain[:]...) +
+		" /Functions [" + strings.Join(functions, " ") + "]" +
+		" /Bounds [" + strings.Join(bounds, " ") + "]" +
+		" /Encode [" + strings.Join(encode, " ") + "] >>"
+}
+
+// numbers returns a PDF array of the numbers in a.
+func numbers(a ...float64) string {
+	s := make([]string, len(a))
+	for i, v := range a {
+		s[i] = ftoa(v)
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
@@ -0,0 +1,385 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package record provides a rasterizer that records the paths it draws in a
+// display list, which can be replayed onto other rasterizers without decoding
+// the IconVG graphic again.
+package record
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/render"
+)
+
+// Rasterizer records the paths added via the XxxTo calls, and adds a layer to
+// its List per Draw call, with the path and how it is drawn.
+//
+// Reset only resets the current path, so a list can be built by drawing many
+// paths, like a render.Renderer does.
+type Rasterizer struct {
+	// List is the display list of the paths drawn so far.
+	List List
+
+	size       image.Point
+	pen, start [2]float32
+	verbs      []Verb
+	args       []float32
+	stroke     raster.Stroke
+}
+
+// NewRasterizer returns a rasterizer of width w and height h.
+func NewRasterizer(w, h int) *Rasterizer {
+	return &Rasterizer{size: image.Pt(w, h)}
+}
+
+// Reset empties the current path and sets the size of the rasterizer to width
+// w and height h. It does not remove the layers drawn so far from the List,
+// and it stops stroking paths.
+func (z *Rasterizer) Reset(w, h int) {
+	z.size = image.Pt(w, h)
+	z.pen, z.start = [2]float32{}, [2]float32{}
+	z.verbs, z.args = z.verbs[:0], z.args[:0]
+	z.stroke = raster.Stroke{}
+}
+
+func (z *Rasterizer) Size() image.Point {
+	return z.size
+}
+
+func (z *Rasterizer) Bounds() image.Rectangle {
+	return image.Rectangle{Max: z.size}
+}
+
+func (z *Rasterizer) Pen() (x, y float32) {
+	return z.pen[0], z.pen[1]
+}
+
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset, which becomes the
+// Stroke of their layers. A stroke that is not wider than zero fills the
+// paths again.
+func (z *Rasterizer) SetStroke(s raster.Stroke) {
+	z.stroke = s
+}
+
+func (z *Rasterizer) MoveTo(ax, ay float32) {
+	z.verbs = append(z.verbs, MoveTo)
+	z.args = append(z.args, ax, ay)
+	z.pen = [2]float32{ax, ay}
+	z.start = z.pen
+}
+
+func (z *Rasterizer) LineTo(bx, by float32) {
+	z.verbs = append(z.verbs, LineTo)
+	z.args = append(z.args, bx, by)
+	z.pen = [2]float32{bx, by}
+}
+
+func (z *Rasterizer) QuadTo(bx, by, cx, cy float32) {
+	z.verbs = append(z.verbs, QuadTo)
+	z.args = append(z.args, bx, by, cx, cy)
+	z.pen = [2]float32{cx, cy}
+}
+
+func (z *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	z.verbs = append(z.verbs, CubeTo)
+	z.args = append(z.args, bx, by, cx, cy, dx, dy)
+	z.pen = [2]float32{dx, dy}
+}
+
+func (z *Rasterizer) ClosePath() {
+	z.verbs = append(z.verbs, ClosePath)
+	z.pen = z.start
+}
+
+// Draw adds a layer for the current path to the List, that is drawn in r with
+// src aligned such that sp in src is at r.Min. After that, the current path is
+// empty.
+//
+// The layer holds a copy of src when it is an *image.Uniform or a
+// *render.Gradient, because a render.Renderer changes those between paths.
+// Other sources are held as they are, and must not change while the List is
+// in use.
+func (z *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	if len(z.verbs) == 0 {
+		return
+	}
+	l := Layer{
+		R:      r,
+		Size:   z.size,
+		Verbs:  append([]Verb(nil), z.verbs...),
+		Args:   append([]float32(nil), z.args...),
+		Src:    snapshot(src),
+		Sp:     sp,
+		Stroke: z.stroke,
+	}
+	l.Stroke.Dashes = append([]float32(nil), z.stroke.Dashes...)
+	z.List.Layers = append(z.List.Layers, l)
+	z.verbs, z.args = z.verbs[:0], z.args[:0]
+}
+
+// snapshot returns a copy of src when src is a source that a render.Renderer
+// reuses, or else src.
+func snapshot(src image.Image) image.Image {
+	switch s := src.(type) {
+	case *image.Uniform:
+		return image.NewUniform(color.RGBA64Model.Convert(s.C))
+	case *render.Gradient:
+		g := *s
+		g.Ranges = append([]render.Range(nil), s.Ranges...)
+		return &g
+	}
+	return src
+}
+
+// Verb is the kind of a segment of a path.
+type Verb uint8
+
+const (
+	MoveTo Verb = iota
+	LineTo
+	QuadTo
+	CubeTo
+	ClosePath
+)
+
+// NumArgs returns the number of coordinates that follow the verb in the Args
+// of a Layer.
+func (v Verb) NumArgs() int {
+	switch v {
+	case MoveTo, LineTo:
+		return 2
+	case QuadTo:
+		return 4
+	case CubeTo:
+		return 6
+	}
+	return 0
+}
+
+// List is a display list, with a layer per path drawn, in the order in which
+// they were drawn.
+type List struct {
+	Layers []Layer
+}
+
+// Replay draws the layers of the list onto z, with their paths and sources
+// scaled by scale and then translated by offset. A scale of 1 draws the same
+// pixels as the rasterizer that the list was recorded for, at offset. Note
+// that the paths are those of the size that the list was recorded at, which
+// for an IconVG graphic includes its levels of detail.
+func (l *List) Replay(z raster.Rasterizer, offset image.Point, scale float32) {
+	for i := range l.Layers {
+		l.Layers[i].Replay(z, offset, scale)
+	}
+}
+
+// Layer is a path and how it is drawn, as passed to the Draw call of a
+// Rasterizer. The coordinates of the path are relative to R.Min.
+type Layer struct {
+	// R is the rectangle that the path is drawn in.
+	R image.Rectangle
+	// Size is the size that the Rasterizer was reset to.
+	Size image.Point
+
+	// Verbs are the segments of the path, and Args their coordinates, as x
+	// and y pairs in the order of the arguments of the XxxTo calls.
+	Verbs []Verb
+	Args  []float32
+
+	// Src is drawn with Sp in Src aligned with R.Min.
+	Src image.Image
+	Sp  image.Point
+
+	// Stroke is the style of the stroke of the path, which is filled when
+	// the stroke is not wider than zero.
+	Stroke raster.Stroke
+}
+
+// Bounds returns the rectangle in the space of R that contains the control
+// points of the path, and the width of its stroke, clipped to R.
+func (l *Layer) Bounds() image.Rectangle {
+	if len(l.Args) == 0 {
+		return image.Rectangle{}
+	}
+	x0, y0 := float32(math.Inf(1)), float32(math.Inf(1))
+	x1, y1 := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for i := 0; i+1 < len(l.Args); i += 2 {
+		x, y := l.Args[i], l.Args[i+1]
+		if x < x0 {
+			x0 = x
+		}
+		if x > x1 {
+			x1 = x
+		}
+		if y < y0 {
+			y0 = y
+		}
+		if y > y1 {
+			y1 = y
+		}
+	}
+	if w := l.Stroke.Width; w > 0 {
+		// A square cap reaches half the width times √2 beyond a point, and a
+		// miter join up to half the width times the miter limit.
+		d := w / 2 * math.Sqrt2
+		if l.Stroke.Join == raster.JoinMiter {
+			limit := l.Stroke.MiterLimit
+			if limit == 0 {
+				limit = 4
+			}
+			if m := w / 2 * limit; m > d {
+				d = m
+			}
+		}
+		x0, y0, x1, y1 = x0-d, y0-d, x1+d, y1+d
+	}
+	b := image.Rect(
+		int(math.Floor(float64(x0))), int(math.Floor(float64(y0))),
+		int(math.Ceil(float64(x1))), int(math.Ceil(float64(y1))),
+	)
+	return b.Add(l.R.Min).Intersect(l.R)
+}
+
+// Path is the part of a Rasterizer that adds paths, which a raster.Stroker
+// also has.
+type Path interface {
+	MoveTo(ax, ay float32)
+	LineTo(bx, by float32)
+	QuadTo(bx, by, cx, cy float32)
+	CubeTo(bx, by, cx, cy, dx, dy float32)
+	ClosePath()
+}
+
+// Walk adds the path of the layer to p, with its coordinates scaled by scale
+// and then translated by (dx, dy).
+func (l *Layer) Walk(p Path, dx, dy, scale float32) {
+	args := l.Args
+	for _, v := range l.Verbs {
+		var a [6]float32
+		n := v.NumArgs()
+		for i := 0; i < n; i += 2 {
+			a[i] = args[i]*scale + dx
+			a[i+1] = args[i+1]*scale + dy
+		}
+		args = args[n:]
+		switch v {
+		case MoveTo:
+			p.MoveTo(a[0], a[1])
+		case LineTo:
+			p.LineTo(a[0], a[1])
+		case QuadTo:
+			p.QuadTo(a[0], a[1], a[2], a[3])
+		case CubeTo:
+			p.CubeTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		case ClosePath:
+			p.ClosePath()
+		}
+	}
+}
+
+// Replay draws the layer onto z, with its path and source scaled by scale and
+// then translated by offset. It strokes the path with the Stroker of a
+// raster.Stroker when z is not a raster.StrokeRasterizer. A scale that is not
+// positive draws nothing.
+func (l *Layer) Replay(z raster.Rasterizer, offset image.Point, scale float32) {
+	if !(scale > 0) {
+		return
+	}
+	r, size, src, sp := l.R.Add(offset), l.Size, l.Src, l.Sp
+	var dx, dy float32
+	if scale != 1 {
+		// The path is at l.R.Min*scale + offset, which is in between pixels,
+		// so it is drawn in r with the fraction as (dx, dy).
+		s := float64(scale)
+		r = image.Rect(
+			int(math.Floor(float64(l.R.Min.X)*s)), int(math.Floor(float64(l.R.Min.Y)*s)),
+			int(math.Ceil(float64(l.R.Max.X)*s)), int(math.Ceil(float64(l.R.Max.Y)*s)),
+		).Add(offset)
+		dx = float32(l.R.Min.X)*scale + float32(offset.X-r.Min.X)
+		dy = float32(l.R.Min.Y)*scale + float32(offset.Y-r.Min.Y)
+		size = image.Pt(
+			int(math.Ceil(float64(l.Size.X)*s+float64(dx))),
+			int(math.Ceil(float64(l.Size.Y)*s+float64(dy))),
+		)
+		// A pixel q of z shows the pixel (q-offset)/scale - l.R.Min + l.Sp
+		// of l.Src.
+		src, sp = scaled(l.Src, scale,
+			-float64(offset.X)/s-float64(l.R.Min.X-l.Sp.X),
+			-float64(offset.Y)/s-float64(l.R.Min.Y-l.Sp.Y),
+		), r.Min
+	}
+
+	z.Reset(size.X, size.Y)
+	var p Path = z
+	var stroker *raster.Stroker
+	if l.Stroke.Width > 0 {
+		s := l.Stroke
+		s.Width *= scale
+		s.DashOffset *= scale
+		if len(s.Dashes) > 0 {
+			s.Dashes = make([]float32, len(l.Stroke.Dashes))
+			for i, d := range l.Stroke.Dashes {
+				s.Dashes[i] = d * scale
+			}
+		}
+		if sz, ok := z.(raster.StrokeRasterizer); ok {
+			sz.SetStroke(s)
+		} else {
+			stroker = &raster.Stroker{Stroke: s}
+			p = stroker
+		}
+	}
+	l.Walk(p, dx, dy, scale)
+	if stroker != nil {
+		stroker.Outline(z)
+	}
+	z.Draw(r, src, sp)
+}
+
+// scaled returns src scaled by s, such that its pixel at q is the pixel of src
+// at q/s + (tx, ty).
+func scaled(src image.Image, s float32, tx, ty float64) image.Image {
+	switch src := src.(type) {
+	case *image.Uniform:
+		return src
+	case *render.Gradient:
+		// Pix2Grad maps q/s + t, which is still an affine transformation.
+		g := *src
+		m, is := src.Pix2Grad, 1/float64(s)
+		g.Pix2Grad = render.Aff3{
+			m[0] * is, m[1] * is, m[0]*tx + m[1]*ty + m[2],
+			m[3] * is, m[4] * is, m[3]*tx + m[4]*ty + m[5],
+		}
+		return &g
+	}
+	return &scaledImage{src, float64(s), tx, ty}
+}
+
+// scaledImage is an image scaled with nearest neighbor sampling.
+type scaledImage struct {
+	src    image.Image
+	s      float64
+	tx, ty float64
+}
+
+func (m *scaledImage) ColorModel() color.Model {
+	return m.src.ColorModel()
+}
+
+func (m *scaledImage) Bounds() image.Rectangle {
+	b := m.src.Bounds()
+	return image.Rect(
+		int(math.Floor((float64(b.Min.X)-m.tx)*m.s)), int(math.Floor((float64(b.Min.Y)-m.ty)*m.s)),
+		int(math.Ceil((float64(b.Max.X)-m.tx)*m.s)), int(math.Ceil((float64(b.Max.Y)-m.ty)*m.s)),
+	)
+}
+
+func (m *scaledImage) At(x, y int) color.Color {
+	sx := math.Floor((float64(x)+0.5)/m.s + m.tx)
+	sy := math.Floor((float64(y)+0.5)/m.s + m.ty)
+	return m.src.At(int(sx), int(sy))
+}
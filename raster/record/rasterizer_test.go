@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package record
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+var filenames = []string{
+	"../../testdata/action-info.lores",
+	"../../testdata/cowbell",
+	"../../testdata/elliptical",
+	"../../testdata/favicon",
+	"../../testdata/gradient",
+	"../../testdata/lod-polygon",
+}
+
+// record returns the display list of the IconVG file rendered in r.
+func record(t *testing.T, filename string, r image.Rectangle) *List {
+	ivgData, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := NewRasterizer(r.Dx(), r.Dy())
+	var rd render.Renderer
+	rd.SetRasterizer(z, r)
+	if err := decode.Decode(&rd, ivgData); err != nil {
+		t.Fatalf("%s: %v", filename, err)
+	}
+	return &z.List
+}
+
+// renderFile returns the IconVG file rendered in r of an image with bounds b.
+func renderFile(t *testing.T, filename string, b, r image.Rectangle) *image.RGBA {
+	ivgData, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewRGBA(b)
+	var rd render.Renderer
+	rd.SetRasterizer(img.NewRasterizer(dst), r)
+	if err := decode.Decode(&rd, ivgData); err != nil {
+		t.Fatalf("%s: %v", filename, err)
+	}
+	return dst
+}
+
+func TestReplay(t *testing.T) {
+	b := image.Rect(0, 0, 100, 100)
+	for _, filename := range filenames {
+		list := record(t, filename, image.Rect(0, 0, 48, 48))
+		if len(list.Layers) == 0 {
+			t.Fatalf("%s: no layers", filename)
+		}
+
+		want := renderFile(t, filename, b, image.Rect(5, 7, 53, 55))
+		got := image.NewRGBA(b)
+		list.Replay(img.NewRasterizer(got), image.Pt(5, 7), 1)
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("%s: scale 1: pixels differ", filename)
+		}
+
+		// Scaling by 2 is exact for the paths, but not for the gradients. The
+		// levels of detail are those of the recorded size, so they differ.
+		if filename == "../../testdata/lod-polygon" {
+			continue
+		}
+		want = renderFile(t, filename, b, image.Rect(0, 0, 96, 96).Add(image.Pt(3, 2)))
+		got = image.NewRGBA(b)
+		list.Replay(img.NewRasterizer(got), image.Pt(3, 2), 2)
+		for i := range got.Pix {
+			if d := int(got.Pix[i]) - int(want.Pix[i]); d < -1 || d > 1 {
+				t.Errorf("%s: scale 2: pixel %d: got %d, want %d", filename, i/4, got.Pix[i], want.Pix[i])
+				break
+			}
+		}
+	}
+}
+
+// fillOnly hides the SetStroke method of its rasterizer.
+type fillOnly struct {
+	raster.Rasterizer
+}
+
+func TestReplayStroke(t *testing.T) {
+	stroke := raster.Stroke{Width: 3, Join: raster.JoinRound, Dashes: []float32{6, 2}}
+	draw := func(z raster.StrokeRasterizer) {
+		z.Reset(32, 32)
+		z.SetStroke(stroke)
+		z.MoveTo(4, 4)
+		z.QuadTo(28, 4, 28, 28)
+		z.LineTo(4, 20)
+		z.Draw(z.Bounds(), image.NewUniform(color.RGBA{0x40, 0x80, 0, 0xff}), image.Point{})
+	}
+	z := NewRasterizer(32, 32)
+	draw(z)
+	stroke.Dashes[0] = 1
+	if got := z.List.Layers[0].Stroke.Dashes[0]; got != 6 {
+		t.Errorf("Dashes[0]: got %v, want 6", got)
+	}
+
+	for _, scale := range []float32{1, 1.5} {
+		stroke.Width, stroke.Dashes = 3*scale, []float32{6 * scale, 2 * scale}
+		want := image.NewRGBA(image.Rect(0, 0, 48, 48))
+		zw := img.NewRasterizer(want)
+		draw(&scaledPath{zw, scale})
+
+		got := image.NewRGBA(image.Rect(0, 0, 48, 48))
+		z.List.Replay(fillOnly{img.NewRasterizer(got)}, image.Point{}, scale)
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("scale %v: pixels differ", scale)
+		}
+	}
+}
+
+// scaledPath scales the paths added to its rasterizer.
+type scaledPath struct {
+	*img.Rasterizer
+	s float32
+}
+
+func (z *scaledPath) Reset(w, h int) {
+	s := z.s
+	z.Rasterizer.Reset(int(float32(w)*s+0.999), int(float32(h)*s+0.999))
+}
+
+func (z *scaledPath) MoveTo(ax, ay float32) { z.Rasterizer.MoveTo(ax*z.s, ay*z.s) }
+func (z *scaledPath) LineTo(bx, by float32) { z.Rasterizer.LineTo(bx*z.s, by*z.s) }
+
+func (z *scaledPath) QuadTo(bx, by, cx, cy float32) {
+	z.Rasterizer.QuadTo(bx*z.s, by*z.s, cx*z.s, cy*z.s)
+}
+
+func (z *scaledPath) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	r.Max = r.Min.Add(z.Rasterizer.Size())
+	z.Rasterizer.Draw(r, src, sp)
+}
+
+func TestLayers(t *testing.T) {
+	z := NewRasterizer(32, 32)
+	z.MoveTo(4, 6)
+	z.CubeTo(10, 2, 20, 40, 30, 10)
+	z.ClosePath()
+	z.Draw(image.Rect(10, 10, 42, 42), image.Opaque, image.Point{})
+	z.Reset(32, 32)
+	z.Draw(z.Bounds(), image.Opaque, image.Point{})
+	z.SetStroke(raster.Stroke{Width: 2, Join: raster.JoinBevel})
+	z.MoveTo(4, 6)
+	z.LineTo(20, 6)
+	z.Draw(z.Bounds(), image.Opaque, image.Point{})
+
+	if n := len(z.List.Layers); n != 2 {
+		t.Fatalf("got %d layers, want 2", n)
+	}
+	l := z.List.Layers[0]
+	if got, want := l.Verbs, []Verb{MoveTo, CubeTo, ClosePath}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Verbs: got %v, want %v", got, want)
+	}
+	if got, want := len(l.Args), 8; got != want {
+		t.Errorf("len(Args): got %d, want %d", got, want)
+	}
+	if got, want := l.Bounds(), image.Rect(14, 12, 40, 42); got != want {
+		t.Errorf("Bounds: got %v, want %v", got, want)
+	}
+	// The stroke reaches √2 beyond the points, for a square cap.
+	if got, want := z.List.Layers[1].Bounds(), image.Rect(2, 4, 22, 8); got != want {
+		t.Errorf("stroke Bounds: got %v, want %v", got, want)
+	}
+}
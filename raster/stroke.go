@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package raster
+
+import (
+	"github.com/reactivego/ivg/internal/stroke"
+)
+
+// Cap is the shape at the ends of the open subpaths of a stroke.
+type Cap uint8
+
+const (
+	CapButt Cap = iota
+	CapRound
+	CapSquare
+)
+
+// Join is the shape at the corners of a stroke.
+type Join uint8
+
+const (
+	JoinMiter Join = iota
+	JoinRound
+	JoinBevel
+)
+
+// Stroke is the style of a stroke. Its fields have the meaning of the SVG
+// stroke-width, stroke-linecap, stroke-linejoin, stroke-miterlimit,
+// stroke-dasharray and stroke-dashoffset properties, and its zero value but
+// for the width is the SVG default.
+type Stroke struct {
+	Width float32
+	Cap   Cap
+	Join  Join
+	// MiterLimit is the limit on the ratio of the length of a miter join to
+	// the width of the stroke, beyond which a miter join becomes a bevel
+	// join. Zero means the SVG default of 4.
+	MiterLimit float32
+	// Dashes are the lengths of the dashes and the gaps between them, which
+	// alternate along each subpath. A pattern of odd length is repeated twice
+	// to make it even. No dashes, or dashes with a negative length or without
+	// any length, draw a solid stroke.
+	Dashes []float32
+	// DashOffset is the distance into the dash pattern at which each subpath
+	// starts.
+	DashOffset float32
+}
+
+// StrokeRasterizer is a Rasterizer that can also stroke the paths added via
+// the XxxTo calls, instead of filling them. It is an optional interface for
+// a Rasterizer, which is checked for with a type assertion.
+type StrokeRasterizer interface {
+	Rasterizer
+	// SetStroke sets the style of the stroke that Draw draws of the paths
+	// added after it, until the next call to SetStroke or Reset. A stroke
+	// that is not wider than zero fills the paths again, like Reset does.
+	SetStroke(s Stroke)
+}
+
+// Path is the part of a Rasterizer that adds paths, to which a Stroker adds
+// the outline of a stroke.
+type Path interface {
+	MoveTo(ax, ay float32)
+	LineTo(bx, by float32)
+	CubeTo(bx, by, cx, cy, dx, dy float32)
+	ClosePath()
+}
+
+// Stroker converts the stroke of a path to its outline, which is a path that
+// is filled with the nonzero winding rule. A Rasterizer that fills paths can
+// implement StrokeRasterizer by adding its paths to a Stroker, and adding the
+// outline of their stroke to itself before it draws. The zero value is a
+// Stroker with an empty path and the pen at (0, 0).
+type Stroker struct {
+	// Stroke is the style of the stroke of the path.
+	Stroke Stroke
+
+	path stroke.Path
+}
+
+// Reset empties the path and moves the pen back to (0, 0).
+func (s *Stroker) Reset() {
+	s.path.Reset()
+}
+
+// Pen returns the location of the path-drawing pen: the last argument to the
+// most recent XxxTo call.
+func (s *Stroker) Pen() (x, y float32) {
+	px, py := s.path.Pen()
+	return float32(px), float32(py)
+}
+
+// MoveTo starts a new subpath and moves the pen to (ax, ay).
+func (s *Stroker) MoveTo(ax, ay float32) {
+	s.path.MoveTo(float64(ax), float64(ay))
+}
+
+// LineTo adds a line segment, from the pen to (bx, by), and moves the pen to
+// (bx, by).
+func (s *Stroker) LineTo(bx, by float32) {
+	s.path.LineTo(float64(bx), float64(by))
+}
+
+// QuadTo adds a quadratic Bézier segment, from the pen via (bx, by) to (cx,
+// cy), and moves the pen to (cx, cy).
+func (s *Stroker) QuadTo(bx, by, cx, cy float32) {
+	s.path.QuadTo(float64(bx), float64(by), float64(cx), float64(cy))
+}
+
+// CubeTo adds a cubic Bézier segment, from the pen via (bx, by) and (cx, cy)
+// to (dx, dy), and moves the pen to (dx, dy).
+func (s *Stroker) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	s.path.CubeTo(float64(bx), float64(by), float64(cx), float64(cy), float64(dx), float64(dy))
+}
+
+// ClosePath closes the current subpath and moves the pen back to its start.
+func (s *Stroker) ClosePath() {
+	s.path.Close()
+}
+
+// Outline adds the outline of the stroke of the path to dst, as closed
+// subpaths, and then empties the path.
+func (s *Stroker) Outline(dst Path) {
+	p := &s.path
+	if len(s.Stroke.Dashes) > 0 {
+		dashes := make([]float64, len(s.Stroke.Dashes))
+		for i, d := range s.Stroke.Dashes {
+			dashes[i] = float64(d)
+		}
+		p = p.Dash(dashes, float64(s.Stroke.DashOffset))
+	}
+	p.Outline(stroke.Style{
+		Width:      float64(s.Stroke.Width),
+		Cap:        stroke.Cap(s.Stroke.Cap),
+		Join:       stroke.Join(s.Stroke.Join),
+		MiterLimit: float64(s.Stroke.MiterLimit),
+	}, sink{dst})
+	s.path.Reset()
+}
+
+// sink adds the outline of a stroke to a Path.
+type sink struct{ Path }
+
+func (s sink) MoveTo(x, y float64) { s.Path.MoveTo(float32(x), float32(y)) }
+func (s sink) LineTo(x, y float64) { s.Path.LineTo(float32(x), float32(y)) }
+func (s sink) Close()              { s.ClosePath() }
+
+func (s sink) CubeTo(x1, y1, x2, y2, x, y float64) {
+	s.Path.CubeTo(float32(x1), float32(y1), float32(x2), float32(y2), float32(x), float32(y))
+}
@@ -0,0 +1,368 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package svg provides a rasterizer that writes the paths it draws to an SVG
+// document, instead of rasterizing them, so that what is drawn stays
+// resolution independent.
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/reactivego/ivg/raster"
+)
+
+// Rasterizer records the paths added via the XxxTo calls in pixel space, and
+// writes them as a <path> element per Draw call to an SVG document of its
+// size. Sources that are an *image.Uniform become fill colors, and sources
+// that implement raster.GradientConfig become <linearGradient> and
+// <radialGradient> elements. Any other source becomes a <pattern> with the
+// part of the source that is drawn, as an embedded PNG image.
+//
+// Reset only resets the current path, so a document can be built by drawing
+// many paths, like a render.Renderer does.
+type Rasterizer struct {
+	size image.Point
+
+	path       []byte
+	pen, start [2]float32
+
+	stroke raster.Stroke
+
+	defs bytes.Buffer
+	body bytes.Buffer
+	// ids maps the definitions of gradients to their ids, to write each
+	// gradient once.
+	ids map[string]string
+	// patterns is the number of <pattern> elements written.
+	patterns int
+}
+
+// NewRasterizer returns a rasterizer for an SVG document with width w and
+// height h.
+func NewRasterizer(w, h int) *Rasterizer {
+	return &Rasterizer{
+		size: image.Pt(w, h),
+		ids:  make(map[string]string),
+	}
+}
+
+// Bytes returns the SVG document for the paths drawn so far.
+func (z *Rasterizer) Bytes() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		z.size.X, z.size.Y, z.size.X, z.size.Y)
+	if z.defs.Len() > 0 {
+		b.WriteString("<defs>\n")
+		b.Write(z.defs.Bytes())
+		b.WriteString("</defs>\n")
+	}
+	b.Write(z.body.Bytes())
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// Reset empties the current path and sets the size of the document to width
+// w and height h. It does not remove the paths drawn so far from the
+// document, and it stops stroking paths.
+func (z *Rasterizer) Reset(w, h int) {
+	z.size = image.Pt(w, h)
+	z.path = z.path[:0]
+	z.pen, z.start = [2]float32{}, [2]float32{}
+	z.stroke = raster.Stroke{}
+}
+
+func (z *Rasterizer) Size() image.Point {
+	return z.size
+}
+
+func (z *Rasterizer) Bounds() image.Rectangle {
+	return image.Rectangle{Max: z.size}
+}
+
+func (z *Rasterizer) Pen() (x, y float32) {
+	return z.pen[0], z.pen[1]
+}
+
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset, which becomes the
+// stroke attributes of their <path> elements. A stroke that is not wider than
+// zero fills the paths again.
+func (z *Rasterizer) SetStroke(s raster.Stroke) {
+	z.stroke = s
+}
+
+func (z *Rasterizer) MoveTo(ax, ay float32) {
+	z.path = appendPathData(z.path, 'M', ax, ay)
+	z.pen = [2]float32{ax, ay}
+	z.start = z.pen
+}
+
+func (z *Rasterizer) LineTo(bx, by float32) {
+	z.path = appendPathData(z.path, 'L', bx, by)
+	z.pen = [2]float32{bx, by}
+}
+
+func (z *Rasterizer) QuadTo(bx, by, cx, cy float32) {
+	z.path = appendPathData(z.path, 'Q', bx, by, cx, cy)
+	z.pen = [2]float32{cx, cy}
+}
+
+func (z *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	z.path = appendPathData(z.path, 'C', bx, by, cx, cy, dx, dy)
+	z.pen = [2]float32{dx, dy}
+}
+
+func (z *Rasterizer) ClosePath() {
+	z.path = append(z.path, 'Z')
+	z.pen = z.start
+}
+
+// Draw writes a <path> element for the current path, translated by r.Min,
+// that is painted with src aligned such that sp in src is at r.Min. Unlike
+// rasterizers that draw into an image, it does not clip the path to r. After
+// that, the current path is empty.
+func (z *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	if len(z.path) == 0 {
+		return
+	}
+	paint, ok := z.paint(r, src, sp)
+	if !ok {
+		z.path = z.path[:0]
+		return
+	}
+	z.body.WriteString("<path")
+	if r.Min != (image.Point{}) {
+		fmt.Fprintf(&z.body, ` transform="translate(%d %d)"`, r.Min.X, r.Min.Y)
+	}
+	if z.stroke.Width > 0 {
+		z.body.WriteString(` fill="none"` + paint.attrs("stroke") + strokeAttrs(z.stroke))
+	} else {
+		z.body.WriteString(paint.attrs("fill"))
+	}
+	z.body.WriteString(` d="`)
+	z.body.Write(z.path)
+	z.body.WriteString("\"/>\n")
+	z.path = z.path[:0]
+}
+
+// paintAttrs are the color and opacity of a fill or stroke.
+type paintAttrs struct {
+	color, opacity string
+}
+
+// attrs returns the attributes of p as the named paint, fill or stroke.
+func (p paintAttrs) attrs(name string) string {
+	s := ` ` + name + `="` + p.color + `"`
+	if p.opacity != "" {
+		s += ` ` + name + `-opacity="` + p.opacity + `"`
+	}
+	return s
+}
+
+// paint returns how to paint a path translated by r.Min with src aligned such
+// that sp is at r.Min. It returns false when the path would not be visible.
+func (z *Rasterizer) paint(r image.Rectangle, src image.Image, sp image.Point) (paintAttrs, bool) {
+	switch s := src.(type) {
+	case *image.Uniform:
+		c := color.RGBAModel.Convert(s.C).(color.RGBA)
+		if c.A == 0 {
+			return paintAttrs{}, false
+		}
+		rgb, opacity := unpremul(c)
+		return paintAttrs{rgb, opacity}, true
+	case raster.GradientConfig:
+		id, ok := z.gradient(s, sp)
+		return paintAttrs{color: "url(#" + id + ")"}, ok
+	}
+	id, ok := z.pattern(r, src, sp)
+	return paintAttrs{color: "url(#" + id + ")"}, ok
+}
+
+// gradient returns the id of the gradient element for g, with sp at the
+// origin of the path, adding the element to the defs if it is new. It
+// returns false if g has no stops or its transformation is degenerate.
+func (z *Rasterizer) gradient(g raster.GradientConfig, sp image.Point) (id string, ok bool) {
+	colors, offsets := g.StopColors(), g.StopOffsets()
+	if len(colors) == 0 || len(colors) != len(offsets) {
+		return "", false
+	}
+	type stop struct {
+		offset float64
+		color  color.RGBA
+	}
+	stops := make([]stop, len(colors), len(colors)+4)
+	for i := range colors {
+		stops[i] = stop{offsets[i], colors[i]}
+	}
+	if g.SpreadMethod() == 0 {
+		// SVG has no equivalent of spread none, so pad with transparent
+		// stops at offsets 0 and 1.
+		first, last := stops[0], stops[len(stops)-1]
+		if first.offset > 0 {
+			stops = append([]stop{{0, first.color}}, stops...)
+		}
+		if last.offset < 1 {
+			stops = append(stops, stop{1, last.color})
+		}
+		stops = append([]stop{{0, color.RGBA{}}}, stops...)
+		stops = append(stops, stop{1, color.RGBA{}})
+	}
+
+	// The transformation maps the pixel space of g to gradient space. The
+	// path is in the pixel space of g translated by -sp.
+	a, b, c, d, e, f := g.Transform()
+	c += a*float64(sp.X) + b*float64(sp.Y)
+	f += d*float64(sp.X) + e*float64(sp.Y)
+
+	var elem bytes.Buffer
+	name := "linearGradient"
+	if g.GradientShape() == 0 {
+		// The offset is a*x + b*y + c, which is 0 and 1 at the points
+		// (x1, y1) and (x2, y2) on the line through the origin in the
+		// direction (a, b).
+		dd := a*a + b*b
+		if dd == 0 {
+			return "", false
+		}
+		fmt.Fprintf(&elem, `linearGradient gradientUnits="userSpaceOnUse" x1="%s" y1="%s" x2="%s" y2="%s"`,
+			ftoa(-c*a/dd), ftoa(-c*b/dd), ftoa((1-c)*a/dd), ftoa((1-c)*b/dd))
+	} else {
+		// The transformation maps pixel space to gradient space, where the
+		// gradient is the unit circle. The gradientTransform maps the other
+		// way.
+		det := a*e - b*d
+		if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+			return "", false
+		}
+		ia, ib, id, ie := e/det, -b/det, -d/det, a/det
+		ic, iF := -(ia*c + ib*f), -(id*c + ie*f)
+		name = "radialGradient"
+		fmt.Fprintf(&elem, `radialGradient gradientUnits="userSpaceOnUse" cx="0" cy="0" r="1" gradientTransform="matrix(%s %s %s %s %s %s)"`,
+			ftoa(ia), ftoa(id), ftoa(ib), ftoa(ie), ftoa(ic), ftoa(iF))
+	}
+	switch g.SpreadMethod() {
+	case 2:
+		elem.WriteString(` spreadMethod="reflect"`)
+	case 3:
+		elem.WriteString(` spreadMethod="repeat"`)
+	}
+	elem.WriteString(">\n")
+	for _, s := range stops {
+		rgb, opacity := unpremul(s.color)
+		fmt.Fprintf(&elem, `<stop offset="%s" stop-color="%s"`, ftoa(s.offset), rgb)
+		if opacity != "" {
+			fmt.Fprintf(&elem, ` stop-opacity="%s"`, opacity)
+		}
+		elem.WriteString("/>\n")
+	}
+
+	key := elem.String()
+	if id, ok := z.ids[key]; ok {
+		return id, true
+	}
+	id = "g" + strconv.Itoa(len(z.ids))
+	z.ids[key] = id
+	z.defs.WriteString("<" + name + ` id="` + id + `"` + key[len(name):])
+	z.defs.WriteString("</" + name + ">\n")
+	return id, true
+}
+
+// pattern returns the id of a new pattern element with the part of src that
+// is drawn within r, as an embedded PNG image. It returns false if no part of
+// src is drawn.
+func (z *Rasterizer) pattern(r image.Rectangle, src image.Image, sp image.Point) (id string, ok bool) {
+	// The bounds of the part of src that is drawn, in the space of the path.
+	b := src.Bounds().Sub(sp).Intersect(r.Sub(r.Min))
+	if b.Empty() {
+		return "", false
+	}
+	m := image.NewNRGBA(b)
+	draw.Draw(m, b, src, b.Min.Add(sp), draw.Src)
+	var data bytes.Buffer
+	if err := png.Encode(&data, m); err != nil {
+		return "", false
+	}
+	id = "p" + strconv.Itoa(z.patterns)
+	z.patterns++
+	fmt.Fprintf(&z.defs, `<pattern id="%s" patternUnits="userSpaceOnUse" x="%d" y="%d" width="%d" height="%d">`+"\n",
+		id, b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+	fmt.Fprintf(&z.defs, `<image width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+		b.Dx(), b.Dy(), base64.StdEncoding.EncodeToString(data.Bytes()))
+	z.defs.WriteString("</pattern>\n")
+	return id, true
+}
+
+// strokeAttrs returns the attributes of a stroke in style s, but for its
+// color.
+func strokeAttrs(s raster.Stroke) string {
+	attrs := ` stroke-width="` + ftoa(float64(s.Width)) + `"`
+	switch s.Cap {
+	case raster.CapRound:
+		attrs += ` stroke-linecap="round"`
+	case raster.CapSquare:
+		attrs += ` stroke-linecap="square"`
+	}
+	switch s.Join {
+	case raster.JoinRound:
+		attrs += ` stroke-linejoin="round"`
+	case raster.JoinBevel:
+		attrs += ` stroke-linejoin="bevel"`
+	}
+	if s.MiterLimit != 0 && s.MiterLimit != 4 {
+		attrs += ` stroke-miterlimit="` + ftoa(float64(s.MiterLimit)) + `"`
+	}
+	if len(s.Dashes) > 0 {
+		attrs += ` stroke-dasharray="`
+		for i, d := range s.Dashes {
+			if i > 0 {
+				attrs += " "
+			}
+			attrs += ftoa(float64(d))
+		}
+		attrs += `"`
+		if s.DashOffset != 0 {
+			attrs += ` stroke-dashoffset="` + ftoa(float64(s.DashOffset)) + `"`
+		}
+	}
+	return attrs
+}
+
+func appendPathData(b []byte, verb byte, args ...float32) []byte {
+	b = append(b, verb)
+	for i, a := range args {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendFloat(b, float64(a), 'g', -1, 32)
+	}
+	return b
+}
+
+// unpremul returns the color and opacity of the alpha-premultiplied c. The
+// opacity is empty when c is opaque.
+func unpremul(c color.RGBA) (rgb string, opacity string) {
+	if c.A == 0 {
+		return "#000000", "0"
+	}
+	un := func(v uint8) uint32 { return (uint32(v)*0xff + uint32(c.A)/2) / uint32(c.A) }
+	rgb = fmt.Sprintf("#%02x%02x%02x", un(c.R), un(c.G), un(c.B))
+	if c.A != 0xff {
+		opacity = strconv.FormatFloat(float64(c.A)/0xff, 'g', 3, 64)
+	}
+	return rgb, opacity
+}
+
+func ftoa(f float64) string {
+	if f == 0 {
+		f = 0 // Avoid "-0".
+	}
+	return strconv.FormatFloat(f, 'g', -1, 32)
+}
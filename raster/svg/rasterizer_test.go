@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package svg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/internal/imagetest"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+	"github.com/reactivego/ivg/svgicon"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+// TestRenderRoundTrip renders the IconVG files in testdata to SVG, parses the
+// SVG again and compares the rendering with the PNG files.
+func TestRenderRoundTrip(t *testing.T) {
+	for _, filename := range []string{
+		"../../testdata/action-info.hires",
+		"../../testdata/arcs",
+		"../../testdata/cowbell",
+		"../../testdata/elliptical",
+		"../../testdata/favicon",
+		"../../testdata/gradient",
+		"../../testdata/video-005.primitive",
+	} {
+		ivgData, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", filename, err)
+			continue
+		}
+		vb, err := decode.DecodeViewBox(ivgData)
+		if err != nil {
+			t.Errorf("%s: DecodeViewBox: %v", filename, err)
+			continue
+		}
+		want, err := imagetest.DecodePNG(filepath.FromSlash(filename) + ".png")
+		if err != nil {
+			t.Errorf("%s: decodePNG: %v", filename, err)
+			continue
+		}
+
+		b := want.Bounds()
+		z := NewRasterizer(b.Dx(), b.Dy())
+		var r render.Renderer
+		r.SetRasterizer(z, b)
+		if err := decode.Decode(&r, ivgData); err != nil {
+			t.Errorf("%s: Decode: %v", filename, err)
+			continue
+		}
+
+		got := image.NewRGBA(b)
+		r.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src}, b)
+		if err := svgicon.Parse(&r, z.Bytes(), &svgicon.Options{ViewBox: vb}); err != nil {
+			t.Errorf("%s: Parse: %v", filename, err)
+			continue
+		}
+		if err := imagetest.CheckApproxEqual(got, want); err != nil {
+			t.Errorf("%s: %v", filename, err)
+		}
+	}
+}
+
+func TestRasterizer(t *testing.T) {
+	z := NewRasterizer(32, 16)
+	z.MoveTo(1, 2)
+	z.LineTo(3, 4)
+	z.QuadTo(5, 6, 7, 8)
+	z.CubeTo(9, 10, 11, 12, 13, 14.5)
+	z.ClosePath()
+	if x, y := z.Pen(); x != 1 || y != 2 {
+		t.Errorf("Pen: got (%g, %g), want (1, 2)", x, y)
+	}
+	z.Draw(z.Bounds(), image.NewUniform(color.RGBA{0x40, 0x00, 0x00, 0x80}), image.Point{})
+
+	z.Reset(32, 16)
+	z.SetStroke(raster.Stroke{Width: 2, Cap: raster.CapRound, Dashes: []float32{3, 1}, DashOffset: 1})
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.Draw(image.Rect(4, 5, 32, 16), image.Black, image.Point{})
+
+	// A transparent source draws nothing.
+	z.Reset(32, 16)
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.ClosePath()
+	z.Draw(z.Bounds(), image.Transparent, image.Point{})
+
+	// Any other source is a pattern of the part of it that is drawn.
+	m := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	z.MoveTo(0, 0)
+	z.LineTo(8, 0)
+	z.LineTo(8, 8)
+	z.ClosePath()
+	z.Draw(image.Rect(0, 0, 2, 3), m, image.Pt(1, 1))
+
+	got := string(z.Bytes())
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="32" height="16" viewBox="0 0 32 16">
+<defs>
+<pattern id="p0" patternUnits="userSpaceOnUse" x="0" y="0" width="2" height="3">
+<image width="2" height="3" href="data:image/png;base64,`
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("\ngot:\n%s\nwant prefix:\n%s", got, want)
+	}
+	want = `</pattern>
+</defs>
+<path fill="#800000" fill-opacity="0.502" d="M1 2L3 4Q5 6 7 8C9 10 11 12 13 14.5Z"/>
+<path transform="translate(4 5)" fill="none" stroke="#000000" stroke-width="2" stroke-linecap="round" stroke-dasharray="3 1" stroke-dashoffset="1" d="M0 0L8 0"/>
+<path fill="url(#p0)" d="M0 0L8 0L8 8Z"/>
+</svg>
+`
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("\ngot:\n%s\nwant suffix:\n%s", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package term provides a rasterizer that renders to a terminal, as Unicode
+// block characters in 24-bit ANSI colors.
+package term
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// Cell is the kind of block characters that a terminal character cell shows
+// pixels with.
+type Cell int
+
+const (
+	// HalfBlock shows 1 by 2 pixels per cell, with the upper and lower half
+	// block characters. Almost every terminal font has them.
+	HalfBlock Cell = iota
+	// Sextant shows 2 by 3 pixels per cell, with the sextant characters of
+	// Unicode 13, in at most two colors per cell.
+	Sextant
+)
+
+// Size returns the number of pixels that a character cell shows.
+func (c Cell) Size() image.Point {
+	if c == Sextant {
+		return image.Pt(2, 3)
+	}
+	return image.Pt(1, 2)
+}
+
+// rune returns the character that shows the pixels in bit mask pattern in the
+// foreground color, and the others in the background color. Bit i is the i'th
+// pixel of the cell, in rows from the top left.
+func (c Cell) rune(pattern int) rune {
+	switch c {
+	case Sextant:
+		switch pattern {
+		case 0:
+			return ' '
+		case 1 | 4 | 16:
+			return '▌'
+		case 2 | 8 | 32:
+			return '▐'
+		case 63:
+			return '█'
+		}
+		// The sextants are in the order of their pattern, without the ones
+		// above.
+		r := rune(0x1fb00 + pattern - 1)
+		if pattern > 1|4|16 {
+			r--
+		}
+		if pattern > 2|8|32 {
+			r--
+		}
+		return r
+	default:
+		return [4]rune{' ', '▀', '▄', '█'}[pattern&3]
+	}
+}
+
+// Encoder writes images as lines of text with ANSI escape codes for 24-bit
+// colors, which terminals like xterm, iTerm2, Windows Terminal, GNOME Terminal
+// and kitty show.
+type Encoder struct {
+	Cell Cell
+
+	// Background is the color that translucent pixels are composited on. When
+	// nil, pixels that are less than half opaque show the background of the
+	// terminal, and the other pixels show their color as if they were opaque.
+	Background color.Color
+}
+
+// Encode writes m to w, one character cell per Cell().Size() pixels, and one
+// line of text per row of cells. Each line ends with a newline, after the
+// colors have been reset.
+func (e *Encoder) Encode(w io.Writer, m image.Image) error {
+	_, err := w.Write(e.appendText(nil, m))
+	return err
+}
+
+// pixel is an unpremultiplied color that is opaque, unless transparent is
+// true.
+type pixel struct {
+	c           color.RGBA
+	transparent bool
+}
+
+// defaultColor is the color of an escape code that sets the default
+// background color of the terminal.
+var defaultColor = pixel{transparent: true}
+
+func (e *Encoder) appendText(b []byte, m image.Image) []byte {
+	var bg color.RGBA
+	if e.Background != nil {
+		bg = color.RGBAModel.Convert(e.Background).(color.RGBA)
+	}
+	at := func(x, y int) pixel {
+		if !(image.Point{x, y}).In(m.Bounds()) {
+			if e.Background != nil {
+				return pixel{c: bg}
+			}
+			return defaultColor
+		}
+		c := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+		if e.Background != nil {
+			// Composite c over the background, which is opaque.
+			a := 0xff - uint32(c.A)
+			return pixel{c: color.RGBA{
+				R: uint8(uint32(c.R) + uint32(bg.R)*a/0xff),
+				G: uint8(uint32(c.G) + uint32(bg.G)*a/0xff),
+				B: uint8(uint32(c.B) + uint32(bg.B)*a/0xff),
+				A: 0xff,
+			}}
+		}
+		if c.A < 0x80 {
+			return defaultColor
+		}
+		return pixel{c: color.RGBA{
+			R: uint8(uint32(c.R) * 0xff / uint32(c.A)),
+			G: uint8(uint32(c.G) * 0xff / uint32(c.A)),
+			B: uint8(uint32(c.B) * 0xff / uint32(c.A)),
+			A: 0xff,
+		}}
+	}
+
+	size := e.Cell.Size()
+	r := m.Bounds()
+	pixels := make([]pixel, size.X*size.Y)
+	for y := r.Min.Y; y < r.Max.Y; y += size.Y {
+		// fg and bg are the colors that the escape codes so far have set, of
+		// which fg is transparent while not set.
+		fg, bg := defaultColor, defaultColor
+		for x := r.Min.X; x < r.Max.X; x += size.X {
+			for i := range pixels {
+				pixels[i] = at(x+i%size.X, y+i/size.X)
+			}
+			pattern, cfg, cbg := split(pixels)
+			if pattern == 0 {
+				// Only the background shows, so keep the foreground.
+				cfg = fg
+			} else if cbg == fg && cfg == bg && !cbg.transparent {
+				// Swap the colors rather than setting both.
+				pattern ^= 1<<len(pixels) - 1
+				cfg, cbg = cbg, cfg
+			}
+			if cfg != fg {
+				b = append(b, "\x1b[38;2;"...)
+				b = appendRGB(b, cfg.c)
+				fg = cfg
+			}
+			if cbg != bg {
+				if cbg.transparent {
+					b = append(b, "\x1b[49m"...)
+				} else {
+					b = append(b, "\x1b[48;2;"...)
+					b = appendRGB(b, cbg.c)
+				}
+				bg = cbg
+			}
+			b = append(b, string(e.Cell.rune(pattern))...)
+		}
+		if fg != defaultColor || bg != defaultColor {
+			b = append(b, "\x1b[0m"...)
+		}
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// split splits the pixels of a cell in the pixels of the foreground, which are
+// in the pattern it returns, and those of the background, and returns their
+// colors. Transparent pixels are always in the background. Other pixels are
+// split in two clusters around the pair of pixels that differ the most, of
+// which the cluster of the first pixel of the pair is the foreground.
+func split(pixels []pixel) (pattern int, fg, bg pixel) {
+	var i, j, most int
+	transparent := false
+	for k, p := range pixels {
+		transparent = transparent || p.transparent
+		for l := k + 1; l < len(pixels); l++ {
+			if d := distance(p, pixels[l]); d > most {
+				i, j, most = k, l, d
+			}
+		}
+	}
+	var sums [2][3]int
+	var counts [2]int
+	for k, p := range pixels {
+		n := 1
+		if transparent {
+			if p.transparent {
+				continue
+			}
+			n = 0
+		} else if distance(p, pixels[i]) <= distance(p, pixels[j]) {
+			n = 0
+		}
+		if n == 0 {
+			pattern |= 1 << k
+		}
+		sums[n][0] += int(p.c.R)
+		sums[n][1] += int(p.c.G)
+		sums[n][2] += int(p.c.B)
+		counts[n]++
+	}
+	mean := func(n int) pixel {
+		if counts[n] == 0 {
+			return defaultColor
+		}
+		c := counts[n]
+		return pixel{c: color.RGBA{
+			R: uint8((sums[n][0] + c/2) / c),
+			G: uint8((sums[n][1] + c/2) / c),
+			B: uint8((sums[n][2] + c/2) / c),
+			A: 0xff,
+		}}
+	}
+	fg, bg = mean(0), mean(1)
+	if counts[1] == 0 && !transparent {
+		// All pixels are in the foreground, so show them as background.
+		return 0, defaultColor, fg
+	}
+	return pattern, fg, bg
+}
+
+// distance returns the squared distance between the colors of p and q, where
+// a transparent pixel is far from any other.
+func distance(p, q pixel) int {
+	if p.transparent || q.transparent {
+		if p.transparent == q.transparent {
+			return 0
+		}
+		return 3 * 0x100 * 0x100
+	}
+	dr := int(p.c.R) - int(q.c.R)
+	dg := int(p.c.G) - int(q.c.G)
+	db := int(p.c.B) - int(q.c.B)
+	return dr*dr + dg*dg + db*db
+}
+
+// appendRGB appends the parameters of a 24-bit color and the end of the escape
+// code to b.
+func appendRGB(b []byte, c color.RGBA) []byte {
+	b = strconv.AppendUint(b, uint64(c.R), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.G), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.B), 10)
+	return append(b, 'm')
+}
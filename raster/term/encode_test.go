@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package term
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/render"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+func TestSextantRunes(t *testing.T) {
+	for pattern, want := range map[int]rune{
+		0:  ' ',
+		1:  '\U0001fb00',
+		20: '\U0001fb13',
+		21: '▌',
+		22: '\U0001fb14',
+		41: '\U0001fb27',
+		42: '▐',
+		43: '\U0001fb28',
+		62: '\U0001fb3b',
+		63: '█',
+	} {
+		if got := Sextant.rune(pattern); got != want {
+			t.Errorf("pattern %d: got %U, want %U", pattern, got, want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	halfRed := color.RGBA{0x80, 0, 0, 0x80}
+	m := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for x, c := range []color.RGBA{red, red, blue, {}} {
+		m.SetRGBA(x, 0, c)
+	}
+	for x, c := range []color.RGBA{red, blue, red, halfRed} {
+		m.SetRGBA(x, 1, c)
+	}
+	m.SetRGBA(1, 2, blue)
+
+	testCases := []struct {
+		e    Encoder
+		want string
+	}{{
+		e: Encoder{Cell: HalfBlock},
+		want: "\x1b[48;2;255;0;0m \x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀▄\x1b[49m▄\x1b[0m\n" +
+			" \x1b[38;2;0;0;255m▀  \x1b[0m\n",
+	}, {
+		e: Encoder{Cell: HalfBlock, Background: color.White},
+		want: "\x1b[48;2;255;0;0m \x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀▄\x1b[38;2;255;255;255m\x1b[48;2;255;127;127m▀\x1b[0m\n" +
+			"\x1b[48;2;255;255;255m \x1b[38;2;0;0;255m▀  \x1b[0m\n",
+	}, {
+		e:    Encoder{Cell: Sextant},
+		want: "\x1b[38;2;153;0;102m\U0001fb2c\x1b[38;2;170;0;85m\U0001fb0c\x1b[0m\n",
+	}, {
+		e:    Encoder{Cell: Sextant, Background: color.White},
+		want: "\x1b[38;2;255;64;64m\x1b[48;2;0;0;255m\U0001fb15\x1b[38;2;128;0;128m\x1b[48;2;255;223;223m\U0001fb04\x1b[0m\n",
+	}}
+	for i, tc := range testCases {
+		var b bytes.Buffer
+		if err := tc.e.Encode(&b, m); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("test case %d:\ngot  %q\nwant %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestRasterizer(t *testing.T) {
+	ivgData, err := os.ReadFile(filepath.FromSlash("../../testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := NewRasterizer(24, 12, HalfBlock)
+	if got, want := z.Size(), image.Pt(24, 24); got != want {
+		t.Fatalf("Size: got %v, want %v", got, want)
+	}
+	var r render.Renderer
+	r.SetRasterizer(z, z.Bounds())
+	if err := decode.Decode(&r, ivgData); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if _, err := z.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	if len(lines) != 13 || lines[12] != "" {
+		t.Fatalf("got %d lines, want 12", len(lines)-1)
+	}
+	// The top and bottom rows of cells are blank, and the others show the
+	// black circle of the icon.
+	if want := strings.Repeat(" ", 24); lines[0] != want || lines[11] != want {
+		t.Errorf("got\n%q\n%q\nwant blank lines", lines[0], lines[11])
+	}
+	for _, line := range lines[1:11] {
+		if !strings.Contains(line, "\x1b[48;2;0;0;0m") {
+			t.Errorf("line %q does not have the black of the icon", line)
+		}
+	}
+}
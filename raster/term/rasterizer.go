@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package term
+
+import (
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/reactivego/ivg/raster/img"
+)
+
+// Rasterizer is an img.Rasterizer that draws into an image of the pixels of a
+// number of terminal character cells, which it writes as text with WriteTo.
+type Rasterizer struct {
+	img.Rasterizer
+
+	// Background is the color that translucent pixels are composited on, like
+	// the field of the Encoder.
+	Background color.Color
+
+	cell Cell
+	dst  *image.RGBA
+}
+
+// NewRasterizer returns a rasterizer for cols by rows character cells of kind
+// cell, whose size in pixels is that of cell times cols and rows.
+func NewRasterizer(cols, rows int, cell Cell) *Rasterizer {
+	s := cell.Size()
+	z := &Rasterizer{cell: cell, dst: image.NewRGBA(image.Rect(0, 0, cols*s.X, rows*s.Y))}
+	z.Dst = z.dst
+	z.Reset(z.dst.Rect.Dx(), z.dst.Rect.Dy())
+	return z
+}
+
+// Image returns the image that the rasterizer draws into.
+func (z *Rasterizer) Image() *image.RGBA {
+	return z.dst
+}
+
+// WriteTo writes the image that the rasterizer draws into to w, as text with
+// ANSI escape codes for a terminal.
+func (z *Rasterizer) WriteTo(w io.Writer) (int64, error) {
+	e := Encoder{Cell: z.cell, Background: z.Background}
+	n, err := w.Write(e.appendText(nil, z.dst))
+	return int64(n), err
+}
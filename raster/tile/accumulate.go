@@ -0,0 +1,82 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tile
+
+// This file contains the accumulation of golang.org/x/image/vector, which
+// sums the coverage deltas to the coverage of each pixel, changed to start
+// from the sum of the deltas before a tile.
+
+const (
+	// almost256 scales a floating point value in the range [0, 1] to a uint8
+	// value in the range [0x00, 0xff].
+	almost256 = 255.99998
+
+	// almost65536 scales a floating point value in the range [0, 1] to a
+	// uint16 value in the range [0x0000, 0xffff].
+	almost65536 = almost256 * 256
+)
+
+// fixedAccumulate replaces the deltas in buf by the coverage of their pixels,
+// where acc is the sum of the deltas before buf. Integers sum the same in any
+// order, so acc may be summed per tile.
+func fixedAccumulate(buf []uint32, acc int2ϕ) {
+	for i, v := range buf {
+		acc += int2ϕ(v)
+		buf[i] = fixedMask(acc)
+	}
+}
+
+func fixedMask(acc int2ϕ) uint32 {
+	a := acc
+	if a < 0 {
+		a = -a
+	}
+	a >>= 2*ϕ - 16
+	if a > 0xffff {
+		a = 0xffff
+	}
+	return uint32(a)
+}
+
+// floatingAccumulate sets mask, when not nil, to the coverage of the pixels
+// of the deltas, where acc is the sum of the deltas before them, and returns
+// the sum up to and including them. Floating point numbers do not sum the
+// same in every order, so it sums them in the order of the vector package,
+// which with SIMD sums the first blocks deltas in blocks of 4.
+func floatingAccumulate(mask []uint32, deltas []float32, acc float32, blocks int) float32 {
+	i := 0
+	if accumulateSIMD {
+		for ; i+4 <= len(deltas) && i+4 <= blocks; i += 4 {
+			s0, s1, s2, s3 := deltas[i], deltas[i+1], deltas[i+2], deltas[i+3]
+			x1, x2, x3 := s0+s1, s1+s2, s2+s3
+			x2, x3 = x2+s0, x3+x1
+			if mask != nil {
+				mask[i+0] = floatingMask(s0 + acc)
+				mask[i+1] = floatingMask(x1 + acc)
+				mask[i+2] = floatingMask(x2 + acc)
+				mask[i+3] = floatingMask(x3 + acc)
+			}
+			acc = x3 + acc
+		}
+	}
+	for ; i < len(deltas); i++ {
+		acc += deltas[i]
+		if mask != nil {
+			mask[i] = floatingMask(acc)
+		}
+	}
+	return acc
+}
+
+func floatingMask(acc float32) uint32 {
+	a := acc
+	if a < 0 {
+		a = -a
+	}
+	if a > 1 {
+		a = 1
+	}
+	return uint32(almost65536 * a)
+}
@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build !appengine && gc && !noasm
+// +build !appengine,gc,!noasm
+
+package tile
+
+import "golang.org/x/sys/cpu"
+
+// accumulateSIMD is whether the vector package sums the coverage deltas with
+// SIMD instructions, which it does when the CPU has SSE4.1.
+var accumulateSIMD = cpu.X86.HasSSE41
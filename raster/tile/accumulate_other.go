@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+//go:build !amd64 || appengine || !gc || noasm
+// +build !amd64 appengine !gc noasm
+
+package tile
+
+// accumulateSIMD is whether the vector package sums the coverage deltas with
+// SIMD instructions.
+const accumulateSIMD = false
@@ -0,0 +1,286 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tile
+
+// This file contains the line segment rasterization of
+// golang.org/x/image/vector, in fixed and floating point math, changed to
+// write only the rows of a tile. The arithmetic is the same to the bit, so
+// that the tiles together hold the same coverage deltas as the buffer of a
+// vector.Rasterizer. See the vector package for the derivations.
+
+import "math"
+
+const (
+	// ϕ is the number of binary digits after the fixed point.
+	ϕ = 9
+
+	fxOne          int1ϕ = 1 << ϕ
+	fxOneAndAHalf  int1ϕ = 1<<ϕ + 1<<(ϕ-1)
+	fxOneMinusIota int1ϕ = 1<<ϕ - 1 // Used for rounding up.
+)
+
+// int1ϕ is a signed fixed-point number with 1*ϕ binary digits after the fixed
+// point.
+type int1ϕ int32
+
+// int2ϕ is a signed fixed-point number with 2*ϕ binary digits after the fixed
+// point, which is how the fixed point deltas are stored as uint32.
+type int2ϕ int32
+
+func fixedMax(x, y int1ϕ) int1ϕ {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func fixedMin(x, y int1ϕ) int1ϕ {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func fixedFloor(x int1ϕ) int32 { return int32(x >> ϕ) }
+func fixedCeil(x int1ϕ) int32  { return int32((x + fxOneMinusIota) >> ϕ) }
+
+func floatingMax(x, y float32) float32 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func floatingMin(x, y float32) float32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func floatingFloor(x float32) int32 { return int32(math.Floor(float64(x))) }
+func floatingCeil(x float32) int32  { return int32(math.Ceil(float64(x))) }
+
+func lerp(t, px, py, qx, qy float32) (x, y float32) {
+	return px + t*(qx-px), py + t*(qy-py)
+}
+
+func clamp(i, width int32) uint {
+	if i < 0 {
+		return 0
+	}
+	if i < width {
+		return uint(i)
+	}
+	return uint(width)
+}
+
+// devSquared returns a measure of how curvy the sequence (ax, ay) to (bx, by)
+// to (cx, cy) is. It determines how many line segments will approximate a
+// Bézier curve segment.
+func devSquared(ax, ay, bx, by, cx, cy float32) float32 {
+	devx := ax - 2*bx + cx
+	devy := ay - 2*by + cy
+	return devx*devx + devy*devy
+}
+
+// tile is a band of rows of the coverage deltas of an image that is width
+// pixels wide and height pixels high.
+type tile struct {
+	width, height int32
+	// y0 and y1 are the first row and the row after the last row of the tile.
+	y0, y1 int32
+}
+
+// fixedLine adds the deltas of the line segment from (ax, ay) to (bx, by) in
+// the rows of t to buf, which holds those rows. Like a vector.Rasterizer, it
+// adds the deltas that are right of the last pixel of a row to the first pixel
+// of the next row, so it adds the deltas of the row above t that are there.
+func (t tile) fixedLine(buf []uint32, ax, ay, bx, by float32) {
+	dir := int1ϕ(1)
+	if ay > by {
+		dir, ax, ay, bx, by = -1, bx, by, ax, ay
+	}
+	// Horizontal line segments yield no change in coverage.
+	if by-ay <= 0.000001 {
+		return
+	}
+	dxdy := (bx - ax) / (by - ay)
+
+	ayϕ := int1ϕ(ay * float32(fxOne))
+	byϕ := int1ϕ(by * float32(fxOne))
+
+	x := int1ϕ(ax * float32(fxOne))
+	y := fixedFloor(ayϕ)
+	yMax := fixedCeil(byϕ)
+	if yMax > t.height {
+		yMax = t.height
+	}
+	if yMax > t.y1 {
+		yMax = t.y1
+	}
+	width := t.width
+
+	for ; y < yMax; y++ {
+		dy := fixedMin(int1ϕ(y+1)<<ϕ, byϕ) - fixedMax(int1ϕ(y)<<ϕ, ayϕ)
+		xNext := x + int1ϕ(float32(dy)*dxdy)
+		if y < 0 || y < t.y0-1 {
+			x = xNext
+			continue
+		}
+		// The deltas of row y start at index row of buf, which is negative
+		// for the row above t.
+		row := int((y - t.y0) * width)
+		add := func(xi int32, v uint32) {
+			if i := row + int(clamp(xi, width)); uint(i) < uint(len(buf)) {
+				buf[i] += v
+			}
+		}
+		d := dy * dir
+		x0, x1 := x, xNext
+		if x > xNext {
+			x0, x1 = x1, x0
+		}
+		x0i := fixedFloor(x0)
+		x0Floor := int1ϕ(x0i) << ϕ
+		x1i := fixedCeil(x1)
+		x1Ceil := int1ϕ(x1i) << ϕ
+
+		if x1i <= x0i+1 {
+			xmf := (x+xNext)>>1 - x0Floor
+			add(x0i+0, uint32(d*(fxOne-xmf)))
+			add(x0i+1, uint32(d*xmf))
+		} else {
+			oneOverS := x1 - x0
+			twoOverS := 2 * oneOverS
+			x0f := x0 - x0Floor
+			oneMinusX0f := fxOne - x0f
+			oneMinusX0fSquared := oneMinusX0f * oneMinusX0f
+			x1f := x1 - x1Ceil + fxOne
+			x1fSquared := x1f * x1f
+
+			D := oneMinusX0fSquared
+			D *= d
+			D /= twoOverS
+			add(x0i, uint32(D))
+
+			if x1i == x0i+2 {
+				D := twoOverS<<ϕ - oneMinusX0fSquared - x1fSquared
+				D *= d
+				D /= twoOverS
+				add(x0i+1, uint32(D))
+			} else {
+				D := (fxOneAndAHalf-x0f)<<(ϕ+1) - oneMinusX0fSquared
+				D *= d
+				D /= twoOverS
+				add(x0i+1, uint32(D))
+
+				dTimesS := uint32((d << (2 * ϕ)) / oneOverS)
+				for xi := x0i + 2; xi < x1i-1; xi++ {
+					add(xi, dTimesS)
+				}
+
+				const C = 1<<(ϕ+2) - fxOneAndAHalf<<1
+				D = x1f<<1 + C
+				D <<= ϕ
+				D -= x1fSquared
+				D *= d
+				D /= twoOverS
+				add(x1i-1, uint32(D))
+			}
+
+			D = x1fSquared
+			D *= d
+			D /= twoOverS
+			add(x1i, uint32(D))
+		}
+
+		x = xNext
+	}
+}
+
+// floatingLine is like fixedLine, in floating point math.
+func (t tile) floatingLine(buf []float32, ax, ay, bx, by float32) {
+	dir := float32(1)
+	if ay > by {
+		dir, ax, ay, bx, by = -1, bx, by, ax, ay
+	}
+	// Horizontal line segments yield no change in coverage.
+	if by-ay <= 0.000001 {
+		return
+	}
+	dxdy := (bx - ax) / (by - ay)
+
+	x := ax
+	y := floatingFloor(ay)
+	yMax := floatingCeil(by)
+	if yMax > t.height {
+		yMax = t.height
+	}
+	if yMax > t.y1 {
+		yMax = t.y1
+	}
+	width := t.width
+
+	for ; y < yMax; y++ {
+		dy := floatingMin(float32(y+1), by) - floatingMax(float32(y), ay)
+
+		// The "float32" in expressions like "float32(foo*bar)" disable the
+		// compiler's Fused Multiply Add (FMA) instruction selection, like in
+		// the vector package, to have the same rounding errors.
+		xNext := x + float32(dy*dxdy)
+		if y < 0 || y < t.y0-1 {
+			x = xNext
+			continue
+		}
+		row := int((y - t.y0) * width)
+		add := func(xi int32, v float32) {
+			if i := row + int(clamp(xi, width)); uint(i) < uint(len(buf)) {
+				buf[i] += v
+			}
+		}
+		d := float32(dy * dir)
+		x0, x1 := x, xNext
+		if x > xNext {
+			x0, x1 = x1, x0
+		}
+		x0i := floatingFloor(x0)
+		x0Floor := float32(x0i)
+		x1i := floatingCeil(x1)
+		x1Ceil := float32(x1i)
+
+		if x1i <= x0i+1 {
+			xmf := float32(0.5*(x+xNext)) - x0Floor
+			add(x0i+0, d-float32(d*xmf))
+			add(x0i+1, float32(d*xmf))
+		} else {
+			s := 1 / (x1 - x0)
+			x0f := x0 - x0Floor
+			oneMinusX0f := 1 - x0f
+			a0 := float32(0.5 * s * oneMinusX0f * oneMinusX0f)
+			x1f := x1 - x1Ceil + 1
+			am := float32(0.5 * s * x1f * x1f)
+
+			add(x0i, float32(d*a0))
+
+			if x1i == x0i+2 {
+				add(x0i+1, float32(d*(1-a0-am)))
+			} else {
+				a1 := float32(s * (1.5 - x0f))
+				add(x0i+1, float32(d*(a1-a0)))
+				dTimesS := float32(d * s)
+				for xi := x0i + 2; xi < x1i-1; xi++ {
+					add(xi, dTimesS)
+				}
+				a2 := a1 + float32(s*float32(x1i-x0i-3))
+				add(x1i-1, float32(d*(1-a2-am)))
+			}
+
+			add(x1i, float32(d*am))
+		}
+
+		x = xNext
+	}
+}
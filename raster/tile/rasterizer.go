@@ -0,0 +1,500 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+// Package tile provides a rasterizer that draws into an image in tiles, which
+// it rasterizes and composites concurrently.
+package tile
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/reactivego/ivg/raster"
+)
+
+// tileHeight is the number of rows of a tile. It is a multiple of 4, so that
+// every tile starts at a block of 4 pixels that the vector package sums with
+// SIMD instructions.
+const tileHeight = 16
+
+// floatingPointMathThreshold is the width or height above which the rasterizer
+// uses floating point math instead of fixed point math, like the vector
+// package.
+const floatingPointMathThreshold = 512
+
+// Rasterizer draws into the image Dst like an img.Rasterizer, pixel for pixel,
+// but splits the image in tiles that it rasterizes and composites
+// concurrently. The tiles are bands of rows as wide as the image, because the
+// coverage of a pixel depends on the pixels left of it in its row. Draw bins
+// the line segments of the path per tile, rasterizes the tiles concurrently,
+// sums the coverage from the first tile to the last, and then composites the
+// tiles concurrently.
+//
+// Dst and the source images must allow concurrent calls for different rows,
+// like the image types of the standard library do. It implements
+// raster.StrokeRasterizer by filling the outline of strokes.
+type Rasterizer struct {
+	// Dst is the image that the Draw call uses as destination to draw into.
+	Dst draw.Image
+
+	// DrawOp is a Porter-Duff compositing operator that will be used for the
+	// next call to the Draw method. After that call finishes, DrawOp is set to
+	// draw.Over.
+	DrawOp draw.Op
+
+	// Workers is the number of goroutines that Draw uses. When it is not
+	// positive, Draw uses runtime.GOMAXPROCS(0) goroutines.
+	Workers int
+
+	size       image.Point
+	floating   bool
+	first, pen [2]float32
+	// lines are the line segments of the path, as ax, ay, bx and by.
+	lines [][4]float32
+
+	// stroker records the paths to stroke, while its stroke is wider than
+	// zero.
+	stroker raster.Stroker
+
+	// bins are the indices in lines of the line segments per tile.
+	bins [][]int32
+	// fixed holds the deltas and then the coverage in fixed point math, and
+	// floats holds the deltas in floating point math, with the coverage in
+	// mask. fixedAcc and floatAcc are the sums of the deltas before each
+	// tile.
+	fixed    []uint32
+	floats   []float32
+	mask     []uint32
+	fixedAcc []int2ϕ
+	floatAcc []float32
+}
+
+// NewRasterizer returns a rasterizer for dst image, with the dst size used to
+// reset the rasterizer.
+func NewRasterizer(dst draw.Image) *Rasterizer {
+	z := &Rasterizer{Dst: dst}
+	s := dst.Bounds().Size()
+	z.Reset(s.X, s.Y)
+	return z
+}
+
+// Reset empties the path of the rasterizer and sets its size to width w and
+// height h, and stops stroking paths.
+func (z *Rasterizer) Reset(w, h int) {
+	z.size = image.Pt(w, h)
+	z.floating = w > floatingPointMathThreshold || h > floatingPointMathThreshold
+	z.first, z.pen = [2]float32{}, [2]float32{}
+	z.lines = z.lines[:0]
+	z.stroker = raster.Stroker{}
+}
+
+func (z *Rasterizer) Size() image.Point {
+	return z.size
+}
+
+func (z *Rasterizer) Bounds() image.Rectangle {
+	return image.Rectangle{Max: z.size}
+}
+
+// SetStroke sets the style of the stroke that Draw draws of the paths added
+// after it, until the next call to SetStroke or Reset. A stroke that is not
+// wider than zero fills the paths again.
+func (z *Rasterizer) SetStroke(s raster.Stroke) {
+	z.stroker.Stroke = s
+}
+
+func (z *Rasterizer) stroking() bool {
+	return z.stroker.Stroke.Width > 0
+}
+
+func (z *Rasterizer) Pen() (x, y float32) {
+	if z.stroking() {
+		return z.stroker.Pen()
+	}
+	return z.pen[0], z.pen[1]
+}
+
+func (z *Rasterizer) MoveTo(ax, ay float32) {
+	if z.stroking() {
+		z.stroker.MoveTo(ax, ay)
+	} else {
+		fill{z}.MoveTo(ax, ay)
+	}
+}
+
+func (z *Rasterizer) LineTo(bx, by float32) {
+	if z.stroking() {
+		z.stroker.LineTo(bx, by)
+	} else {
+		fill{z}.LineTo(bx, by)
+	}
+}
+
+func (z *Rasterizer) QuadTo(bx, by, cx, cy float32) {
+	if z.stroking() {
+		z.stroker.QuadTo(bx, by, cx, cy)
+	} else {
+		fill{z}.QuadTo(bx, by, cx, cy)
+	}
+}
+
+func (z *Rasterizer) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	if z.stroking() {
+		z.stroker.CubeTo(bx, by, cx, cy, dx, dy)
+	} else {
+		fill{z}.CubeTo(bx, by, cx, cy, dx, dy)
+	}
+}
+
+func (z *Rasterizer) ClosePath() {
+	if z.stroking() {
+		z.stroker.ClosePath()
+	} else {
+		fill{z}.ClosePath()
+	}
+}
+
+// fill adds paths to the path that is filled, which is where the outline of
+// strokes goes.
+type fill struct{ z *Rasterizer }
+
+func (f fill) MoveTo(ax, ay float32) {
+	f.z.first = [2]float32{ax, ay}
+	f.z.pen = f.z.first
+}
+
+func (f fill) LineTo(bx, by float32) {
+	z := f.z
+	z.lines = append(z.lines, [4]float32{z.pen[0], z.pen[1], bx, by})
+	z.pen = [2]float32{bx, by}
+}
+
+// QuadTo adds the quadratic Bézier segment as line segments, which are the
+// same as those of a vector.Rasterizer.
+func (f fill) QuadTo(bx, by, cx, cy float32) {
+	ax, ay := f.z.pen[0], f.z.pen[1]
+	devsq := devSquared(ax, ay, bx, by, cx, cy)
+	if devsq >= 0.333 {
+		const tol = 3
+		n := 1 + int(math.Sqrt(math.Sqrt(tol*float64(devsq))))
+		t, nInv := float32(0), 1/float32(n)
+		for i := 0; i < n-1; i++ {
+			t += nInv
+			abx, aby := lerp(t, ax, ay, bx, by)
+			bcx, bcy := lerp(t, bx, by, cx, cy)
+			f.LineTo(lerp(t, abx, aby, bcx, bcy))
+		}
+	}
+	f.LineTo(cx, cy)
+}
+
+// CubeTo adds the cubic Bézier segment as line segments, which are the same as
+// those of a vector.Rasterizer.
+func (f fill) CubeTo(bx, by, cx, cy, dx, dy float32) {
+	ax, ay := f.z.pen[0], f.z.pen[1]
+	devsq := devSquared(ax, ay, bx, by, dx, dy)
+	if devsqAlt := devSquared(ax, ay, cx, cy, dx, dy); devsq < devsqAlt {
+		devsq = devsqAlt
+	}
+	if devsq >= 0.333 {
+		const tol = 3
+		n := 1 + int(math.Sqrt(math.Sqrt(tol*float64(devsq))))
+		t, nInv := float32(0), 1/float32(n)
+		for i := 0; i < n-1; i++ {
+			t += nInv
+			abx, aby := lerp(t, ax, ay, bx, by)
+			bcx, bcy := lerp(t, bx, by, cx, cy)
+			cdx, cdy := lerp(t, cx, cy, dx, dy)
+			abcx, abcy := lerp(t, abx, aby, bcx, bcy)
+			bcdx, bcdy := lerp(t, bcx, bcy, cdx, cdy)
+			f.LineTo(lerp(t, abcx, abcy, bcdx, bcdy))
+		}
+	}
+	f.LineTo(dx, dy)
+}
+
+// ClosePath closes the path with a line segment to its first point, like a
+// vector.Rasterizer. Note that MoveTo does not close the path.
+func (f fill) ClosePath() {
+	f.LineTo(f.z.first[0], f.z.first[1])
+}
+
+// Draw aligns r.Min in field Dst with sp in src and then replaces the
+// rectangle r in Dst with the result of drawing src on Dst, like an
+// img.Rasterizer. The current value of the DrawOp field is used for drawing.
+// But note, after drawing the DrawOp is reset to draw.Over.
+func (z *Rasterizer) Draw(r image.Rectangle, src image.Image, sp image.Point) {
+	if z.stroking() {
+		z.stroker.Outline(fill{z})
+	}
+	op := z.DrawOp
+	z.DrawOp = draw.Over
+	w, h := int32(z.size.X), int32(z.size.Y)
+	if w <= 0 || h <= 0 {
+		return
+	}
+	n := int((h + tileHeight - 1) / tileHeight)
+	z.bin(n)
+	tiles := func(i int) (t tile, lo, hi int) {
+		t = tile{width: w, height: h, y0: int32(i) * tileHeight, y1: int32(i+1) * tileHeight}
+		if t.y1 > h {
+			t.y1 = h
+		}
+		return t, int(t.y0 * w), int(t.y1 * w)
+	}
+
+	// A tile without line segments has the coverage of the tiles before it
+	// in all its pixels, which leaves Dst as it is when that is zero and Dst
+	// is an image that composites with draw.Over to itself.
+	keep := false
+	if op == draw.Over {
+		switch z.Dst.(type) {
+		case *image.RGBA, *image.Alpha:
+			keep = true
+		}
+	}
+
+	if z.floating {
+		z.floats = grow(z.floats, int(w*h))
+		z.mask = growUint32(z.mask, int(w*h))
+		z.parallel(n, func(i int) {
+			if len(z.bins[i]) == 0 {
+				return
+			}
+			t, lo, hi := tiles(i)
+			buf := z.floats[lo:hi]
+			for j := range buf {
+				buf[j] = 0
+			}
+			for _, k := range z.bins[i] {
+				l := z.lines[k]
+				t.floatingLine(buf, l[0], l[1], l[2], l[3])
+			}
+		})
+		// Sum the coverage up to each tile, which is a sum of floating point
+		// numbers that must be in the same order as in the vector package.
+		z.floatAcc = z.floatAcc[:0]
+		acc := float32(0)
+		for i := 0; i < n; i++ {
+			z.floatAcc = append(z.floatAcc, acc)
+			if _, lo, hi := tiles(i); len(z.bins[i]) > 0 {
+				acc = floatingAccumulate(nil, z.floats[lo:hi], acc, len(z.floats)&^3-lo)
+			}
+		}
+		z.parallel(n, func(i int) {
+			_, lo, hi := tiles(i)
+			if len(z.bins[i]) == 0 {
+				if keep && floatingMask(z.floatAcc[i]) == 0 {
+					return
+				}
+				for j := lo; j < hi; j++ {
+					z.floats[j] = 0
+				}
+			}
+			floatingAccumulate(z.mask[lo:hi], z.floats[lo:hi], z.floatAcc[i], len(z.floats)&^3-lo)
+			z.composite(op, r, src, sp, z.mask, i*tileHeight, hi/int(w))
+		})
+	} else {
+		z.fixed = growUint32(z.fixed, int(w*h))
+		z.fixedAcc = z.fixedAcc[:0]
+		for i := 0; i < n; i++ {
+			z.fixedAcc = append(z.fixedAcc, 0)
+		}
+		z.parallel(n, func(i int) {
+			if len(z.bins[i]) == 0 {
+				return
+			}
+			t, lo, hi := tiles(i)
+			buf := z.fixed[lo:hi]
+			for j := range buf {
+				buf[j] = 0
+			}
+			for _, k := range z.bins[i] {
+				l := z.lines[k]
+				t.fixedLine(buf, l[0], l[1], l[2], l[3])
+			}
+			for _, v := range buf {
+				z.fixedAcc[i] += int2ϕ(v)
+			}
+		})
+		// Sum the coverage up to each tile, which is a sum of integers that
+		// may be in any order.
+		acc := int2ϕ(0)
+		for i, sum := range z.fixedAcc {
+			z.fixedAcc[i], acc = acc, acc+sum
+		}
+		z.parallel(n, func(i int) {
+			_, lo, hi := tiles(i)
+			if len(z.bins[i]) == 0 {
+				if keep && fixedMask(z.fixedAcc[i]) == 0 {
+					return
+				}
+				for j := lo; j < hi; j++ {
+					z.fixed[j] = 0
+				}
+			}
+			fixedAccumulate(z.fixed[lo:hi], z.fixedAcc[i])
+			z.composite(op, r, src, sp, z.fixed, i*tileHeight, hi/int(w))
+		})
+	}
+}
+
+// bin sets the bins of the n tiles to the line segments in the rows of the
+// tiles, or in the row above them, which adds to the first pixel of a tile.
+func (z *Rasterizer) bin(n int) {
+	for len(z.bins) < n {
+		z.bins = append(z.bins, nil)
+	}
+	z.bins = z.bins[:n]
+	for i := range z.bins {
+		z.bins[i] = z.bins[i][:0]
+	}
+	for k, l := range z.lines {
+		ay, by := l[1], l[3]
+		if ay > by {
+			ay, by = by, ay
+		}
+		if !(by-ay > 0.000001) {
+			continue
+		}
+		// The rows are widened by one, for rounding, and by another for the
+		// row above a tile.
+		lo := int(math.Floor(float64(ay))) - 1
+		hi := int(math.Ceil(float64(by))) + 2
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > n*tileHeight {
+			hi = n * tileHeight
+		}
+		for i := lo / tileHeight; i*tileHeight < hi; i++ {
+			z.bins[i] = append(z.bins[i], int32(k))
+		}
+	}
+}
+
+// parallel calls f for the tiles from 0 to n, with z.Workers goroutines.
+func (z *Rasterizer) parallel(n int, f func(i int)) {
+	workers := z.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	var next int32 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt32(&next, 1)); i < n; i = int(atomic.AddInt32(&next, 1)) {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func grow(b []float32, n int) []float32 {
+	if n > cap(b) {
+		return make([]float32, n)
+	}
+	return b[:n]
+}
+
+func growUint32(b []uint32, n int) []uint32 {
+	if n > cap(b) {
+		return make([]uint32, n)
+	}
+	return b[:n]
+}
+
+// composite draws src on Dst through the mask, in the rows from y0 to y1 of
+// the mask, like a vector.Rasterizer draws through its mask.
+func (z *Rasterizer) composite(op draw.Op, r image.Rectangle, src image.Image, sp image.Point, mask []uint32, y0, y1 int) {
+	if y1 > r.Dy() {
+		y1 = r.Dy()
+	}
+	x1 := r.Dx()
+	if x1 > z.size.X {
+		x1 = z.size.X
+	}
+	stride := z.size.X
+
+	if src, ok := src.(*image.Uniform); ok {
+		sr, sg, sb, sa := src.RGBA()
+		switch dst := z.Dst.(type) {
+		case *image.Alpha:
+			if sa == 0xffff {
+				for y := y0; y < y1; y++ {
+					pix := dst.Pix[dst.PixOffset(r.Min.X, r.Min.Y+y):]
+					for x := 0; x < x1; x++ {
+						ma := mask[y*stride+x]
+						if op == draw.Over {
+							a := 0xffff - ma
+							pix[x] = uint8((uint32(pix[x])*0x101*a/0xffff + ma) >> 8)
+						} else {
+							pix[x] = uint8(ma >> 8)
+						}
+					}
+				}
+				return
+			}
+		case *image.RGBA:
+			for y := y0; y < y1; y++ {
+				pix := dst.Pix[dst.PixOffset(r.Min.X, r.Min.Y+y):]
+				for x := 0; x < x1; x++ {
+					ma := mask[y*stride+x]
+					i := 4 * x
+					if op == draw.Over {
+						a := 0xffff - (sa * ma / 0xffff)
+						pix[i+0] = uint8(((uint32(pix[i+0])*0x101*a + sr*ma) / 0xffff) >> 8)
+						pix[i+1] = uint8(((uint32(pix[i+1])*0x101*a + sg*ma) / 0xffff) >> 8)
+						pix[i+2] = uint8(((uint32(pix[i+2])*0x101*a + sb*ma) / 0xffff) >> 8)
+						pix[i+3] = uint8(((uint32(pix[i+3])*0x101*a + sa*ma) / 0xffff) >> 8)
+					} else {
+						pix[i+0] = uint8((sr * ma / 0xffff) >> 8)
+						pix[i+1] = uint8((sg * ma / 0xffff) >> 8)
+						pix[i+2] = uint8((sb * ma / 0xffff) >> 8)
+						pix[i+3] = uint8((sa * ma / 0xffff) >> 8)
+					}
+				}
+			}
+			return
+		}
+	}
+
+	out := color.RGBA64{}
+	outc := color.Color(&out)
+	for y := y0; y < y1; y++ {
+		for x := 0; x < x1; x++ {
+			sr, sg, sb, sa := src.At(sp.X+x, sp.Y+y).RGBA()
+			ma := mask[y*stride+x]
+			if op == draw.Over {
+				dr, dg, db, da := z.Dst.At(r.Min.X+x, r.Min.Y+y).RGBA()
+				a := 0xffff - (sa * ma / 0xffff)
+				out.R = uint16((dr*a + sr*ma) / 0xffff)
+				out.G = uint16((dg*a + sg*ma) / 0xffff)
+				out.B = uint16((db*a + sb*ma) / 0xffff)
+				out.A = uint16((da*a + sa*ma) / 0xffff)
+			} else {
+				out.R = uint16(sr * ma / 0xffff)
+				out.G = uint16(sg * ma / 0xffff)
+				out.B = uint16(sb * ma / 0xffff)
+				out.A = uint16(sa * ma / 0xffff)
+			}
+			z.Dst.Set(r.Min.X+x, r.Min.Y+y, outc)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package tile
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/raster"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+var _ raster.StrokeRasterizer = (*Rasterizer)(nil)
+
+var filenames = []string{
+	"../../testdata/action-info.lores",
+	"../../testdata/arcs",
+	"../../testdata/blank",
+	"../../testdata/cowbell",
+	"../../testdata/elliptical",
+	"../../testdata/favicon",
+	"../../testdata/gradient",
+	"../../testdata/lod-polygon",
+	"../../testdata/video-005.primitive",
+}
+
+// pix returns the pixels of m.
+func pix(m draw.Image) []byte {
+	switch m := m.(type) {
+	case *image.RGBA:
+		return m.Pix
+	case *image.NRGBA:
+		return m.Pix
+	case *image.Alpha:
+		return m.Pix
+	}
+	panic("unsupported image")
+}
+
+// TestRender checks that the rasterizer renders the IconVG files in testdata
+// the same as an img.Rasterizer, to the bit, in fixed and floating point math.
+func TestRender(t *testing.T) {
+	newImages := []func(r image.Rectangle) draw.Image{
+		func(r image.Rectangle) draw.Image { return image.NewRGBA(r) },
+		func(r image.Rectangle) draw.Image { return image.NewNRGBA(r) },
+		func(r image.Rectangle) draw.Image { return image.NewAlpha(r) },
+	}
+	sizes := []image.Point{{48, 48}, {203, 77}, {530, 150}}
+	for _, filename := range filenames {
+		ivgData, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range sizes {
+			for k, newImage := range newImages {
+				r := image.Rectangle{Max: size}
+				want := newImage(r)
+				var z render.Renderer
+				z.SetRasterizer(&img.Rasterizer{Dst: want}, r)
+				if err := decode.Decode(&z, ivgData); err != nil {
+					t.Fatalf("%s: %v", filename, err)
+				}
+				for _, workers := range []int{1, 7} {
+					got := newImage(r)
+					z.SetRasterizer(&Rasterizer{Dst: got, Workers: workers}, r)
+					if err := decode.Decode(&z, ivgData); err != nil {
+						t.Fatalf("%s: %v", filename, err)
+					}
+					if !bytes.Equal(pix(got), pix(want)) {
+						t.Errorf("%s: size %v, image %d, workers %d: pixels differ", filename, size, k, workers)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestDraw checks the compositing of the sources and operators, for paths
+// that extend beyond the image, and strokes.
+func TestDraw(t *testing.T) {
+	var g render.Gradient
+	g.Init(render.ShapeRadial, render.SpreadReflect, render.Aff3{0.1, 0, -2, 0, 0.1, -2}, []render.Stop{
+		{Offset: 0, RGBA64: color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{Offset: 1, RGBA64: color.RGBA64{0, 0, 0x8080, 0x8080}},
+	})
+	sources := []image.Image{
+		image.NewUniform(color.RGBA{0x80, 0x40, 0x00, 0xff}),
+		image.NewUniform(color.RGBA{0x40, 0x20, 0x00, 0x80}),
+		&g,
+	}
+	draws := func(z raster.StrokeRasterizer, src image.Image, op draw.Op, dst draw.Image) {
+		r := z.Bounds()
+		z.Reset(r.Dx(), r.Dy())
+		z.MoveTo(-20, 5)
+		z.CubeTo(40, -30, 90, 80, 2000, 30.5)
+		z.LineTo(30, 2000)
+		z.QuadTo(-50, 40, 10, 10)
+		z.MoveTo(10.25, 20)
+		z.LineTo(50, 21)
+		z.LineTo(12, 60)
+		z.ClosePath()
+		switch z := z.(type) {
+		case *img.Rasterizer:
+			z.DrawOp = op
+		case *Rasterizer:
+			z.DrawOp = op
+		}
+		z.Draw(r, src, image.Pt(3, 1))
+
+		z.Reset(r.Dx(), r.Dy())
+		z.SetStroke(raster.Stroke{Width: 3.5, Join: raster.JoinRound, Dashes: []float32{7, 3}})
+		z.MoveTo(5, 5)
+		z.CubeTo(60, 0, 0, 60, float32(r.Dx()), float32(r.Dy()))
+		z.Draw(r, src, image.Point{})
+	}
+	for _, size := range []image.Point{{70, 90}, {520, 40}} {
+		for i, src := range sources {
+			for _, op := range []draw.Op{draw.Over, draw.Src} {
+				for k, newImage := range []func(r image.Rectangle) draw.Image{
+					func(r image.Rectangle) draw.Image { return image.NewRGBA(r) },
+					func(r image.Rectangle) draw.Image { return image.NewNRGBA(r) },
+					func(r image.Rectangle) draw.Image { return image.NewAlpha(r) },
+				} {
+					name := fmt.Sprintf("size %v, source %d, op %v, image %d", size, i, op, k)
+					r := image.Rectangle{Max: size}
+					want := newImage(r)
+					draw.Draw(want, r, image.NewUniform(color.RGBA{0x10, 0x20, 0x30, 0x40}), image.Point{}, draw.Src)
+					got := newImage(r)
+					draw.Draw(got, r, image.NewUniform(color.RGBA{0x10, 0x20, 0x30, 0x40}), image.Point{}, draw.Src)
+					draws(img.NewRasterizer(want), src, op, want)
+					draws(&Rasterizer{Dst: got, size: size, Workers: 3}, src, op, got)
+					if !bytes.Equal(pix(got), pix(want)) {
+						t.Errorf("%s: pixels differ", name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func benchmarkRender(b *testing.B, filename string, size int, newRasterizer func(dst draw.Image) raster.Rasterizer) {
+	ivgData, err := os.ReadFile(filepath.FromSlash(filename) + ".ivg")
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := image.Rect(0, 0, size, size)
+	dst := image.NewRGBA(r)
+	var z render.Renderer
+	z.SetRasterizer(newRasterizer(dst), r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := decode.Decode(&z, ivgData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderImg(b *testing.B) {
+	benchmarkRender(b, "../../testdata/video-005.primitive", 1024, func(dst draw.Image) raster.Rasterizer {
+		return img.NewRasterizer(dst)
+	})
+}
+
+func BenchmarkRenderTile(b *testing.B) {
+	benchmarkRender(b, "../../testdata/video-005.primitive", 1024, func(dst draw.Image) raster.Rasterizer {
+		return NewRasterizer(dst)
+	})
+}
@@ -10,8 +10,6 @@ import (
 	"math"
 )
 
-// TODO: gamma correction / non-linear color interpolation?
-
 // TODO: move this out of an internal directory, either under
 // golang.org/x/image or under the standard library's image, so that
 // golang.org/x/image/{draw,vector} and possibly image/draw can type switch on
@@ -85,6 +83,113 @@ func (s Spread) Clamp(x float64) float64 {
 	return -1
 }
 
+// Interpolation is the color space in which a gradient interpolates between
+// the colors of its stops.
+type Interpolation uint8
+
+const (
+	// InterpolateSRGB interpolates the alpha-premultiplied sRGB values of the
+	// stops, like SVG does.
+	InterpolateSRGB Interpolation = iota
+	// InterpolateLinear interpolates the stops in linear light, which keeps
+	// the colors between two saturated stops from turning dark and muddy.
+	InterpolateLinear
+	// InterpolateOKLab interpolates the stops in the OKLab perceptual color
+	// space, in which the lightness and hue change evenly.
+	InterpolateOKLab
+)
+
+// interpolate returns the color at t, from 0 to 1, between the stops of r,
+// interpolated in the color space i. Like in sRGB, the colors are weighed by
+// their alpha.
+func (i Interpolation) interpolate(r *Range, t float64) color.RGBA64 {
+	a0, a1 := r.A0/0xffff, r.A1/0xffff
+	c0 := i.from(r.R0, r.G0, r.B0, r.A0)
+	c1 := i.from(r.R1, r.G1, r.B1, r.A1)
+	s := 1 - t
+	a := s*a0 + t*a1
+	if !(a > 0) {
+		return color.RGBA64{}
+	}
+	var c [3]float64
+	for j := range c {
+		c[j] = (s*a0*c0[j] + t*a1*c1[j]) / a
+	}
+	return i.to(c, a)
+}
+
+// from converts the alpha-premultiplied 16-bit sRGB color (r, g, b, a) to the
+// color space i, without alpha.
+func (i Interpolation) from(r, g, b, a float64) [3]float64 {
+	if a == 0 {
+		return [3]float64{}
+	}
+	c := [3]float64{
+		srgbToLinear(math.Min(r/a, 1)),
+		srgbToLinear(math.Min(g/a, 1)),
+		srgbToLinear(math.Min(b/a, 1)),
+	}
+	if i == InterpolateOKLab {
+		c = linearToOKLab(c)
+	}
+	return c
+}
+
+// to converts the color c in the color space i, with alpha a from 0 to 1, to
+// an alpha-premultiplied 16-bit sRGB color. Colors outside of the sRGB gamut
+// are clipped.
+func (i Interpolation) to(c [3]float64, a float64) color.RGBA64 {
+	if i == InterpolateOKLab {
+		c = okLabToLinear(c)
+	}
+	v := func(x float64) uint16 {
+		return uint16(linearToSRGB(math.Max(0, math.Min(x, 1)))*a*0xffff + 0.5)
+	}
+	return color.RGBA64{v(c[0]), v(c[1]), v(c[2]), uint16(a*0xffff + 0.5)}
+}
+
+// srgbToLinear converts an sRGB component from 0 to 1 to linear light.
+func srgbToLinear(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear light component from 0 to 1 to sRGB.
+func linearToSRGB(x float64) float64 {
+	if x <= 0.0031308 {
+		return x * 12.92
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
+
+// linearToOKLab converts a linear light sRGB color to OKLab, as defined at
+// https://bottosson.github.io/posts/oklab/
+func linearToOKLab(c [3]float64) [3]float64 {
+	l := math.Cbrt(0.4122214708*c[0] + 0.5363325363*c[1] + 0.0514459929*c[2])
+	m := math.Cbrt(0.2119034982*c[0] + 0.6806995451*c[1] + 0.1073969566*c[2])
+	s := math.Cbrt(0.0883024619*c[0] + 0.2817188376*c[1] + 0.6299787005*c[2])
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// okLabToLinear converts an OKLab color to linear light sRGB.
+func okLabToLinear(c [3]float64) [3]float64 {
+	l := c[0] + 0.3963377774*c[1] + 0.2158037573*c[2]
+	m := c[0] - 0.1055613458*c[1] - 0.0638541728*c[2]
+	s := c[0] - 0.0894841775*c[1] - 1.2914855480*c[2]
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return [3]float64{
+		+4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	}
+}
+
 // Stop is an offset and color.
 type Stop struct {
 	Offset float64
@@ -159,6 +264,10 @@ type Gradient struct {
 	Shape  Shape
 	Spread Spread
 
+	// Interpolation is the color space in which the colors between the stops
+	// are interpolated.
+	Interpolation Interpolation
+
 	// Pix2Grad transforms coordinates from pixel space (the arguments to the
 	// Image.At method) to gradient space. Gradient space is where a linear
 	// gradient ranges from x == 0 to x == 1, and a radial gradient has center
@@ -208,6 +317,19 @@ func (g *Gradient) Bounds() image.Rectangle {
 
 // At satisfies the image.Image interface.
 func (g *Gradient) At(x, y int) color.Color {
+	return g.rgba64At(x, y)
+}
+
+// Span sets dst[i] to the color of the pixel (x+i, y), the same color that At
+// returns for it. It satisfies the raster.GradientSpanner interface, so that
+// rasterizers can fill a row of pixels at once.
+func (g *Gradient) Span(dst []color.RGBA64, x, y int) {
+	for i := range dst {
+		dst[i] = g.rgba64At(x+i, y)
+	}
+}
+
+func (g *Gradient) rgba64At(x, y int) color.RGBA64 {
 	if len(g.Ranges) == 0 {
 		return color.RGBA64{}
 	}
@@ -233,6 +355,9 @@ func (g *Gradient) At(x, y int) color.Color {
 	for _, r := range g.Ranges {
 		if r.Offset0 <= offset && offset <= r.Offset1 {
 			t := (offset - r.Offset0) / r.Width
+			if g.Interpolation != InterpolateSRGB {
+				return g.Interpolation.interpolate(&r, t)
+			}
 			s := 1 - t
 			return color.RGBA64{
 				uint16(s*r.R0 + t*r.R1),
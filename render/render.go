@@ -40,6 +40,17 @@ type Renderer struct {
 	scaleY float32
 	biasY  float32
 
+	// transform is the transformation set by SetTransform, which is applied
+	// after scale and bias when transformed is true. invTransform is its
+	// inverse, which is only valid when invertible is true.
+	transform    Aff3
+	invTransform Aff3
+	transformed  bool
+	invertible   bool
+
+	// interpolation is the color space set by SetInterpolation.
+	interpolation Interpolation
+
 	viewBox ivg.ViewBox
 	// Palette is a 64 color palette. When encoding, it is the suggested
 	// palette to place within the IconVG graphic. When decoding, it is either
@@ -97,6 +108,40 @@ func (z *Renderer) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
 	z.recalcTransform()
 }
 
+// SetTransform sets an affine transformation that is applied to the graphic
+// after its viewBox has been scaled to the rectangle passed to SetRasterizer.
+// It works in the pixel space of that rectangle, where (0, 0) is its top left
+// and (r.Dx(), r.Dy()) its bottom right corner, so rotating the graphic about
+// the center of the rectangle is
+//
+//	cx, cy := float64(r.Dx())/2, float64(r.Dy())/2
+//	sin, cos := math.Sincos(angle)
+//	z.SetTransform(Aff3{
+//		cos, -sin, cx - cos*cx + sin*cy,
+//		sin, cos, cy - sin*cx - cos*cy,
+//	})
+//
+// The transformation applies to gradients as well. It does not change the
+// level of detail, which still depends on the height of the rectangle. A
+// transformation that is not invertible draws nothing.
+//
+// Call SetTransform before calling Decode or between calls to Decode.
+func (z *Renderer) SetTransform(m Aff3) {
+	z.transform = m
+	z.transformed = true
+	z.invTransform, z.invertible = invert(m)
+}
+
+// SetInterpolation sets the color space in which gradients interpolate
+// between the colors of their stops. The default, InterpolateSRGB, matches how
+// SVG renders gradients. InterpolateLinear and InterpolateOKLab avoid the
+// muddy mid-tones of interpolating sRGB values.
+//
+// Call SetInterpolation before calling Decode or between calls to Decode.
+func (z *Renderer) SetInterpolation(i Interpolation) {
+	z.interpolation = i
+}
+
 func (z *Renderer) recalcTransform() {
 	z.scaleX = float32(z.r.Dx()) / (z.viewBox.MaxX - z.viewBox.MinX)
 	z.biasX = -z.viewBox.MinX
@@ -104,6 +149,34 @@ func (z *Renderer) recalcTransform() {
 	z.biasY = -z.viewBox.MinY
 }
 
+// concat returns the transformation that applies b and then a.
+func concat(a, b Aff3) Aff3 {
+	return Aff3{
+		a[0]*b[0] + a[1]*b[3],
+		a[0]*b[1] + a[1]*b[4],
+		a[0]*b[2] + a[1]*b[5] + a[2],
+		a[3]*b[0] + a[4]*b[3],
+		a[3]*b[1] + a[4]*b[4],
+		a[3]*b[2] + a[4]*b[5] + a[5],
+	}
+}
+
+// invert returns the inverse of m, if m is invertible.
+func invert(m Aff3) (Aff3, bool) {
+	det := m[0]*m[4] - m[1]*m[3]
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Aff3{}, false
+	}
+	return Aff3{
+		+m[4] / det,
+		-m[1] / det,
+		(m[1]*m[5] - m[4]*m[2]) / det,
+		-m[3] / det,
+		+m[0] / det,
+		(m[3]*m[2] - m[0]*m[5]) / det,
+	}, true
+}
+
 func (z *Renderer) CSel() uint8 {
 	return z.cSel
 }
@@ -138,20 +211,42 @@ func (z *Renderer) SetLOD(lod0, lod1 float32) {
 	z.lod0, z.lod1 = lod0, lod1
 }
 
-func (z *Renderer) unabsX(x float32) float32 { return x/z.scaleX - z.biasX }
-func (z *Renderer) unabsY(y float32) float32 { return y/z.scaleY - z.biasY }
-func (z *Renderer) absX(x float32) float32   { return z.scaleX * (x + z.biasX) }
-func (z *Renderer) absY(y float32) float32   { return z.scaleY * (y + z.biasY) }
-func (z *Renderer) relX(x float32) float32   { return z.scaleX * x }
-func (z *Renderer) relY(y float32) float32   { return z.scaleY * y }
+func (z *Renderer) absX(x float32) float32 { return z.scaleX * (x + z.biasX) }
+func (z *Renderer) absY(y float32) float32 { return z.scaleY * (y + z.biasY) }
+func (z *Renderer) relX(x float32) float32 { return z.scaleX * x }
+func (z *Renderer) relY(y float32) float32 { return z.scaleY * y }
 
 func (z *Renderer) absVec2(x, y float32) (zx, zy float32) {
-	return z.absX(x), z.absY(y)
+	zx, zy = z.absX(x), z.absY(y)
+	if z.transformed {
+		m := &z.transform
+		return affine(m, zx, zy, m[2], m[5])
+	}
+	return zx, zy
 }
 
 func (z *Renderer) relVec2(x, y float32) (zx, zy float32) {
 	px, py := z.z.Pen()
-	return px + z.relX(x), py + z.relY(y)
+	zx, zy = z.relX(x), z.relY(y)
+	if z.transformed {
+		zx, zy = affine(&z.transform, zx, zy, 0, 0)
+	}
+	return px + zx, py + zy
+}
+
+// unabsVec2 is the inverse of absVec2.
+func (z *Renderer) unabsVec2(x, y float32) (zx, zy float32) {
+	if z.transformed {
+		m := &z.invTransform
+		x, y = affine(m, x, y, m[2], m[5])
+	}
+	return x/z.scaleX - z.biasX, y/z.scaleY - z.biasY
+}
+
+// affine returns the linear part of m applied to (x, y), plus (tx, ty).
+func affine(m *Aff3, x, y float32, tx, ty float64) (zx, zy float32) {
+	return float32(m[0]*float64(x) + m[1]*float64(y) + tx),
+		float32(m[3]*float64(x) + m[4]*float64(y) + ty)
 }
 
 // implicitSmoothPoint returns the implicit control point for smooth-quadratic
@@ -220,7 +315,13 @@ func (z *Renderer) initGradient(rgba color.RGBA) (ok bool) {
 		e * invZSY,
 		f - d*zBX - e*zBY,
 	}
+	if z.transformed {
+		// The pixel space of pix2Grad is before the transformation set by
+		// SetTransform.
+		pix2Grad = concat(pix2Grad, z.invTransform)
+	}
 
+	z.gradient.Interpolation = z.interpolation
 	return z.gradient.Init(Shape(shape), Spread(spread), pix2Grad, z.stops[:nStops])
 }
 
@@ -240,7 +341,7 @@ func (z *Renderer) StartPath(adj uint8, x, y float32) {
 
 	width, height := z.r.Dx(), z.r.Dy()
 	h := float32(height)
-	z.disabled = z.disabled || !(z.lod0 <= h && h < z.lod1)
+	z.disabled = z.disabled || z.transformed && !z.invertible || !(z.lod0 <= h && h < z.lod1)
 	if z.disabled {
 		return
 	}
@@ -280,8 +381,13 @@ func (z *Renderer) AbsHLineTo(x float32) {
 	if z.disabled {
 		return
 	}
-	_, py := z.z.Pen()
+	px, py := z.z.Pen()
 	z.prevSmoothType = smoothTypeNone
+	if z.transformed {
+		_, gy := z.unabsVec2(px, py)
+		z.z.LineTo(z.absVec2(x, gy))
+		return
+	}
 	z.z.LineTo(z.absX(x), py)
 }
 
@@ -289,17 +395,21 @@ func (z *Renderer) RelHLineTo(x float32) {
 	if z.disabled {
 		return
 	}
-	px, py := z.z.Pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(px+z.relX(x), py)
+	z.z.LineTo(z.relVec2(x, 0))
 }
 
 func (z *Renderer) AbsVLineTo(y float32) {
 	if z.disabled {
 		return
 	}
-	px, _ := z.z.Pen()
+	px, py := z.z.Pen()
 	z.prevSmoothType = smoothTypeNone
+	if z.transformed {
+		gx, _ := z.unabsVec2(px, py)
+		z.z.LineTo(z.absVec2(gx, y))
+		return
+	}
 	z.z.LineTo(px, z.absY(y))
 }
 
@@ -307,9 +417,8 @@ func (z *Renderer) RelVLineTo(y float32) {
 	if z.disabled {
 		return
 	}
-	px, py := z.z.Pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(px, py+z.relY(y))
+	z.z.LineTo(z.relVec2(0, y))
 }
 
 func (z *Renderer) AbsLineTo(x, y float32) {
@@ -444,7 +553,7 @@ func (z *Renderer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool,
 	Rx := math.Abs(float64(rx))
 	Ry := math.Abs(float64(ry))
 	if !(Rx > 0 && Ry > 0) {
-		z.z.LineTo(x, y)
+		z.z.LineTo(z.absVec2(x, y))
 		return
 	}
 
@@ -454,11 +563,11 @@ func (z *Renderer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool,
 	// factors can be different, and aren't trivial to calculate due to
 	// xAxisRotation.
 	//
-	// We convert back to destination image coordinates via absX and absY calls
+	// We convert back to destination image coordinates via absVec2 calls
 	// later, during arcSegmentTo.
-	penX, penY := z.z.Pen()
-	x1 := float64(z.unabsX(penX))
-	y1 := float64(z.unabsY(penY))
+	penX, penY := z.unabsVec2(z.z.Pen())
+	x1 := float64(penX)
+	y1 := float64(penY)
 	x2 := float64(x)
 	y2 := float64(y)
 
@@ -561,14 +670,10 @@ func (z *Renderer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool,
 		y2 := ry * (+sin2 - t*cos2)
 		x3 := rx * (+cos2)
 		y3 := ry * (+sin2)
-		z.z.CubeTo(
-			z.absX(float32(cx+cosPhi*x1-sinPhi*y1)),
-			z.absY(float32(cy+sinPhi*x1+cosPhi*y1)),
-			z.absX(float32(cx+cosPhi*x2-sinPhi*y2)),
-			z.absY(float32(cy+sinPhi*x2+cosPhi*y2)),
-			z.absX(float32(cx+cosPhi*x3-sinPhi*y3)),
-			z.absY(float32(cy+sinPhi*x3+cosPhi*y3)),
-		)
+		zx1, zy1 := z.absVec2(float32(cx+cosPhi*x1-sinPhi*y1), float32(cy+sinPhi*x1+cosPhi*y1))
+		zx2, zy2 := z.absVec2(float32(cx+cosPhi*x2-sinPhi*y2), float32(cy+sinPhi*x2+cosPhi*y2))
+		zx3, zy3 := z.absVec2(float32(cx+cosPhi*x3-sinPhi*y3), float32(cy+sinPhi*x3+cosPhi*y3))
+		z.z.CubeTo(zx1, zy1, zx2, zy2, zx3, zy3)
 	}
 	for i := 0; i < n; i++ {
 		arcSegmentTo(cx, cy,
@@ -580,6 +685,6 @@ func (z *Renderer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool,
 }
 
 func (z *Renderer) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
-	ax, ay := z.relVec2(x, y)
-	z.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, z.unabsX(ax), z.unabsY(ay))
+	ax, ay := z.unabsVec2(z.relVec2(x, y))
+	z.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, ax, ay)
 }
@@ -2,11 +2,16 @@ package render
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
 	"github.com/reactivego/ivg/raster/img"
 )
 
@@ -43,3 +48,135 @@ func TestInvalidAlphaPremultipliedColor(t *testing.T) {
 		t.Errorf("got [% 02x], want [% 02x]", got, want)
 	}
 }
+
+// TestSetTransform tests that rendering with a transformation that maps the
+// pixel grid onto itself moves the pixels of the rendering without it.
+func TestSetTransform(t *testing.T) {
+	const n = 64
+	testCases := []struct {
+		desc string
+		m    Aff3
+		// pixel returns the pixel that (x, y) moves to.
+		pixel func(x, y int) (int, int)
+	}{{
+		desc:  "mirror",
+		m:     Aff3{-1, 0, n, 0, 1, 0},
+		pixel: func(x, y int) (int, int) { return n - 1 - x, y },
+	}, {
+		desc:  "rotate",
+		m:     Aff3{0, -1, n, 1, 0, 0},
+		pixel: func(x, y int) (int, int) { return n - 1 - y, x },
+	}, {
+		desc:  "translate",
+		m:     Aff3{1, 0, 3, 0, 1, -5},
+		pixel: func(x, y int) (int, int) { return x + 3, y - 5 },
+	}}
+	for _, filename := range []string{
+		"../testdata/arcs.ivg",
+		"../testdata/cowbell.ivg",
+		"../testdata/gradient.ivg",
+		"../testdata/video-005.primitive.ivg",
+	} {
+		ivgData, err := os.ReadFile(filepath.FromSlash(filename))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		want := image.NewRGBA(image.Rect(0, 0, n, n))
+		var z Renderer
+		z.SetRasterizer(&img.Rasterizer{Dst: want, DrawOp: draw.Src}, want.Bounds())
+		if err := decode.Decode(&z, ivgData); err != nil {
+			t.Fatalf("%s: Decode: %v", filename, err)
+		}
+		for _, tc := range testCases {
+			got := image.NewRGBA(image.Rect(0, 0, n, n))
+			z.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src}, got.Bounds())
+			z.SetTransform(tc.m)
+			if err := decode.Decode(&z, ivgData); err != nil {
+				t.Fatalf("%s: Decode: %v", filename, err)
+			}
+			if err := checkMoved(got, want, tc.pixel); err != nil {
+				t.Errorf("%s: %s: %v", filename, tc.desc, err)
+			}
+			z.SetTransform(Aff3{1, 0, 0, 0, 1, 0})
+		}
+	}
+}
+
+func checkMoved(got, want *image.RGBA, pixel func(x, y int) (int, int)) error {
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			gx, gy := pixel(x, y)
+			if !(image.Point{gx, gy}).In(b) {
+				continue
+			}
+			// The rasterizer accumulates coverage along rows, so edges
+			// round differently once rotated, and layers of translucent
+			// paths add up those differences.
+			const D = 0xff * 6 / 100
+			i, j := got.PixOffset(gx, gy), want.PixOffset(x, y)
+			for k := 0; k < 4; k++ {
+				if d := int(got.Pix[i+k]) - int(want.Pix[j+k]); d < -D || +D < d {
+					return fmt.Errorf("at (%d, %d): got RGBA % 02x, want RGBA % 02x",
+						x, y, got.Pix[i:i+4], want.Pix[j:j+4])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TestSetTransformNotInvertible tests that a transformation that is not
+// invertible draws nothing.
+func TestSetTransformNotInvertible(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	var z Renderer
+	z.SetRasterizer(img.NewRasterizer(dst), dst.Bounds())
+	z.SetTransform(Aff3{1, 1, 0, 1, 1, 0})
+	z.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	z.SetCReg(0, false, ivg.RGBAColor(color.RGBA{0x55, 0x00, 0x00, 0x66}))
+	z.StartPath(0, -32, -32)
+	z.AbsHLineTo(32)
+	z.AbsVLineTo(32)
+	z.AbsHLineTo(-32)
+	z.ClosePathEndPath()
+	if got := dst.Pix; !bytes.Equal(got, make([]byte, len(got))) {
+		t.Errorf("got [% 02x], want all zeroes", got)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	black := color.RGBA64{0, 0, 0, 0xffff}
+	white := color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}
+	red := color.RGBA64{0xffff, 0, 0, 0xffff}
+	green := color.RGBA64{0, 0xffff, 0, 0xffff}
+	testCases := []struct {
+		interpolation Interpolation
+		c0, c1        color.RGBA64
+		want          color.RGBA64
+	}{
+		{InterpolateSRGB, black, white, color.RGBA64{0x7fff, 0x7fff, 0x7fff, 0xffff}},
+		{InterpolateLinear, black, white, color.RGBA64{0xbc40, 0xbc40, 0xbc40, 0xffff}},
+		{InterpolateLinear, red, green, color.RGBA64{0xbc40, 0xbc40, 0, 0xffff}},
+		// The color of a transparent stop does not bleed into the other.
+		{InterpolateLinear, red, color.RGBA64{}, color.RGBA64{0x8000, 0, 0, 0x8000}},
+		// The lightness of black and white is 0 and 1 in OKLab.
+		{InterpolateOKLab, black, white, color.RGBA64{0x6374, 0x6374, 0x6374, 0xffff}},
+		{InterpolateOKLab, red, color.RGBA64{}, color.RGBA64{0x8000, 0, 0, 0x8000}},
+	}
+	near := func(a, b uint16) bool { return int(a)-int(b) <= 0x20 && int(b)-int(a) <= 0x20 }
+	for _, tc := range testCases {
+		g := Gradient{Interpolation: tc.interpolation}
+		// The center of pixel 49 is halfway between the stops.
+		g.Init(ShapeLinear, SpreadPad, Aff3{0.01, 0, 0.005, 0, 1, 0}, []Stop{{0, tc.c0}, {1, tc.c1}})
+		for _, p := range []struct {
+			x    int
+			want color.RGBA64
+		}{{-1, tc.c0}, {49, tc.want}, {100, tc.c1}} {
+			got := g.At(p.x, 0).(color.RGBA64)
+			if !near(got.R, p.want.R) || !near(got.G, p.want.G) || !near(got.B, p.want.B) || !near(got.A, p.want.A) {
+				t.Errorf("interpolation %d, %v to %v, x %d: got %v, want %v", tc.interpolation, tc.c0, tc.c1, p.x, got, p.want)
+			}
+		}
+	}
+}
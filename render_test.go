@@ -5,7 +5,6 @@
 package ivg_test
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -16,6 +15,7 @@ import (
 	"testing"
 
 	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/internal/imagetest"
 	"github.com/reactivego/ivg/raster/img"
 	"github.com/reactivego/ivg/render"
 )
@@ -37,49 +37,6 @@ func encodePNG(dstFilename string, src image.Image) error {
 	return closeErr
 }
 
-func decodePNG(srcFilename string) (image.Image, error) {
-	f, err := os.Open(srcFilename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	return png.Decode(f)
-}
-
-func checkApproxEqual(m0, m1 image.Image) error {
-	diff := func(a, b uint32) uint32 {
-		if a < b {
-			return b - a
-		}
-		return a - b
-	}
-
-	bounds0 := m0.Bounds()
-	bounds1 := m1.Bounds()
-	if bounds0 != bounds1 {
-		return fmt.Errorf("bounds differ: got %v, want %v", bounds0, bounds1)
-	}
-	for y := bounds0.Min.Y; y < bounds0.Max.Y; y++ {
-		for x := bounds0.Min.X; x < bounds0.Max.X; x++ {
-			r0, g0, b0, a0 := m0.At(x, y).RGBA()
-			r1, g1, b1, a1 := m1.At(x, y).RGBA()
-
-			// TODO: be more principled in picking this magic threshold, other
-			// than what the difference is, in practice, in x/image/vector's
-			// fixed and floating point rasterizer?
-			const D = 0xffff * 12 / 100 // Diff threshold of 12%.
-
-			if diff(r0, r1) > D || diff(g0, g1) > D || diff(b0, b1) > D || diff(a0, a1) > D {
-				return fmt.Errorf("at (%d, %d):\n"+
-					"got  RGBA %#04x, %#04x, %#04x, %#04x\n"+
-					"want RGBA %#04x, %#04x, %#04x, %#04x",
-					x, y, r0, g0, b0, a0, r1, g1, b1, a1)
-			}
-		}
-	}
-	return nil
-}
-
 var testdataTestCases = []struct {
 	filename string
 	variants string
@@ -88,10 +45,10 @@ var testdataTestCases = []struct {
 	{"testdata/action-info.hires", ""},
 	{"testdata/arcs", ""},
 	{"testdata/blank", ""},
-	{"testdata/cowbell", ""},
+	{"testdata/cowbell", ";linear;oklab"},
 	{"testdata/elliptical", ""},
 	{"testdata/favicon", ";pink"},
-	{"testdata/gradient", ""},
+	{"testdata/gradient", ";linear;oklab"},
 	{"testdata/lod-polygon", ";64"},
 	{"testdata/video-005.primitive", ""},
 }
@@ -130,7 +87,14 @@ func TestRenderer(t *testing.T) {
 			bounds := image.Rect(0, 0, width, height)
 			got := image.NewRGBA(bounds)
 			var z render.Renderer
-			z.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src}, got.Bounds())
+			// The linear variant also composites in linear light.
+			switch variant {
+			case "linear":
+				z.SetInterpolation(render.InterpolateLinear)
+			case "oklab":
+				z.SetInterpolation(render.InterpolateOKLab)
+			}
+			z.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src, LinearLight: variant == "linear"}, got.Bounds())
 			if err := decode.Decode(&z, ivgData, opts...); err != nil {
 				t.Errorf("%s %q variant: Decode: %v", tc.filename, variant, err)
 				continue
@@ -147,12 +111,12 @@ func TestRenderer(t *testing.T) {
 				}
 				continue
 			}
-			want, err := decodePNG(wantFilename)
+			want, err := imagetest.DecodePNG(wantFilename)
 			if err != nil {
 				t.Errorf("%s %q variant: decodePNG: %v", tc.filename, variant, err)
 				continue
 			}
-			if err := checkApproxEqual(got, want); err != nil {
+			if err := imagetest.CheckApproxEqual(got, want); err != nil {
 				t.Errorf("%s %q variant: %v", tc.filename, variant, err)
 				continue
 			}
@@ -0,0 +1,150 @@
+package svgicon
+
+import (
+	"math"
+
+	"github.com/reactivego/ivg"
+)
+
+// bounds is a partial ivg.Destination that computes the bounding box of the
+// path segments sent to it, in the style of an SVG object bounding box. Only
+// the path drawing methods are implemented. The box is that of the
+// segments' end points and control points, widened for arcs, so it contains
+// the box of the exact geometry but may be larger.
+type bounds struct {
+	ivg.Destination
+	minX, minY, maxX, maxY float32
+	x, y                   float32
+	startX, startY         float32
+	empty                  bool
+}
+
+func newBounds() *bounds {
+	return &bounds{
+		minX: float32(math.Inf(+1)), minY: float32(math.Inf(+1)),
+		maxX: float32(math.Inf(-1)), maxY: float32(math.Inf(-1)),
+		empty: true,
+	}
+}
+
+// rect returns the bounding box as x, y, width and height.
+func (b *bounds) rect() (x, y, w, h float32) {
+	if b.empty {
+		return 0, 0, 0, 0
+	}
+	return b.minX, b.minY, b.maxX - b.minX, b.maxY - b.minY
+}
+
+func (b *bounds) add(x, y float32) {
+	b.empty = false
+	if x < b.minX {
+		b.minX = x
+	}
+	if x > b.maxX {
+		b.maxX = x
+	}
+	if y < b.minY {
+		b.minY = y
+	}
+	if y > b.maxY {
+		b.maxY = y
+	}
+}
+
+func (b *bounds) moveTo(x, y float32) {
+	b.x, b.y = x, y
+	b.startX, b.startY = x, y
+	b.add(x, y)
+}
+
+func (b *bounds) lineTo(x, y float32) {
+	b.x, b.y = x, y
+	b.add(x, y)
+}
+
+func (b *bounds) StartPath(adj uint8, x, y float32) { b.moveTo(x, y) }
+func (b *bounds) ClosePathEndPath()                 { b.x, b.y = b.startX, b.startY }
+func (b *bounds) ClosePathAbsMoveTo(x, y float32)   { b.moveTo(x, y) }
+func (b *bounds) ClosePathRelMoveTo(x, y float32)   { b.moveTo(b.startX+x, b.startY+y) }
+
+func (b *bounds) AbsHLineTo(x float32)   { b.lineTo(x, b.y) }
+func (b *bounds) RelHLineTo(x float32)   { b.lineTo(b.x+x, b.y) }
+func (b *bounds) AbsVLineTo(y float32)   { b.lineTo(b.x, y) }
+func (b *bounds) RelVLineTo(y float32)   { b.lineTo(b.x, b.y+y) }
+func (b *bounds) AbsLineTo(x, y float32) { b.lineTo(x, y) }
+func (b *bounds) RelLineTo(x, y float32) { b.lineTo(b.x+x, b.y+y) }
+func (b *bounds) AbsSmoothQuadTo(x, y float32) {
+	b.lineTo(x, y)
+}
+func (b *bounds) RelSmoothQuadTo(x, y float32) {
+	b.lineTo(b.x+x, b.y+y)
+}
+func (b *bounds) AbsQuadTo(x1, y1, x, y float32) {
+	b.add(x1, y1)
+	b.lineTo(x, y)
+}
+func (b *bounds) RelQuadTo(x1, y1, x, y float32) {
+	b.add(b.x+x1, b.y+y1)
+	b.lineTo(b.x+x, b.y+y)
+}
+func (b *bounds) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	b.add(x2, y2)
+	b.lineTo(x, y)
+}
+func (b *bounds) RelSmoothCubeTo(x2, y2, x, y float32) {
+	b.add(b.x+x2, b.y+y2)
+	b.lineTo(b.x+x, b.y+y)
+}
+func (b *bounds) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	b.add(x1, y1)
+	b.add(x2, y2)
+	b.lineTo(x, y)
+}
+func (b *bounds) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	b.add(b.x+x1, b.y+y1)
+	b.add(b.x+x2, b.y+y2)
+	b.lineTo(b.x+x, b.y+y)
+}
+func (b *bounds) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	b.arcTo(rx, ry, xAxisRotation, x, y)
+}
+func (b *bounds) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	b.arcTo(rx, ry, xAxisRotation, b.x+x, b.y+y)
+}
+
+// arcTo adds an elliptical arc to (x, y). Every point of the arc is within r
+// of the ellipse's center, which in turn is within r of both end points,
+// where r is the larger of the radii after scaling them up like SVG does
+// when they are too small. The xAxisRotation is in units of full turns.
+func (b *bounds) arcTo(rx, ry, xAxisRotation float32, x, y float32) {
+	rx64, ry64 := math.Abs(float64(rx)), math.Abs(float64(ry))
+	if rx64 == 0 || ry64 == 0 {
+		b.lineTo(x, y)
+		return
+	}
+	sin, cos := math.Sincos(2 * math.Pi * float64(xAxisRotation))
+	hx, hy := float64(b.x-x)/2, float64(b.y-y)/2
+	px, py := cos*hx+sin*hy, -sin*hx+cos*hy
+	r := math.Max(rx64, ry64)
+	if lambda := (px*px)/(rx64*rx64) + (py*py)/(ry64*ry64); lambda > 1 {
+		r *= math.Sqrt(lambda)
+	}
+	d := float32(2 * r)
+	b.add(max32(b.x, x)-d, max32(b.y, y)-d)
+	b.add(min32(b.x, x)+d, min32(b.y, y)+d)
+	b.lineTo(x, y)
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
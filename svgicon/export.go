@@ -0,0 +1,373 @@
+package svgicon
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/generate"
+)
+
+var positiveInfinity = float32(math.Inf(+1))
+
+// Exporter implements the ivg.Destination interface to convert an IconVG
+// graphic to a standalone SVG document.
+//
+// Flat colors become fill attributes and gradients become <linearGradient>
+// and <radialGradient> elements in user space. Paths whose level of detail
+// range is not the default [0, +∞) are wrapped in a <g> element annotated
+// with data-ivg-lod0 and data-ivg-lod1 attributes, which Parse understands.
+// Paths that the IconVG renderer would not draw, because their color or
+// gradient is invalid or fully transparent, are omitted.
+//
+// The zero value is usable. Calling Reset, which is optional, sets the
+// metadata. If Reset is not called before other Exporter methods, the default
+// metadata is implied.
+type Exporter struct {
+	initialized bool
+
+	viewBox ivg.ViewBox
+	palette [64]color.RGBA
+
+	lod0 float32
+	lod1 float32
+	cSel uint8
+	nSel uint8
+
+	cReg [64]color.RGBA
+	nReg [64]float32
+
+	// groupLOD0 and groupLOD1 are the level of detail range of the open <g>
+	// element, if inGroup.
+	inGroup   bool
+	groupLOD0 float32
+	groupLOD1 float32
+
+	// drawing is whether the current path is to be written. Its fill
+	// attributes are in fill and its path data is in path.
+	drawing bool
+	fill    string
+	path    []byte
+
+	defs      bytes.Buffer
+	body      bytes.Buffer
+	gradients map[string]string
+}
+
+// Bytes returns the SVG document for the graphic sent to e so far.
+func (e *Exporter) Bytes() ([]byte, error) {
+	e.lazyReset()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%s %s %s %s\">\n",
+		ftoa(e.viewBox.MinX), ftoa(e.viewBox.MinY),
+		ftoa(e.viewBox.MaxX-e.viewBox.MinX), ftoa(e.viewBox.MaxY-e.viewBox.MinY))
+	if e.defs.Len() > 0 {
+		b.WriteString("<defs>\n")
+		b.Write(e.defs.Bytes())
+		b.WriteString("</defs>\n")
+	}
+	b.Write(e.body.Bytes())
+	if e.inGroup {
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes(), nil
+}
+
+func (e *Exporter) lazyReset() {
+	if !e.initialized {
+		e.Reset(ivg.DefaultViewBox, ivg.DefaultPalette)
+	}
+}
+
+// Reset resets the Destination for the given Metadata.
+func (e *Exporter) Reset(viewbox ivg.ViewBox, palette [64]color.RGBA) {
+	*e = Exporter{
+		initialized: true,
+		viewBox:     viewbox,
+		palette:     palette,
+		lod1:        positiveInfinity,
+		groupLOD1:   positiveInfinity,
+		cReg:        palette,
+		path:        e.path[:0],
+		gradients:   make(map[string]string),
+	}
+}
+
+func (e *Exporter) CSel() uint8 {
+	e.lazyReset()
+	return e.cSel
+}
+
+func (e *Exporter) SetCSel(cSel uint8) {
+	e.lazyReset()
+	e.cSel = cSel & 0x3f
+}
+
+func (e *Exporter) NSel() uint8 {
+	e.lazyReset()
+	return e.nSel
+}
+
+func (e *Exporter) SetNSel(nSel uint8) {
+	e.lazyReset()
+	e.nSel = nSel & 0x3f
+}
+
+func (e *Exporter) SetCReg(adj uint8, incr bool, c ivg.Color) {
+	e.lazyReset()
+	e.cReg[(e.cSel-adj)&0x3f] = c.Resolve(&e.palette, &e.cReg)
+	if incr {
+		e.cSel++
+	}
+}
+
+func (e *Exporter) SetNReg(adj uint8, incr bool, f float32) {
+	e.lazyReset()
+	e.nReg[(e.nSel-adj)&0x3f] = f
+	if incr {
+		e.nSel++
+	}
+}
+
+func (e *Exporter) SetLOD(lod0, lod1 float32) {
+	e.lazyReset()
+	e.lod0, e.lod1 = lod0, lod1
+}
+
+func (e *Exporter) StartPath(adj uint8, x, y float32) {
+	e.lazyReset()
+	e.drawing = false
+	c := e.cReg[(e.cSel-adj)&0x3f]
+	switch {
+	case ivg.ValidAlphaPremulColor(c):
+		if c.A == 0 {
+			return
+		}
+		e.fill = fillAttrs(c)
+	case ivg.ValidGradient(c):
+		id, ok := e.gradient(c)
+		if !ok {
+			return
+		}
+		e.fill = `fill="url(#` + id + `)"`
+	default:
+		return
+	}
+	e.drawing = true
+	e.path = e.path[:0]
+	e.appendPathData('M', x, y)
+}
+
+func (e *Exporter) ClosePathEndPath() {
+	if !e.drawing {
+		return
+	}
+	e.drawing = false
+	e.path = append(e.path, 'z')
+	if e.inGroup && (e.groupLOD0 != e.lod0 || e.groupLOD1 != e.lod1) {
+		e.body.WriteString("</g>\n")
+		e.inGroup = false
+	}
+	if !e.inGroup && (e.lod0 != 0 || e.lod1 != positiveInfinity) {
+		e.body.WriteString(`<g data-ivg-lod0="` + ftoa(e.lod0) + `"`)
+		if e.lod1 != positiveInfinity {
+			e.body.WriteString(` data-ivg-lod1="` + ftoa(e.lod1) + `"`)
+		}
+		e.body.WriteString(">\n")
+		e.inGroup, e.groupLOD0, e.groupLOD1 = true, e.lod0, e.lod1
+	}
+	e.body.WriteString("<path " + e.fill + ` d="`)
+	e.body.Write(e.path)
+	e.body.WriteString("\"/>\n")
+}
+
+func (e *Exporter) ClosePathAbsMoveTo(x, y float32) {
+	e.path = append(e.path, 'z')
+	e.appendPathData('M', x, y)
+}
+
+func (e *Exporter) ClosePathRelMoveTo(x, y float32) {
+	e.path = append(e.path, 'z')
+	e.appendPathData('m', x, y)
+}
+
+func (e *Exporter) AbsHLineTo(x float32) { e.appendPathData('H', x) }
+func (e *Exporter) RelHLineTo(x float32) { e.appendPathData('h', x) }
+func (e *Exporter) AbsVLineTo(y float32) { e.appendPathData('V', y) }
+func (e *Exporter) RelVLineTo(y float32) { e.appendPathData('v', y) }
+
+func (e *Exporter) AbsLineTo(x, y float32) { e.appendPathData('L', x, y) }
+func (e *Exporter) RelLineTo(x, y float32) { e.appendPathData('l', x, y) }
+
+func (e *Exporter) AbsSmoothQuadTo(x, y float32) { e.appendPathData('T', x, y) }
+func (e *Exporter) RelSmoothQuadTo(x, y float32) { e.appendPathData('t', x, y) }
+
+func (e *Exporter) AbsQuadTo(x1, y1, x, y float32) { e.appendPathData('Q', x1, y1, x, y) }
+func (e *Exporter) RelQuadTo(x1, y1, x, y float32) { e.appendPathData('q', x1, y1, x, y) }
+
+func (e *Exporter) AbsSmoothCubeTo(x2, y2, x, y float32) { e.appendPathData('S', x2, y2, x, y) }
+func (e *Exporter) RelSmoothCubeTo(x2, y2, x, y float32) { e.appendPathData('s', x2, y2, x, y) }
+
+func (e *Exporter) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	e.appendPathData('C', x1, y1, x2, y2, x, y)
+}
+
+func (e *Exporter) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	e.appendPathData('c', x1, y1, x2, y2, x, y)
+}
+
+func (e *Exporter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	e.appendPathData('A', rx, ry, xAxisRotation*360, flag(largeArc), flag(sweep), x, y)
+}
+
+func (e *Exporter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	e.appendPathData('a', rx, ry, xAxisRotation*360, flag(largeArc), flag(sweep), x, y)
+}
+
+func (e *Exporter) appendPathData(verb byte, args ...float32) {
+	if e.drawing {
+		e.path = formatPathData(e.path, verb, args...)
+	}
+}
+
+// gradient returns the id of the gradient element for the gradient encoded
+// in c and the registers, adding the element to the defs if it is new. It
+// returns false if the renderer would not draw the gradient.
+func (e *Exporter) gradient(c color.RGBA) (id string, ok bool) {
+	cBase, nBase, shape, spread, nStops := ivg.DecodeGradient(c)
+
+	type stop struct {
+		offset float32
+		color  color.RGBA
+	}
+	stops := make([]stop, 0, nStops+4)
+	prevN := float32(math.Inf(-1))
+	for i := uint8(0); i < nStops; i++ {
+		c := e.cReg[(cBase+i)&0x3f]
+		n := e.nReg[(nBase+i)&0x3f]
+		if !ivg.ValidAlphaPremulColor(c) || !(0 <= n && n <= 1) || !(n > prevN) {
+			return "", false
+		}
+		prevN = n
+		stops = append(stops, stop{n, c})
+	}
+	if len(stops) == 0 {
+		return "", false
+	}
+	if generate.GradientSpread(spread) == generate.GradientSpreadNone {
+		// SVG has no equivalent of spread none, so pad with transparent
+		// stops at offsets 0 and 1.
+		first, last := stops[0], stops[len(stops)-1]
+		if first.offset > 0 {
+			stops = append([]stop{{0, first.color}}, stops...)
+		}
+		if last.offset < 1 {
+			stops = append(stops, stop{1, last.color})
+		}
+		stops = append([]stop{{0, color.RGBA{}}}, stops...)
+		stops = append(stops, stop{1, color.RGBA{}})
+	}
+
+	a := e.nReg[(nBase-6)&0x3f]
+	b := e.nReg[(nBase-5)&0x3f]
+	cc := e.nReg[(nBase-4)&0x3f]
+	d := e.nReg[(nBase-3)&0x3f]
+	ee := e.nReg[(nBase-2)&0x3f]
+	f := e.nReg[(nBase-1)&0x3f]
+
+	var elem bytes.Buffer
+	if generate.GradientShape(shape) == generate.GradientShapeLinear {
+		// The offset is a*x + b*y + cc, which is 0 and 1 at the points
+		// (x1, y1) and (x2, y2) on the line through the origin in the
+		// direction (a, b).
+		dd := a*a + b*b
+		if dd == 0 {
+			return "", false
+		}
+		elem.WriteString(`linearGradient gradientUnits="userSpaceOnUse"`)
+		fmt.Fprintf(&elem, ` x1="%s" y1="%s" x2="%s" y2="%s"`,
+			ftoa(-cc*a/dd), ftoa(-cc*b/dd), ftoa((1-cc)*a/dd), ftoa((1-cc)*b/dd))
+	} else {
+		// The registers map graphic space to gradient space, where the
+		// gradient is the unit circle. The gradientTransform maps the other
+		// way.
+		m, ok := invert([6]float32{a, b, cc, d, ee, f})
+		if !ok {
+			return "", false
+		}
+		elem.WriteString(`radialGradient gradientUnits="userSpaceOnUse" cx="0" cy="0" r="1"`)
+		fmt.Fprintf(&elem, ` gradientTransform="matrix(%s %s %s %s %s %s)"`,
+			ftoa(m[0]), ftoa(m[3]), ftoa(m[1]), ftoa(m[4]), ftoa(m[2]), ftoa(m[5]))
+	}
+	switch generate.GradientSpread(spread) {
+	case generate.GradientSpreadReflect:
+		elem.WriteString(` spreadMethod="reflect"`)
+	case generate.GradientSpreadRepeat:
+		elem.WriteString(` spreadMethod="repeat"`)
+	}
+	elem.WriteString(">\n")
+	for _, s := range stops {
+		fmt.Fprintf(&elem, "<stop offset=\"%s\" %s/>\n", ftoa(s.offset), stopAttrs(s.color))
+	}
+
+	key := elem.String()
+	if id, ok := e.gradients[key]; ok {
+		return id, true
+	}
+	id = "g" + strconv.Itoa(len(e.gradients))
+	e.gradients[key] = id
+	name := "linearGradient"
+	if generate.GradientShape(shape) != generate.GradientShapeLinear {
+		name = "radialGradient"
+	}
+	e.defs.WriteString("<" + name + ` id="` + id + `"` + key[len(name):])
+	e.defs.WriteString("</" + name + ">\n")
+	return id, true
+}
+
+// unpremul returns the color and opacity of the alpha-premultiplied c.
+func unpremul(c color.RGBA) (rgb string, opacity string) {
+	if c.A == 0 {
+		return "#000000", "0"
+	}
+	un := func(v uint8) uint32 { return (uint32(v)*0xff + uint32(c.A)/2) / uint32(c.A) }
+	rgb = fmt.Sprintf("#%02x%02x%02x", un(c.R), un(c.G), un(c.B))
+	if c.A != 0xff {
+		opacity = strconv.FormatFloat(float64(c.A)/0xff, 'g', 3, 64)
+	}
+	return rgb, opacity
+}
+
+func fillAttrs(c color.RGBA) string {
+	rgb, opacity := unpremul(c)
+	if opacity == "" {
+		return `fill="` + rgb + `"`
+	}
+	return `fill="` + rgb + `" fill-opacity="` + opacity + `"`
+}
+
+func stopAttrs(c color.RGBA) string {
+	rgb, opacity := unpremul(c)
+	if opacity == "" {
+		return `stop-color="` + rgb + `"`
+	}
+	return `stop-color="` + rgb + `" stop-opacity="` + opacity + `"`
+}
+
+func ftoa(f float32) string {
+	if f == 0 {
+		f = 0 // Avoid "-0".
+	}
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func flag(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package svgicon
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/internal/imagetest"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+// TestExportRoundTrip exports the IconVG files in testdata to SVG, parses
+// the SVG again and compares the rendering with the PNG files.
+func TestExportRoundTrip(t *testing.T) {
+	testCases := []struct {
+		filename string
+		variants string
+	}{
+		{"../testdata/action-info.lores", ""},
+		{"../testdata/action-info.hires", ""},
+		{"../testdata/arcs", ""},
+		{"../testdata/blank", ""},
+		{"../testdata/cowbell", ""},
+		{"../testdata/elliptical", ""},
+		{"../testdata/favicon", ";pink"},
+		{"../testdata/gradient", ""},
+		{"../testdata/lod-polygon", ";64"},
+		{"../testdata/video-005.primitive", ""},
+	}
+	for _, tc := range testCases {
+		ivgData, err := os.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		vb, err := decode.DecodeViewBox(ivgData)
+		if err != nil {
+			t.Errorf("%s: DecodeViewBox: %v", tc.filename, err)
+			continue
+		}
+
+		for _, variant := range strings.Split(tc.variants, ";") {
+			opts := []decode.DecodeOption{}
+			if variant == "pink" {
+				pink := color.RGBA{0xfe, 0x76, 0xea, 0xff}
+				opts = append(opts, decode.WithColorAt(0, pink))
+			}
+			var e Exporter
+			if err := decode.Decode(&e, ivgData, opts...); err != nil {
+				t.Errorf("%s %q variant: Decode: %v", tc.filename, variant, err)
+				continue
+			}
+			svgData, err := e.Bytes()
+			if err != nil {
+				t.Errorf("%s %q variant: Bytes: %v", tc.filename, variant, err)
+				continue
+			}
+
+			wantFilename := filepath.FromSlash(tc.filename)
+			if variant != "" {
+				wantFilename += "." + variant
+			}
+			want, err := imagetest.DecodePNG(wantFilename + ".png")
+			if err != nil {
+				t.Errorf("%s %q variant: decodePNG: %v", tc.filename, variant, err)
+				continue
+			}
+			got := image.NewRGBA(want.Bounds())
+			var z render.Renderer
+			z.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src}, got.Bounds())
+			if err := Parse(&z, svgData, &Options{ViewBox: vb}); err != nil {
+				t.Errorf("%s %q variant: Parse: %v", tc.filename, variant, err)
+				continue
+			}
+			if err := imagetest.CheckApproxEqual(got, want); err != nil {
+				t.Errorf("%s %q variant: %v", tc.filename, variant, err)
+			}
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	var e Exporter
+	e.Reset(ivg.ViewBox{MinX: 0, MinY: 0, MaxX: 48, MaxY: 32}, ivg.DefaultPalette)
+	e.SetCReg(0, false, ivg.RGBAColor(color.RGBA{0x40, 0x00, 0x00, 0x80}))
+	e.StartPath(0, 1, 2)
+	e.RelHLineTo(10)
+	e.AbsArcTo(4, 4, 0.25, false, true, 11, 10)
+	e.ClosePathRelMoveTo(-0.5, 1.5)
+	e.RelLineTo(2, 0)
+	e.RelVLineTo(-1)
+	e.ClosePathEndPath()
+	e.SetLOD(0, 80)
+	e.SetCReg(0, false, ivg.RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(1, 1)
+	e.ClosePathEndPath()
+	e.SetCReg(0, false, ivg.PaletteIndexColor(0))
+	e.StartPath(0, 0, 0)
+	e.AbsCubeTo(1, 2, 3, 4, 5, 6)
+	e.ClosePathEndPath()
+
+	got, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 48 32">
+<path fill="#800000" fill-opacity="0.502" d="M1 2h10A4 4 90 0 1 11 10zm-0.5 1.5l2 0v-1z"/>
+<g data-ivg-lod0="0" data-ivg-lod1="80">
+<path fill="#000000" d="M0 0C1 2 3 4 5 6z"/>
+</g>
+</svg>
+`
+	if string(got) != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package svgicon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// node is an element of an SVG document. Its attrs hold both the element's
+// presentation attributes and the declarations of its style attribute, the
+// latter taking precedence.
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+}
+
+// parseNodes parses the SVG document in data into a tree of nodes and returns
+// the root node.
+func parseNodes(data []byte) (*node, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	var root *node
+	var stack []*node
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				// Ignore namespaced attributes like xmlns:xlink, except for
+				// xlink:href which older SVG files use to reference elements.
+				if a.Name.Space == "" || a.Name.Local == "href" {
+					n.attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+				}
+			}
+			for _, decl := range strings.Split(n.attrs["style"], ";") {
+				if i := strings.IndexByte(decl, ':'); i >= 0 {
+					n.attrs[strings.TrimSpace(decl[:i])] = strings.TrimSpace(decl[i+1:])
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if root == nil || root.name != "svg" {
+		return nil, NotAnSVGDocument
+	}
+	return root, nil
+}
+
+// index adds n and its descendants that have an id attribute to ids.
+func (n *node) index(ids map[string]*node) {
+	if id := n.attrs["id"]; id != "" {
+		if _, ok := ids[id]; !ok {
+			ids[id] = n
+		}
+	}
+	for _, c := range n.children {
+		c.index(ids)
+	}
+}
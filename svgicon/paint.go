@@ -0,0 +1,230 @@
+package svgicon
+
+import (
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/reactivego/ivg/generate"
+)
+
+var black = color.NRGBA{0x00, 0x00, 0x00, 0xff}
+
+type paintKind uint8
+
+const (
+	paintNone paintKind = iota
+	paintColor
+	paintURL
+)
+
+// paint is the value of a fill property: none, a color or a reference to a
+// paint server with an optional fallback.
+type paint struct {
+	kind  paintKind
+	color color.NRGBA
+	url   string
+	// hasFallback is whether a paintURL has a fallback, which is then none
+	// (when color is the zero value) or color.
+	hasFallback bool
+}
+
+// fallback returns the paint to use when a paintURL references an element
+// that is not a paint server.
+func (f paint) fallback() paint {
+	if !f.hasFallback || f.color == (color.NRGBA{}) {
+		return paint{kind: paintNone}
+	}
+	return paint{kind: paintColor, color: f.color}
+}
+
+// parsePaint parses the value of a fill property. The currentColor keyword
+// resolves to current.
+func parsePaint(s string, current color.NRGBA) (paint, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "none":
+		return paint{kind: paintNone}, nil
+	case s == "currentColor":
+		return paint{kind: paintColor, color: current}, nil
+	case strings.HasPrefix(s, "url("):
+		i := strings.IndexByte(s, ')')
+		if i < 0 {
+			return paint{}, InvalidPaint
+		}
+		url := strings.Trim(strings.TrimSpace(s[len("url("):i]), `"'`)
+		f := paint{kind: paintURL, url: strings.TrimPrefix(url, "#")}
+		if rest := strings.TrimSpace(s[i+1:]); rest != "" {
+			fb, err := parsePaint(rest, current)
+			if err != nil || fb.kind == paintURL {
+				return paint{}, InvalidPaint
+			}
+			f.hasFallback, f.color = true, fb.color
+		}
+		return f, nil
+	}
+	c, err := parseColor(s)
+	if err != nil {
+		return paint{}, err
+	}
+	return paint{kind: paintColor, color: c}, nil
+}
+
+// gradientAttr returns the attribute name of the gradient element g, or of
+// the gradient elements that g references through href when g does not
+// specify it. It returns def when none of them do.
+func (p *parser) gradientAttr(g *node, name, def string) string {
+	for i := 0; g != nil && i < maxDepth; i++ {
+		if v, ok := g.attrs[name]; ok {
+			return v
+		}
+		g = p.ids[strings.TrimPrefix(g.attrs["href"], "#")]
+	}
+	return def
+}
+
+// gradientStops returns the stops of the gradient element g, or of the first
+// gradient element that g references through href that has stops. The
+// offsets are made strictly increasing, as IconVG requires, and the stop
+// colors have their alpha multiplied by opacity. The currentColor keyword
+// resolves to current.
+func (p *parser) gradientStops(g *node, current color.NRGBA, opacity float32) ([]generate.GradientStop, error) {
+	var stops []generate.GradientStop
+	var err error
+	for i := 0; g != nil && i < maxDepth && len(stops) == 0; i++ {
+		prev := float32(-1)
+		for _, s := range g.children {
+			if s.name != "stop" {
+				continue
+			}
+			offset := float32(0)
+			if v, ok := s.attrs["offset"]; ok {
+				if offset, err = parseOpacity(v); err != nil {
+					return nil, err
+				}
+			}
+			if offset <= prev {
+				// Nudge coincident stops apart by the precision of the
+				// 2-byte zero-to-one number encoding. At offset 1, nudge
+				// the previous stop back instead, if there is room.
+				const eps = 1.0 / 15120
+				if offset = prev + eps; offset > 1 {
+					n := len(stops)
+					if n > 1 && stops[n-2].Offset >= 1-eps {
+						continue
+					}
+					stops[n-1].Offset, offset = 1-eps, 1
+				}
+			}
+			prev = offset
+			c := black
+			if v, ok := s.attrs["stop-color"]; ok && v == "currentColor" {
+				c = current
+			} else if ok {
+				if c, err = parseColor(v); err != nil {
+					return nil, err
+				}
+			}
+			o := float32(1)
+			if v, ok := s.attrs["stop-opacity"]; ok {
+				if o, err = parseOpacity(v); err != nil {
+					return nil, err
+				}
+			}
+			stops = append(stops, generate.GradientStop{
+				Offset: offset,
+				Color:  scaleAlpha(c, o*opacity),
+			})
+		}
+		g = p.ids[strings.TrimPrefix(g.attrs["href"], "#")]
+	}
+	return stops, nil
+}
+
+// setGradient sets CREG[0] to the gradient element g for filling the path
+// described by the SVG path data in d. It returns false when nothing should
+// be painted.
+func (p *parser) setGradient(g *node, st state, d string, opacity float32) (bool, error) {
+	stops, err := p.gradientStops(g, st.color, opacity)
+	if err != nil || len(stops) == 0 {
+		return false, err
+	}
+
+	// toGraphic maps the gradient's coordinate system to graphic space, via
+	// the bounding box of the path for objectBoundingBox units.
+	var toGraphic generate.Aff3
+	if t, err := parseTransform(p.gradientAttr(g, "gradientTransform", "")); err != nil {
+		return false, err
+	} else {
+		toGraphic = t
+	}
+	w, h := p.width, p.height
+	if p.gradientAttr(g, "gradientUnits", "objectBoundingBox") == "objectBoundingBox" {
+		b := newBounds()
+		var gen generate.Generator
+		gen.SetDestination(b)
+		if err := gen.SetPathData(d, 0); err != nil {
+			return false, err
+		}
+		bx, by, bw, bh := b.rect()
+		if bw == 0 || bh == 0 {
+			return false, nil
+		}
+		toGraphic = generate.Concat(toGraphic, generate.Aff3{bw, 0, bx, 0, bh, by})
+		w, h = 1, 1
+	}
+	toGraphic = generate.Concat(toGraphic, st.transform)
+	fromGraphic, ok := invert(toGraphic)
+
+	spread := generate.GradientSpreadPad
+	switch p.gradientAttr(g, "spreadMethod", "pad") {
+	case "reflect":
+		spread = generate.GradientSpreadReflect
+	case "repeat":
+		spread = generate.GradientSpreadRepeat
+	}
+
+	length := func(name, def string, ref float32) float32 {
+		if err != nil {
+			return 0
+		}
+		var f float32
+		f, err = parseLength(p.gradientAttr(g, name, def), ref)
+		return f
+	}
+	shape, grad := generate.GradientShapeLinear, generate.Aff3{}
+	if g.name == "linearGradient" {
+		x1, y1 := length("x1", "0%", w), length("y1", "0%", h)
+		x2, y2 := length("x2", "100%", w), length("y2", "0%", h)
+		if err != nil {
+			return false, err
+		}
+		dx, dy := x2-x1, y2-y1
+		if dd := dx*dx + dy*dy; dd > 0 && ok {
+			grad = generate.Aff3{dx / dd, dy / dd, -(dx*x1 + dy*y1) / dd, 0, 0, 0}
+		} else {
+			ok = false
+		}
+	} else {
+		shape = generate.GradientShapeRadial
+		diag := float32(math.Sqrt(float64(w*w+h*h) / 2))
+		cx, cy := length("cx", "50%", w), length("cy", "50%", h)
+		r := length("r", "50%", diag)
+		if err != nil {
+			return false, err
+		}
+		if r > 0 && ok {
+			grad = generate.Aff3{1 / r, 0, -cx / r, 0, 1 / r, -cy / r}
+		} else {
+			ok = false
+		}
+	}
+
+	if !ok || len(stops) == 1 {
+		// A degenerate gradient paints with the color of its last stop.
+		c := stops[len(stops)-1].Color.(color.NRGBA)
+		p.gen.SetCReg(0, false, rgbaColor(c))
+		return true, nil
+	}
+	return true, p.gen.SetGradient(shape, spread, stops, generate.Concat(fromGraphic, grad))
+}
@@ -0,0 +1,446 @@
+// Package svgicon converts SVG icons to IconVG graphics by driving a
+// generate.Generator, and converts IconVG graphics back to SVG documents with
+// an Exporter.
+//
+// It handles the static subset of SVG that vector drawing tools typically
+// produce for icons: the <svg>, <g>, <use>, <symbol>, <path>, <rect>,
+// <circle>, <ellipse>, <line>, <polygon> and <polyline> elements, nested
+// transform attributes, and <linearGradient> and <radialGradient> paint
+// servers including their gradientTransform and gradientUnits. Paint,
+// opacity and stroke style are taken from presentation attributes and style
+// attributes, but not from <style> sheets.
+//
+// IconVG only fills paths, so strokes are converted to the fill of their
+// outline, without dashes. IconVG has no concept of group opacity, so the
+// opacity of a group is multiplied into the fill and stroke opacity of each
+// of its children, and a stroke is painted over its fill. IconVG only
+// fills paths using the nonzero winding rule, so a path with a fill-rule of
+// evenodd has the subpaths that are holes reversed. That is exact unless its
+// subpaths cross, in which case the path is filled as if its fill-rule were
+// nonzero, or Parse returns generate.PathDataCrossingSubpaths when
+// Options.StrictEvenOdd is set.
+// Radial gradients ignore their focal point (fx, fy).
+package svgicon
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/generate"
+)
+
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+const (
+	NotAnSVGDocument = Error("svgicon: not an SVG document")
+	MissingViewBox   = Error("svgicon: missing viewBox, width or height")
+	InvalidViewBox   = Error("svgicon: invalid viewBox")
+	InvalidTransform = Error("svgicon: invalid transform")
+	InvalidNumber    = Error("svgicon: invalid number")
+	InvalidColor     = Error("svgicon: invalid color")
+	InvalidPaint     = Error("svgicon: invalid paint")
+	NestedTooDeeply  = Error("svgicon: elements nested too deeply")
+	TooManyElements  = Error("svgicon: too many elements")
+)
+
+// maxDepth bounds the nesting of elements, including elements referenced by
+// <use>, which could otherwise reference themselves.
+const maxDepth = 256
+
+// maxElements bounds the number of elements walked, counting an element
+// again for every <use> that references it, because <use> elements that
+// reference each other more than once expand exponentially with depth.
+const maxElements = 1 << 16
+
+// Options are optional parameters for Parse.
+type Options struct {
+	// ViewBox is the IconVG viewBox that the SVG viewBox is mapped onto. The
+	// zero value means a viewBox centered on (0, 0) that has the aspect ratio
+	// of the SVG viewBox and whose larger side is 64 units long, like
+	// ivg.DefaultViewBox. Its bounds are rounded to multiples of 1/64.
+	ViewBox ivg.ViewBox
+
+	// StrictEvenOdd makes Parse return generate.PathDataCrossingSubpaths for
+	// a path with a fill-rule of evenodd whose subpaths cross, instead of
+	// filling it as if its fill-rule were nonzero. That fills the areas that
+	// the path winds around more than once, which evenodd may leave empty.
+	StrictEvenOdd bool
+}
+
+// Parse parses the SVG document in svg and sends the graphic that it
+// describes to dst. The opts may be nil.
+func Parse(dst ivg.Destination, svg []byte, opts *Options) error {
+	root, err := parseNodes(svg)
+	if err != nil {
+		return err
+	}
+	vbx, vby, vbw, vbh, err := viewBox(root)
+	if err != nil {
+		return err
+	}
+
+	var viewbox ivg.ViewBox
+	strictEvenOdd := false
+	if opts != nil {
+		viewbox = opts.ViewBox
+		strictEvenOdd = opts.StrictEvenOdd
+	}
+	if viewbox == (ivg.ViewBox{}) {
+		s := 64 / float32(math.Max(float64(vbw), float64(vbh)))
+		hw := float32(math.Round(float64(vbw*s*32))) / 64
+		hh := float32(math.Round(float64(vbh*s*32))) / 64
+		viewbox = ivg.ViewBox{MinX: -hw, MinY: -hh, MaxX: +hw, MaxY: +hh}
+	}
+	dx, dy := viewbox.Size()
+
+	p := &parser{
+		ids:   make(map[string]*node),
+		width: vbw, height: vbh,
+		lod: [2]float32{0, positiveInfinity},
+
+		strictEvenOdd: strictEvenOdd,
+	}
+	p.gen.SetDestination(dst)
+	root.index(p.ids)
+
+	dst.Reset(viewbox, ivg.DefaultPalette)
+	return p.walk(root, state{
+		transform: generate.Concat(
+			generate.Translate(-vbx, -vby),
+			generate.Scale(dx/vbw, dy/vbh),
+			generate.Translate(viewbox.MinX, viewbox.MinY),
+		),
+		fill:          paint{kind: paintColor, color: black},
+		fillOpacity:   1,
+		strokePaint:   paint{kind: paintNone},
+		strokeOpacity: 1,
+		stroke:        generate.Stroke{Width: 1},
+		opacity:       1,
+		color:         black,
+		visible:       true,
+		lod:           p.lod,
+	}, 0)
+}
+
+// viewBox returns the viewBox of the root <svg> element, falling back to its
+// width and height.
+func viewBox(root *node) (x, y, w, h float32, err error) {
+	if v, ok := root.attrs["viewBox"]; ok {
+		f, err := parseNumbers(v)
+		if err != nil || len(f) != 4 || !(f[2] > 0) || !(f[3] > 0) {
+			return 0, 0, 0, 0, InvalidViewBox
+		}
+		return f[0], f[1], f[2], f[3], nil
+	}
+	ws, hs := root.attrs["width"], root.attrs["height"]
+	if ws == "" || hs == "" || strings.HasSuffix(ws, "%") || strings.HasSuffix(hs, "%") {
+		return 0, 0, 0, 0, MissingViewBox
+	}
+	if w, err = parseLength(ws, 0); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if h, err = parseLength(hs, 0); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if !(w > 0) || !(h > 0) {
+		return 0, 0, 0, 0, InvalidViewBox
+	}
+	return 0, 0, w, h, nil
+}
+
+type parser struct {
+	gen generate.Generator
+	ids map[string]*node
+
+	// width and height are the size of the SVG viewBox, which percentages
+	// are relative to.
+	width, height float32
+
+	// lod is the level of detail range last sent to gen.
+	lod [2]float32
+
+	// elements is the number of elements walked.
+	elements int
+
+	strictEvenOdd bool
+}
+
+// state is the inherited state while walking the SVG document.
+type state struct {
+	// transform maps the current user space to IconVG graphic space.
+	transform     generate.Aff3
+	fill          paint
+	fillOpacity   float32
+	evenOdd       bool
+	strokePaint   paint
+	strokeOpacity float32
+	stroke        generate.Stroke
+	// opacity is the product of the opacity of all ancestors.
+	opacity float32
+	color   color.NRGBA
+	visible bool
+	// lod is the level of detail range, set by the data-ivg-lod0 and
+	// data-ivg-lod1 attributes that Exporter writes.
+	lod [2]float32
+}
+
+func (p *parser) walk(n *node, parent state, depth int) error {
+	if depth > maxDepth {
+		return NestedTooDeeply
+	}
+	p.elements++
+	if p.elements > maxElements {
+		return TooManyElements
+	}
+	if n.attrs["display"] == "none" {
+		return nil
+	}
+	st, err := p.inherit(parent, n)
+	if err != nil {
+		return err
+	}
+	switch n.name {
+	case "svg", "g", "a", "switch":
+		for _, c := range n.children {
+			if err := p.walk(c, st, depth+1); err != nil {
+				return err
+			}
+		}
+	case "use":
+		ref := p.ids[strings.TrimPrefix(n.attrs["href"], "#")]
+		if ref == nil {
+			return nil
+		}
+		x, err := p.length(n, "x", p.width)
+		if err != nil {
+			return err
+		}
+		y, err := p.length(n, "y", p.height)
+		if err != nil {
+			return err
+		}
+		st.transform = generate.Concat(generate.Translate(x, y), st.transform)
+		if ref.name != "symbol" {
+			return p.walk(ref, st, depth+1)
+		}
+		if st, err = p.inherit(st, ref); err != nil {
+			return err
+		}
+		for _, c := range ref.children {
+			if err := p.walk(c, st, depth+1); err != nil {
+				return err
+			}
+		}
+	case "path":
+		return p.draw(st, n.attrs["d"])
+	case "rect", "circle", "ellipse", "line", "polygon", "polyline":
+		d, err := p.shapePathData(n)
+		if err != nil {
+			return err
+		}
+		if n.name == "line" {
+			// A line has no area to fill.
+			return p.stroke(st, d)
+		}
+		return p.draw(st, d)
+	}
+	return nil
+}
+
+// inherit returns the state for n, given the state of its parent.
+func (p *parser) inherit(parent state, n *node) (state, error) {
+	st := parent
+	if v, ok := n.attrs["transform"]; ok {
+		t, err := parseTransform(v)
+		if err != nil {
+			return st, err
+		}
+		st.transform = generate.Concat(t, st.transform)
+	}
+	if v, ok := n.attrs["color"]; ok && v != "inherit" {
+		c, err := parseColor(v)
+		if err != nil {
+			return st, err
+		}
+		st.color = c
+	}
+	if v, ok := n.attrs["fill"]; ok && v != "inherit" {
+		f, err := parsePaint(v, st.color)
+		if err != nil {
+			return st, err
+		}
+		st.fill = f
+	}
+	if v, ok := n.attrs["fill-opacity"]; ok && v != "inherit" {
+		f, err := parseOpacity(v)
+		if err != nil {
+			return st, err
+		}
+		st.fillOpacity = f
+	}
+	switch n.attrs["fill-rule"] {
+	case "nonzero":
+		st.evenOdd = false
+	case "evenodd":
+		st.evenOdd = true
+	}
+	if v, ok := n.attrs["stroke"]; ok && v != "inherit" {
+		f, err := parsePaint(v, st.color)
+		if err != nil {
+			return st, err
+		}
+		st.strokePaint = f
+	}
+	if v, ok := n.attrs["stroke-opacity"]; ok && v != "inherit" {
+		f, err := parseOpacity(v)
+		if err != nil {
+			return st, err
+		}
+		st.strokeOpacity = f
+	}
+	if v, ok := n.attrs["stroke-width"]; ok && v != "inherit" {
+		diag := float32(math.Sqrt(float64(p.width*p.width+p.height*p.height) / 2))
+		f, err := parseLength(v, diag)
+		if err != nil {
+			return st, err
+		}
+		st.stroke.Width = f
+	}
+	switch n.attrs["stroke-linecap"] {
+	case "butt":
+		st.stroke.Cap = generate.CapButt
+	case "round":
+		st.stroke.Cap = generate.CapRound
+	case "square":
+		st.stroke.Cap = generate.CapSquare
+	}
+	switch n.attrs["stroke-linejoin"] {
+	case "miter", "miter-clip", "arcs":
+		st.stroke.Join = generate.JoinMiter
+	case "round":
+		st.stroke.Join = generate.JoinRound
+	case "bevel":
+		st.stroke.Join = generate.JoinBevel
+	}
+	if v, ok := n.attrs["stroke-miterlimit"]; ok && v != "inherit" {
+		f, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return st, InvalidNumber
+		}
+		if f >= 1 {
+			st.stroke.MiterLimit = float32(f)
+		}
+	}
+	if v, ok := n.attrs["opacity"]; ok {
+		f, err := parseOpacity(v)
+		if err != nil {
+			return st, err
+		}
+		st.opacity *= f
+	}
+	if v, ok := n.attrs["visibility"]; ok && v != "inherit" {
+		st.visible = v == "visible"
+	}
+	for i, name := range [2]string{"data-ivg-lod0", "data-ivg-lod1"} {
+		if v, ok := n.attrs[name]; ok {
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return st, InvalidNumber
+			}
+			st.lod[i] = float32(f)
+		}
+	}
+	return st, nil
+}
+
+// draw fills and then strokes the path described by the SVG path data in d.
+func (p *parser) draw(st state, d string) error {
+	if err := p.fill(st, d); err != nil {
+		return err
+	}
+	return p.stroke(st, d)
+}
+
+// fill fills the path described by the SVG path data in d with the fill
+// paint of st.
+func (p *parser) fill(st state, d string) error {
+	if ok, err := p.setPaint(st, d, st.fill, st.fillOpacity); err != nil || !ok {
+		return err
+	}
+	if st.evenOdd {
+		err := p.gen.SetEvenOddPathData(d, 0)
+		if err != generate.PathDataCrossingSubpaths || p.strictEvenOdd {
+			return err
+		}
+	}
+	return p.gen.SetPathData(d, 0)
+}
+
+// stroke strokes the path described by the SVG path data in d with the
+// stroke paint and style of st.
+func (p *parser) stroke(st state, d string) error {
+	if !(st.stroke.Width > 0) {
+		return nil
+	}
+	if ok, err := p.setPaint(st, d, st.strokePaint, st.strokeOpacity); err != nil || !ok {
+		return err
+	}
+	return p.gen.StrokePathData(d, 0, st.stroke)
+}
+
+// setPaint prepares gen for painting the path described by the SVG path data
+// in d with paint f at the given opacity, times the group opacity of st. It
+// returns false when nothing should be painted.
+func (p *parser) setPaint(st state, d string, f paint, opacity float32) (bool, error) {
+	if d == "" || !st.visible {
+		return false, nil
+	}
+	opacity *= st.opacity
+	if f.kind == paintURL {
+		g := p.ids[f.url]
+		if g == nil || (g.name != "linearGradient" && g.name != "radialGradient") {
+			f = f.fallback()
+		} else if ok, err := p.setGradient(g, st, d, opacity); err != nil || !ok {
+			return false, err
+		}
+	}
+	if f.kind == paintColor {
+		p.gen.SetCReg(0, false, rgbaColor(scaleAlpha(f.color, opacity)))
+	} else if f.kind == paintNone {
+		return false, nil
+	}
+	if st.lod != p.lod {
+		p.gen.SetLOD(st.lod[0], st.lod[1])
+		p.lod = st.lod
+	}
+	p.gen.SetTransform(st.transform)
+	return true, nil
+}
+
+// length returns the value of n's attribute name as a length, with
+// percentages relative to ref. A missing attribute has length 0.
+func (p *parser) length(n *node, name string, ref float32) (float32, error) {
+	v, ok := n.attrs[name]
+	if !ok {
+		return 0, nil
+	}
+	return parseLength(v, ref)
+}
+
+// formatPathData formats an SVG path data command, like "M" followed by
+// args.
+func formatPathData(b []byte, verb byte, args ...float32) []byte {
+	b = append(b, verb)
+	for i, a := range args {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendFloat(b, float64(a), 'g', -1, 32)
+	}
+	return b
+}
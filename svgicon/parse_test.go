@@ -0,0 +1,290 @@
+package svgicon
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reactivego/ivg"
+	"github.com/reactivego/ivg/decode"
+	"github.com/reactivego/ivg/encode"
+	"github.com/reactivego/ivg/generate"
+	"github.com/reactivego/ivg/internal/imagetest"
+	"github.com/reactivego/ivg/raster/img"
+	"github.com/reactivego/ivg/render"
+)
+
+// TestParse converts the SVG files in testdata and compares their rendering
+// with the PNG files that were rendered from the hand-converted IconVG files.
+// The conversion uses the viewBox of the hand-converted IconVG file, so that
+// coordinates are quantized in the same way.
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		filename string
+		ivgname  string
+	}{
+		{"../testdata/action-info", "../testdata/action-info.hires"},
+		{"../testdata/cowbell", "../testdata/cowbell"},
+		{"../testdata/favicon", "../testdata/favicon"},
+		{"../testdata/video-005.primitive", "../testdata/video-005.primitive"},
+	}
+	for _, tc := range testCases {
+		svgData, err := os.ReadFile(filepath.FromSlash(tc.filename) + ".svg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		wantData, err := os.ReadFile(filepath.FromSlash(tc.ivgname) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		vb, err := decode.DecodeViewBox(wantData)
+		if err != nil {
+			t.Errorf("%s: DecodeViewBox: %v", tc.filename, err)
+			continue
+		}
+		var e encode.Encoder
+		if err := Parse(&e, svgData, &Options{ViewBox: vb}); err != nil {
+			t.Errorf("%s: Parse: %v", tc.filename, err)
+			continue
+		}
+		ivgData, err := e.Bytes()
+		if err != nil {
+			t.Errorf("%s: Bytes: %v", tc.filename, err)
+			continue
+		}
+
+		want, err := imagetest.DecodePNG(filepath.FromSlash(tc.ivgname) + ".png")
+		if err != nil {
+			t.Errorf("%s: decodePNG: %v", tc.filename, err)
+			continue
+		}
+		got := image.NewRGBA(want.Bounds())
+		var z render.Renderer
+		z.SetRasterizer(&img.Rasterizer{Dst: got, DrawOp: draw.Src}, got.Bounds())
+		if err := decode.Decode(&z, ivgData); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		if err := imagetest.CheckApproxEqual(got, want); err != nil {
+			t.Errorf("%s: %v", tc.filename, err)
+		}
+	}
+}
+
+func TestParseViewBox(t *testing.T) {
+	testCases := []struct {
+		svg  string
+		opts *Options
+		want ivg.ViewBox
+	}{{
+		svg:  `<svg viewBox="0 0 24 24"/>`,
+		want: ivg.ViewBox{MinX: -32, MinY: -32, MaxX: +32, MaxY: +32},
+	}, {
+		svg:  `<svg width="256" height="192"/>`,
+		want: ivg.ViewBox{MinX: -32, MinY: -24, MaxX: +32, MaxY: +24},
+	}, {
+		svg:  `<svg viewBox="0 0 48 48"/>`,
+		opts: &Options{ViewBox: ivg.ViewBox{MinX: 0, MinY: 0, MaxX: 48, MaxY: 48}},
+		want: ivg.ViewBox{MinX: 0, MinY: 0, MaxX: 48, MaxY: 48},
+	}}
+	for _, tc := range testCases {
+		var e encode.Encoder
+		if err := Parse(&e, []byte(tc.svg), tc.opts); err != nil {
+			t.Errorf("%s: Parse: %v", tc.svg, err)
+			continue
+		}
+		data, err := e.Bytes()
+		if err != nil {
+			t.Errorf("%s: Bytes: %v", tc.svg, err)
+			continue
+		}
+		got, err := decode.DecodeViewBox(data)
+		if err != nil {
+			t.Errorf("%s: DecodeViewBox: %v", tc.svg, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.svg, got, tc.want)
+		}
+	}
+}
+
+// useBomb returns an SVG document with n groups that each use the previous
+// group twice, so that it expands to 2^n rectangles.
+func useBomb(n int) string {
+	var b strings.Builder
+	b.WriteString(`<svg viewBox="0 0 24 24"><defs><rect id="g0" width="1" height="1"/>`)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, `<g id="g%d"><use href="#g%d"/><use href="#g%d"/></g>`, i, i-1, i-1)
+	}
+	fmt.Fprintf(&b, `</defs><use href="#g%d"/></svg>`, n)
+	return b.String()
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		svg  string
+		want error
+	}{
+		{`<html/>`, NotAnSVGDocument},
+		{`<svg/>`, MissingViewBox},
+		{`<svg viewBox="0 0 0 24"/>`, InvalidViewBox},
+		{`<svg viewBox="0 0 24 24"><g transform="spin(1)"/></svg>`, InvalidTransform},
+		{`<svg viewBox="0 0 24 24"><rect width="x" height="1"/></svg>`, InvalidNumber},
+		{`<svg viewBox="0 0 24 24"><path fill="#12345" d="M0 0h1v1z"/></svg>`, InvalidColor},
+		{`<svg viewBox="0 0 24 24"><path stroke="red" stroke-width="x" d="M0 0h1v1z"/></svg>`, InvalidNumber},
+		{`<svg viewBox="0 0 24 24"><g id="a"><use href="#a"/></g></svg>`, NestedTooDeeply},
+		{useBomb(32), TooManyElements},
+		{`<svg viewBox="0 0 24 24"><path fill-rule="evenodd" d="M0 0h10v10h-10v-10z"/></svg>`, nil},
+	}
+	for _, tc := range testCases {
+		var e encode.Encoder
+		if err := Parse(&e, []byte(tc.svg), nil); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.svg, err, tc.want)
+		}
+	}
+}
+
+// TestParseDefaultOptions tests that the SVG files in testdata convert with
+// nil Options.
+func TestParseDefaultOptions(t *testing.T) {
+	filenames, err := filepath.Glob(filepath.FromSlash("../testdata/*.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range filenames {
+		svgData, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var e encode.Encoder
+		if err := Parse(&e, svgData, nil); err != nil {
+			t.Errorf("%s: Parse: %v", filename, err)
+		}
+	}
+}
+
+// TestParseCrossingEvenOdd tests that a path with a fill-rule of evenodd
+// whose subpaths cross is filled as if its fill-rule were nonzero, unless
+// StrictEvenOdd is set.
+func TestParseCrossingEvenOdd(t *testing.T) {
+	const svg = `<svg viewBox="0 0 24 24"><path fill-rule="evenodd" d="M0 0h10v10H0zM5 5h10v10H5z"/></svg>`
+	var strict encode.Encoder
+	if err := Parse(&strict, []byte(svg), &Options{StrictEvenOdd: true}); err != generate.PathDataCrossingSubpaths {
+		t.Errorf("StrictEvenOdd: got %v, want %v", err, generate.PathDataCrossingSubpaths)
+	}
+	var e encode.Encoder
+	if err := Parse(&e, []byte(svg), nil); err != nil {
+		t.Fatal(err)
+	}
+	var want encode.Encoder
+	if err := Parse(&want, []byte(strings.Replace(svg, "evenodd", "nonzero", 1)), nil); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := e.Bytes()
+	if wantData, _ := want.Bytes(); string(got) != string(wantData) {
+		t.Errorf("got % x, want % x", got, wantData)
+	}
+}
+
+func render64(svg string) (image.Image, error) {
+	m := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	var z render.Renderer
+	z.SetRasterizer(&img.Rasterizer{Dst: m, DrawOp: draw.Src}, m.Bounds())
+	if err := Parse(&z, []byte(svg), nil); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TestParseEquivalent tests that pairs of SVG documents that describe the
+// same graphic in different ways are rendered alike.
+func TestParseEquivalent(t *testing.T) {
+	const svg = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 32 32">`
+	testCases := []struct {
+		a, b string
+	}{{
+		`<rect x="4" y="6" width="20" height="10" fill="red"/>`,
+		`<path d="M4 6h20v10H4z" fill="#f00"/>`,
+	}, {
+		`<rect x="4" y="6" width="20" height="10" rx="3" fill="rgb(0, 128, 0)"/>`,
+		`<path d="M7 6h14a3 3 0 0 1 3 3v4a3 3 0 0 1-3 3H7a3 3 0 0 1-3-3V9a3 3 0 0 1 3-3z" fill="green"/>`,
+	}, {
+		`<circle cx="16" cy="16" r="10" style="fill:blue; fill-opacity:0.5"/>`,
+		`<ellipse cx="16" cy="16" rx="10" ry="10" fill="#0000ff80"/>`,
+	}, {
+		`<polygon points="2,2 30,2 16,30"/>`,
+		`<polyline points="2 2 30 2 16 30" fill="black"/>`,
+	}, {
+		`<g transform="translate(16 16) scale(2)"><rect x="-4" y="-4" width="8" height="8"/></g>`,
+		`<rect x="8" y="8" width="16" height="16"/>`,
+	}, {
+		`<g fill="#123456" opacity="0.5"><g fill-opacity="0.5"><rect width="32" height="32"/></g></g>`,
+		`<rect width="32" height="32" fill="#123456" fill-opacity="0.25"/>`,
+	}, {
+		`<defs><rect id="r" width="8" height="8"/></defs><use href="#r" x="4" y="4"/><use xlink:href="#r" x="20" y="20"/>`,
+		`<rect x="4" y="4" width="8" height="8"/><rect x="20" y="20" width="8" height="8"/>`,
+	}, {
+		`<rect width="32" height="32" fill="red" display="none"/><rect width="32" height="32" fill="none"/><line x1="0" y1="0" x2="32" y2="32"/>`,
+		``,
+	}, {
+		`<linearGradient id="g"><stop offset="0" stop-color="red"/><stop offset="1" stop-color="blue"/></linearGradient>` +
+			`<rect x="8" y="0" width="16" height="32" fill="url(#g)"/>`,
+		`<linearGradient id="g" gradientUnits="userSpaceOnUse" x1="8" x2="24"><stop offset="0" stop-color="red"/><stop offset="1" stop-color="blue"/></linearGradient>` +
+			`<rect x="8" y="0" width="16" height="32" fill="url(#g)"/>`,
+	}, {
+		`<radialGradient id="g" gradientUnits="userSpaceOnUse" cx="16" cy="16" r="8" gradientTransform="translate(-16 -16) scale(2)"><stop offset="0.5" stop-color="red"/><stop offset="50%" stop-color="blue"/></radialGradient>` +
+			`<rect width="32" height="32" fill="url(#g)"/>`,
+		`<radialGradient id="h" gradientUnits="userSpaceOnUse" cx="16" cy="16" r="16"><stop offset="0.5" stop-color="red"/><stop offset="0.5" stop-color="blue"/></radialGradient>` +
+			`<radialGradient id="g" xlink:href="#h"/>` +
+			`<rect width="32" height="32" fill="url(#g)"/>`,
+	}, {
+		`<rect width="32" height="32" fill="url(#missing) yellow"/><rect width="16" height="16" fill="url(#missing)"/>`,
+		`<rect width="32" height="32" fill="yellow"/>`,
+	}, {
+		`<line x1="4" y1="8" x2="28" y2="8" stroke="red" stroke-width="4"/>`,
+		`<rect x="4" y="6" width="24" height="4" fill="red"/>`,
+	}, {
+		`<polyline points="4 4 28 4 28 28" fill="none" stroke="blue" stroke-width="4" stroke-linecap="square" stroke-linejoin="bevel"/>`,
+		`<path d="M2 2H28L30 4V30H26V6H2z" fill="blue"/>`,
+	}, {
+		`<g style="stroke:currentColor; stroke-width:2; stroke-linecap:round" color="green" fill="none"><path d="M8 16h16"/></g>`,
+		`<path d="M8 15h16a1 1 0 0 1 0 2H8a1 1 0 0 1 0-2z" fill="green"/>`,
+	}, {
+		`<g transform="scale(2)"><line x1="2" y1="4" x2="14" y2="4" stroke="black"/></g>`,
+		`<rect x="4" y="7" width="24" height="2"/>`,
+	}, {
+		`<rect x="8" y="8" width="16" height="16" fill="none" stroke="#000" stroke-opacity="0.5" stroke-width="4"/>`,
+		`<path d="M6 6h20v20H6zM10 10v12h12V10z" fill="#00000080"/>`,
+	}, {
+		`<path fill-rule="evenodd" d="M2 2h28v28H2zM6 6h20v20H6zM10 10h12v12H10z"/>`,
+		`<path d="M2 2h28v28H2zM6 6v20h20V6zM10 10h12v12H10z"/>`,
+	}, {
+		`<g style="fill-rule:evenodd"><path d="M16 4a12 12 0 1 1 0 24a12 12 0 1 1 0-24zM16 10a6 6 0 1 1 0 12a6 6 0 1 1 0-12z"/></g>`,
+		`<path d="M16 4a12 12 0 1 1 0 24a12 12 0 1 1 0-24zM16 10a6 6 0 1 0 0 12a6 6 0 1 0 0-12z"/>`,
+	}, {
+		`<g fill-rule="evenodd"><path fill-rule="nonzero" d="M4 4h24v24H4zM10 10h12v12H10z"/></g>`,
+		`<rect x="4" y="4" width="24" height="24"/>`,
+	}}
+	for _, tc := range testCases {
+		a, err := render64(svg + tc.a + `</svg>`)
+		if err != nil {
+			t.Errorf("%s: %v", tc.a, err)
+			continue
+		}
+		b, err := render64(svg + tc.b + `</svg>`)
+		if err != nil {
+			t.Errorf("%s: %v", tc.b, err)
+			continue
+		}
+		if err := imagetest.CheckApproxEqual(a, b); err != nil {
+			t.Errorf("%s\n%s\n%v", tc.a, tc.b, err)
+		}
+	}
+}
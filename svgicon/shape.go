@@ -0,0 +1,119 @@
+package svgicon
+
+import (
+	"math"
+)
+
+// shapePathData returns the SVG path data for the basic shape element n: a
+// <rect>, <circle>, <ellipse>, <line>, <polygon> or <polyline>. The path
+// data of a line or a polyline is open, that of the other shapes is closed.
+// It returns an empty string when the shape is not rendered.
+func (p *parser) shapePathData(n *node) (string, error) {
+	var args [6]float32
+	lengths := func(names ...string) error {
+		diag := float32(math.Sqrt(float64(p.width*p.width+p.height*p.height) / 2))
+		for i, name := range names {
+			ref := diag
+			switch name {
+			case "x", "cx", "rx", "width", "x1", "x2":
+				ref = p.width
+			case "y", "cy", "ry", "height", "y1", "y2":
+				ref = p.height
+			}
+			v, err := p.length(n, name, ref)
+			if err != nil {
+				return err
+			}
+			args[i] = v
+		}
+		return nil
+	}
+
+	var b []byte
+	switch n.name {
+	case "rect":
+		if err := lengths("x", "y", "width", "height", "rx", "ry"); err != nil {
+			return "", err
+		}
+		x, y, w, h, rx, ry := args[0], args[1], args[2], args[3], args[4], args[5]
+		if !(w > 0) || !(h > 0) {
+			return "", nil
+		}
+		// A missing or negative radius takes the value of the other radius.
+		if _, ok := n.attrs["rx"]; !ok || rx < 0 {
+			rx = ry
+		}
+		if _, ok := n.attrs["ry"]; !ok || ry < 0 {
+			ry = rx
+		}
+		rx, ry = clamp(rx, 0, w/2), clamp(ry, 0, h/2)
+		if rx == 0 || ry == 0 {
+			b = formatPathData(b, 'M', x, y)
+			b = formatPathData(b, 'H', x+w)
+			b = formatPathData(b, 'V', y+h)
+			b = formatPathData(b, 'H', x)
+		} else {
+			b = formatPathData(b, 'M', x+rx, y)
+			b = formatPathData(b, 'H', x+w-rx)
+			b = formatPathData(b, 'A', rx, ry, 0, 0, 1, x+w, y+ry)
+			b = formatPathData(b, 'V', y+h-ry)
+			b = formatPathData(b, 'A', rx, ry, 0, 0, 1, x+w-rx, y+h)
+			b = formatPathData(b, 'H', x+rx)
+			b = formatPathData(b, 'A', rx, ry, 0, 0, 1, x, y+h-ry)
+			b = formatPathData(b, 'V', y+ry)
+			b = formatPathData(b, 'A', rx, ry, 0, 0, 1, x+rx, y)
+		}
+	case "circle", "ellipse":
+		var err error
+		if n.name == "circle" {
+			err = lengths("cx", "cy", "r")
+			args[3] = args[2]
+		} else {
+			err = lengths("cx", "cy", "rx", "ry")
+		}
+		if err != nil {
+			return "", err
+		}
+		cx, cy, rx, ry := args[0], args[1], args[2], args[3]
+		if !(rx > 0) || !(ry > 0) {
+			return "", nil
+		}
+		b = formatPathData(b, 'M', cx-rx, cy)
+		b = formatPathData(b, 'A', rx, ry, 0, 1, 1, cx+rx, cy)
+		b = formatPathData(b, 'A', rx, ry, 0, 1, 1, cx-rx, cy)
+	case "line":
+		if err := lengths("x1", "y1", "x2", "y2"); err != nil {
+			return "", err
+		}
+		b = formatPathData(b, 'M', args[0], args[1])
+		b = formatPathData(b, 'L', args[2], args[3])
+		return string(b), nil
+	case "polygon", "polyline":
+		// A polyline is filled as if it were closed, like a polygon, but its
+		// stroke is open.
+		points, err := parseNumbers(n.attrs["points"])
+		if err != nil {
+			return "", err
+		}
+		if len(points) < 4 {
+			return "", nil
+		}
+		b = formatPathData(b, 'M', points[0], points[1])
+		for i := 2; i+1 < len(points); i += 2 {
+			b = formatPathData(b, 'L', points[i], points[i+1])
+		}
+		if n.name == "polyline" {
+			return string(b), nil
+		}
+	}
+	return string(append(b, 'z')), nil
+}
+
+func clamp(f, lo, hi float32) float32 {
+	if f < lo {
+		return lo
+	} else if f > hi {
+		return hi
+	}
+	return f
+}
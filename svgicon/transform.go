@@ -0,0 +1,83 @@
+package svgicon
+
+import (
+	"math"
+	"strings"
+
+	"github.com/reactivego/ivg/generate"
+)
+
+var identity = generate.Aff3{1, 0, 0, 0, 1, 0}
+
+// parseTransform parses an SVG transform list, like "translate(10 20)
+// rotate(45)", into a single matrix that applies the rightmost transform
+// first.
+func parseTransform(s string) (generate.Aff3, error) {
+	m := identity
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t\n\r,") {
+		i := strings.IndexByte(s, '(')
+		j := strings.IndexByte(s, ')')
+		if i < 0 || j < i {
+			return identity, InvalidTransform
+		}
+		name := strings.TrimSpace(s[:i])
+		args, err := parseNumbers(s[i+1 : j])
+		if err != nil {
+			return identity, err
+		}
+		s = s[j+1:]
+
+		var t generate.Aff3
+		switch {
+		case name == "matrix" && len(args) == 6:
+			t = generate.Aff3{
+				args[0], args[2], args[4],
+				args[1], args[3], args[5],
+			}
+		case name == "translate" && len(args) == 1:
+			t = generate.Translate(args[0], 0)
+		case name == "translate" && len(args) == 2:
+			t = generate.Translate(args[0], args[1])
+		case name == "scale" && (len(args) == 1 || len(args) == 2):
+			t = generate.Scale(args...)
+		case name == "rotate" && len(args) == 1:
+			t = rotate(args[0])
+		case name == "rotate" && len(args) == 3:
+			t = generate.Concat(generate.Translate(-args[1], -args[2]), rotate(args[0]), generate.Translate(args[1], args[2]))
+		case name == "skewX" && len(args) == 1:
+			t = generate.Aff3{1, tan(args[0]), 0, 0, 1, 0}
+		case name == "skewY" && len(args) == 1:
+			t = generate.Aff3{1, 0, 0, tan(args[0]), 1, 0}
+		default:
+			return identity, InvalidTransform
+		}
+		m = generate.Concat(t, m)
+	}
+	return m, nil
+}
+
+func rotate(degrees float32) generate.Aff3 {
+	sin, cos := math.Sincos(float64(degrees) * math.Pi / 180)
+	return generate.Aff3{
+		float32(cos), float32(-sin), 0,
+		float32(sin), float32(cos), 0,
+	}
+}
+
+func tan(degrees float32) float32 {
+	return float32(math.Tan(float64(degrees) * math.Pi / 180))
+}
+
+// invert returns the inverse of m, and false if m is not invertible.
+func invert(m generate.Aff3) (generate.Aff3, bool) {
+	det := float64(m[0])*float64(m[4]) - float64(m[1])*float64(m[3])
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return identity, false
+	}
+	a, b, c := float64(m[0]), float64(m[1]), float64(m[2])
+	d, e, f := float64(m[3]), float64(m[4]), float64(m[5])
+	return generate.Aff3{
+		float32(e / det), float32(-b / det), float32((b*f - c*e) / det),
+		float32(-d / det), float32(a / det), float32((c*d - a*f) / det),
+	}, true
+}
@@ -0,0 +1,158 @@
+package svgicon
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/reactivego/ivg"
+	"golang.org/x/image/colornames"
+)
+
+// parseNumbers parses a list of numbers separated by white space and/or
+// commas, like the value of a points or viewBox attribute.
+func parseNumbers(s string) ([]float32, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	numbers := make([]float32, 0, len(fields))
+	for _, field := range fields {
+		// A field like "1-2" or "1.5.5" holds more than one number.
+		for field != "" {
+			j := 1
+			for dot, exp := strings.HasPrefix(field, "."), false; j < len(field); j++ {
+				c := field[j]
+				if c == '.' && !dot && !exp {
+					dot = true
+				} else if (c == 'e' || c == 'E') && !exp {
+					exp = true
+				} else if (c == '-' || c == '+') && (field[j-1] == 'e' || field[j-1] == 'E') {
+					continue
+				} else if c < '0' || '9' < c {
+					break
+				}
+			}
+			f, err := strconv.ParseFloat(field[:j], 64)
+			if err != nil {
+				return nil, InvalidNumber
+			}
+			numbers = append(numbers, float32(f))
+			field = field[j:]
+		}
+	}
+	return numbers, nil
+}
+
+// parseLength parses a length or coordinate. A percentage is relative to
+// ref. The absolute units are converted to user units at 96 per inch.
+func parseLength(s string, ref float32) (float32, error) {
+	scale := float32(1)
+	switch {
+	case strings.HasSuffix(s, "%"):
+		s, scale = s[:len(s)-1], ref/100
+	case strings.HasSuffix(s, "px"):
+		s = s[:len(s)-2]
+	case strings.HasSuffix(s, "pt"):
+		s, scale = s[:len(s)-2], 96.0/72
+	case strings.HasSuffix(s, "pc"):
+		s, scale = s[:len(s)-2], 96.0/6
+	case strings.HasSuffix(s, "mm"):
+		s, scale = s[:len(s)-2], 96.0/25.4
+	case strings.HasSuffix(s, "cm"):
+		s, scale = s[:len(s)-2], 96.0/2.54
+	case strings.HasSuffix(s, "in"):
+		s, scale = s[:len(s)-2], 96
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 32)
+	if err != nil {
+		return 0, InvalidNumber
+	}
+	return float32(f) * scale, nil
+}
+
+// parseOpacity parses an opacity or an offset, either a number or a
+// percentage, and clamps it to the range [0, 1].
+func parseOpacity(s string) (float32, error) {
+	f, err := parseLength(s, 1)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 {
+		return 0, nil
+	} else if f > 1 {
+		return 1, nil
+	}
+	return f, nil
+}
+
+// parseColor parses a color in one of the forms "#rgb", "#rrggbb", "#rgba",
+// "#rrggbbaa", "rgb(r, g, b)", "rgba(r, g, b, a)" or a color keyword.
+func parseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]}) + strings.Repeat(hex[3:], 2)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		if len(hex) != 8 {
+			return color.NRGBA{}, InvalidColor
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return color.NRGBA{}, InvalidColor
+		}
+		return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+	}
+	if i := strings.IndexByte(s, '('); i >= 0 && strings.HasSuffix(s, ")") {
+		name := strings.ToLower(strings.TrimSpace(s[:i]))
+		args := strings.FieldsFunc(s[i+1:len(s)-1], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/'
+		})
+		if (name != "rgb" && name != "rgba") || (len(args) != 3 && len(args) != 4) {
+			return color.NRGBA{}, InvalidColor
+		}
+		var c [4]uint8
+		c[3] = 0xff
+		for j, arg := range args {
+			ref := float32(255)
+			if j == 3 {
+				ref = 1
+			}
+			f, err := parseLength(arg, ref)
+			if err != nil {
+				return color.NRGBA{}, InvalidColor
+			}
+			if j == 3 {
+				f *= 255
+			}
+			if f < 0 {
+				f = 0
+			} else if f > 255 {
+				f = 255
+			}
+			c[j] = uint8(f + 0.5)
+		}
+		return color.NRGBA{c[0], c[1], c[2], c[3]}, nil
+	}
+	if name := strings.ToLower(s); name == "transparent" {
+		return color.NRGBA{}, nil
+	} else if c, ok := colornames.Map[name]; ok {
+		return color.NRGBA(c), nil
+	}
+	return color.NRGBA{}, InvalidColor
+}
+
+// scaleAlpha returns c with its alpha multiplied by opacity.
+func scaleAlpha(c color.NRGBA, opacity float32) color.NRGBA {
+	c.A = uint8(float32(c.A)*opacity + 0.5)
+	return c
+}
+
+// rgbaColor returns c as an IconVG color, which is alpha-premultiplied.
+func rgbaColor(c color.NRGBA) ivg.Color {
+	r, g, b, a := c.RGBA()
+	return ivg.RGBAColor(color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+}